@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+	"github.com/paologalligit/justified/pkg/client"
+	"github.com/paologalligit/justified/pkg/monitor"
+)
+
+// auditBatchSize is the number of blocks fetched concurrently while auditing.
+const auditBatchSize = 64
+
+// maxAuditFindings is the number of findings per kind printed in the summary.
+const maxAuditFindings = 20
+
+type auditReport struct {
+	From, To  uint32
+	Finalized uint32 // when the walk started.
+	Audited   int
+	Linkage   []string
+	Flags     []string
+	Alignment []string
+	Fetch     []string
+
+	// FinalizedAfter is the finalized block when the walk completed, the
+	// blocks up to it being finalized during the walk.
+	FinalizedAfter uint32
+}
+
+func (r *auditReport) failed() bool {
+	return len(r.Linkage)+len(r.Flags)+len(r.Alignment)+len(r.Fetch) > 0
+}
+
+func (r *auditReport) print() {
+	fmt.Printf("Audited %d blocks in [%d, %d], finalized block %d\n", r.Audited, r.From, r.To, r.Finalized)
+	if r.FinalizedAfter != r.Finalized {
+		fmt.Printf("Finalized block %d when the walk completed\n", r.FinalizedAfter)
+	}
+	printFindings("Fetch errors", r.Fetch)
+	printFindings("Parent linkage errors", r.Linkage)
+	printFindings("isFinalized flag errors", r.Flags)
+	printFindings("Checkpoint alignment errors", r.Alignment)
+	if !r.failed() {
+		fmt.Println("No inconsistencies found")
+	}
+}
+
+func printFindings(title string, findings []string) {
+	fmt.Printf("%s: %d\n", title, len(findings))
+	for i, finding := range findings {
+		if i == maxAuditFindings {
+			fmt.Printf("  ... and %d more\n", len(findings)-maxAuditFindings)
+			break
+		}
+		fmt.Println("  " + finding)
+	}
+}
+
+// runAudit walks a block range verifying parent linkage, isFinalized flags and
+// that the finalized boundary falls on a checkpoint.
+func runAudit(args []string) int {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to the JSON configuration file")
+	network := fs.String("network", "", "preset of a known network filling the unset settings: "+strings.Join(monitor.NetworkNames(), ", "))
+	from := fs.Uint("from", 0, "first block of the range")
+	to := fs.Uint("to", 0, "last block of the range, defaults to the best block")
+	nodeName := fs.String("node", "", "name of the node to audit, defaults to the first configured one")
+	pushURL := fs.String("pushgateway", "", "Pushgateway URL the audit metrics are pushed to, overrides the config")
+	fs.Parse(args)
+
+	cfg, clients, _, err := monitor.Setup(*configPath, *network)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	node := clients[0]
+	ctx := context.Background()
+	if *nodeName != "" {
+		if node = client.Find(clients, *nodeName); node == nil {
+			fmt.Printf("Unknown node %q\n", *nodeName)
+			return 1
+		}
+	}
+
+	finalized, err := node.GetFinalizedBlock(ctx)
+	if err != nil {
+		fmt.Println("Error getting finalized block: ", err)
+		return 1
+	}
+	last := uint32(*to)
+	if last == 0 {
+		best, err := node.GetBestBlock(ctx)
+		if err != nil {
+			fmt.Println("Error getting best block: ", err)
+			return 1
+		}
+		last = best.Number
+	}
+	if uint32(*from) > last {
+		fmt.Printf("Invalid range: from %d is greater than to %d\n", *from, last)
+		return 1
+	}
+
+	start := time.Now()
+	report := audit(ctx, node, uint32(*from), last, finalized.Number, cfg.Thresholds.CheckpointInterval)
+	report.print()
+
+	if *pushURL != "" {
+		cfg.Pushgateway.URL = *pushURL
+	}
+	if cfg.Pushgateway.URL != "" {
+		if err := pushAuditMetrics(cfg.Pushgateway, node.Name, report, time.Since(start)); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+	}
+
+	if report.failed() {
+		return 1
+	}
+	return 0
+}
+
+func audit(ctx context.Context, node *client.Client, from, to, finalized, interval uint32) *auditReport {
+	report := &auditReport{From: from, To: to, Finalized: finalized}
+	progress := newProgressBar(int(to-from) + 1)
+
+	var prev *client.JSONBlockSummary
+	var ahead []uint32 // blocks flagged finalized above the finalized block.
+	for start := uint64(from); start <= uint64(to); start += auditBatchSize {
+		end := uint32(min(start+auditBatchSize-1, uint64(to)))
+		blocks, err := node.GetBlockRange(ctx, uint32(start), end)
+		if err != nil {
+			// Fetch the batch block by block to tell which ones failed.
+			blocks = nil
+			for n := uint32(start); n <= end; n++ {
+				block, err := node.GetBlockByNumber(ctx, n)
+				if err != nil {
+					report.Fetch = append(report.Fetch, fmt.Sprintf("block %d: %v", n, err))
+					continue
+				}
+				blocks = append(blocks, block)
+			}
+		}
+
+		for i := range blocks {
+			if prev != nil && prev.Number+1 != blocks[i].Number {
+				prev = nil
+			}
+			report.Audited++
+			if blocks[i].IsFinalized && blocks[i].Number > finalized {
+				ahead = append(ahead, blocks[i].Number)
+			}
+			auditBlock(report, prev, blocks[i], interval)
+			prev = &blocks[i]
+		}
+		progress.add(int(end-uint32(start)) + 1)
+	}
+	progress.done()
+
+	// The blocks finalized during the walk may be flagged either way, only
+	// the ones flagged above the finalized block when it completed are wrong.
+	report.FinalizedAfter = finalized
+	if block, err := node.GetFinalizedBlock(ctx); err != nil {
+		report.Fetch = append(report.Fetch, fmt.Sprintf("finalized block after the walk: %v", err))
+	} else {
+		report.FinalizedAfter = max(block.Number, finalized)
+	}
+	for _, n := range ahead {
+		if n > report.FinalizedAfter {
+			report.Flags = append(report.Flags, fmt.Sprintf("block %d: isFinalized is true, finalized block is %d", n, report.FinalizedAfter))
+		}
+	}
+	return report
+}
+
+func auditBlock(report *auditReport, prev *client.JSONBlockSummary, block client.JSONBlockSummary, interval uint32) {
+	if !block.IsFinalized && block.Number <= report.Finalized {
+		report.Flags = append(report.Flags, fmt.Sprintf("block %d: isFinalized is false, finalized block is %d", block.Number, report.Finalized))
+	}
+	if prev == nil {
+		return
+	}
+	if block.ParentID != prev.ID {
+		report.Linkage = append(report.Linkage, fmt.Sprintf("block %d: parentID %s does not match block %d ID %s", block.Number, block.ParentID, prev.Number, prev.ID))
+	}
+	if prev.IsFinalized && !block.IsFinalized && !checks.IsCheckPoint(prev.Number, interval) {
+		report.Alignment = append(report.Alignment, fmt.Sprintf("block %d: last finalized block is not a checkpoint", prev.Number))
+	}
+}
+
+// progressBar renders the progress of a long walk on stderr.
+type progressBar struct {
+	total, current, shown int
+}
+
+const progressBarWidth = 40
+
+func newProgressBar(total int) *progressBar {
+	p := &progressBar{total: total, shown: -1}
+	p.render()
+	return p
+}
+
+func (p *progressBar) add(n int) {
+	p.current += n
+	p.render()
+}
+
+func (p *progressBar) render() {
+	percent := 100
+	if p.total > 0 {
+		percent = p.current * 100 / p.total
+	}
+	if percent == p.shown {
+		return
+	}
+	p.shown = percent
+	filled := percent * progressBarWidth / 100
+	fmt.Fprintf(os.Stderr, "\r[%s%s] %3d%% (%d/%d)", strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled), percent, p.current, p.total)
+}
+
+func (p *progressBar) done() {
+	fmt.Fprintln(os.Stderr)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/client"
+	"github.com/paologalligit/justified/pkg/monitor"
+)
+
+// canaryReport collects the divergences of a candidate node from a reference node.
+type canaryReport struct {
+	Candidate, Reference string
+	Cycles               int
+	MaxJustifiedLag      int64 // blocks the candidate was behind the reference at most.
+	MaxFinalizedLag      int64
+	BlockIDs             []string
+	Progression          []string
+	Fetch                []string
+
+	prev *canaryHeads // of the candidate in the previous cycle.
+}
+
+func (r *canaryReport) failed() bool {
+	return r.Cycles == 0 || len(r.BlockIDs)+len(r.Progression)+len(r.Fetch) > 0
+}
+
+func (r *canaryReport) print() {
+	fmt.Printf("Compared candidate %s with reference %s over %d poll cycles\n", r.Candidate, r.Reference, r.Cycles)
+	fmt.Printf("Max lag of the candidate: justified %d blocks, finalized %d blocks\n", r.MaxJustifiedLag, r.MaxFinalizedLag)
+	printFindings("Fetch errors", r.Fetch)
+	printFindings("Block ID divergences", r.BlockIDs)
+	printFindings("Progression divergences", r.Progression)
+	if r.failed() {
+		fmt.Println("Canary FAILED")
+	} else {
+		fmt.Println("Canary PASSED")
+	}
+}
+
+// runCanary monitors a candidate node side by side with a reference node,
+// exiting with 0 when the candidate never diverged from the reference and
+// with 1 otherwise.
+func runCanary(args []string) int {
+	fs := flag.NewFlagSet("canary", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to the JSON configuration file")
+	network := fs.String("network", "", "preset of a known network filling the unset settings: "+strings.Join(monitor.NetworkNames(), ", "))
+	candidateName := fs.String("candidate", "", "name of the node under validation")
+	referenceName := fs.String("reference", "", "name of the node the candidate is compared with, defaults to the first other configured one")
+	duration := fs.Duration("duration", 10*time.Minute, "how long to compare the nodes for")
+	fs.Parse(args)
+
+	if *candidateName == "" {
+		fmt.Println("Missing -candidate")
+		return 1
+	}
+
+	cfg, clients, _, err := monitor.Setup(*configPath, *network)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	candidate := client.Find(clients, *candidateName)
+	if candidate == nil {
+		fmt.Printf("Unknown node %q\n", *candidateName)
+		return 1
+	}
+	var reference *client.Client
+	if *referenceName != "" {
+		reference = client.Find(clients, *referenceName)
+	} else {
+		for _, c := range clients {
+			if c != candidate {
+				reference = c
+				break
+			}
+		}
+	}
+	if reference == nil || reference == candidate {
+		fmt.Printf("Unknown reference node %q\n", *referenceName)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+
+	interval := time.Duration(cfg.BlockInterval) * time.Second
+	maxLag := int64(cfg.MaxNodeLagCheckpoints) * int64(cfg.Thresholds.CheckpointInterval)
+	fmt.Printf("Comparing candidate %s with reference %s for %s\n", candidate.Name, reference.Name, *duration)
+	report := canary(ctx, candidate, reference, interval, maxLag)
+	report.print()
+
+	if report.failed() {
+		return 1
+	}
+	return 0
+}
+
+// canary compares the candidate with the reference every interval until ctx
+// is done. The justified and finalized blocks of the candidate may lag the
+// reference ones by maxLag blocks at most.
+func canary(ctx context.Context, candidate, reference *client.Client, interval time.Duration, maxLag int64) *canaryReport {
+	report := &canaryReport{Candidate: candidate.Name, Reference: reference.Name}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return report
+		case <-ticker.C:
+		}
+		report.compare(ctx, candidate, reference, interval, maxLag)
+	}
+}
+
+// canaryHeads are the justified and finalized blocks of a node.
+type canaryHeads struct {
+	justified, finalized client.JSONBlockSummary
+}
+
+func getHeads(ctx context.Context, c *client.Client) (canaryHeads, error) {
+	justified, err := c.GetJustifiedBlock(ctx)
+	if err != nil {
+		return canaryHeads{}, fmt.Errorf("error getting justified block: %w", err)
+	}
+	finalized, err := c.GetFinalizedBlock(ctx)
+	if err != nil {
+		return canaryHeads{}, fmt.Errorf("error getting finalized block: %w", err)
+	}
+	return canaryHeads{justified: justified, finalized: finalized}, nil
+}
+
+// compare records the divergences of a poll cycle bounded by timeout. Cycles
+// the reference fails to answer are skipped, there being nothing to compare
+// the candidate with, and so is a cycle interrupted by the end of run.
+func (r *canaryReport) compare(run context.Context, candidate, reference *client.Client, timeout time.Duration, maxLag int64) {
+	ctx, cancel := context.WithTimeout(run, timeout)
+	defer cancel()
+	ref, refErr := getHeads(ctx, reference)
+	cand, candErr := getHeads(ctx, candidate)
+	if run.Err() != nil {
+		return
+	}
+	if refErr != nil {
+		fmt.Printf("Error polling reference %s, skipping the cycle: %v\n", reference.Name, refErr)
+		return
+	}
+	r.Cycles++
+	if candErr != nil {
+		r.Fetch = append(r.Fetch, fmt.Sprintf("cycle %d: %v", r.Cycles, candErr))
+		return
+	}
+
+	r.compareHead(ctx, candidate, reference, "justified", cand.justified, ref.justified, &r.MaxJustifiedLag, maxLag)
+	r.compareHead(ctx, candidate, reference, "finalized", cand.finalized, ref.finalized, &r.MaxFinalizedLag, maxLag)
+	if r.prev != nil {
+		if cand.justified.Number < r.prev.justified.Number {
+			r.Progression = append(r.Progression, fmt.Sprintf("cycle %d: justified block went back from %d to %d", r.Cycles, r.prev.justified.Number, cand.justified.Number))
+		}
+		if cand.finalized.Number < r.prev.finalized.Number {
+			r.Progression = append(r.Progression, fmt.Sprintf("cycle %d: finalized block went back from %d to %d", r.Cycles, r.prev.finalized.Number, cand.finalized.Number))
+		}
+	}
+	r.prev = &cand
+}
+
+// compareHead compares the named head block of the candidate with the one of
+// the reference: their heights must be maxLag blocks apart at most, and their
+// chains must agree on the block at the lower of the two heights.
+func (r *canaryReport) compareHead(ctx context.Context, candidate, reference *client.Client, name string, cand, ref client.JSONBlockSummary, maxObservedLag *int64, maxLag int64) {
+	lag := int64(ref.Number) - int64(cand.Number)
+	*maxObservedLag = max(*maxObservedLag, lag)
+	switch {
+	case lag > maxLag:
+		r.Progression = append(r.Progression, fmt.Sprintf("cycle %d: %s block %d is %d blocks behind the reference %d", r.Cycles, name, cand.Number, lag, ref.Number))
+	case -lag > maxLag:
+		r.Progression = append(r.Progression, fmt.Sprintf("cycle %d: %s block %d is %d blocks ahead of the reference %d", r.Cycles, name, cand.Number, -lag, ref.Number))
+	}
+
+	number := min(cand.Number, ref.Number)
+	candID, refID := cand.ID, ref.ID
+	switch {
+	case cand.Number > number:
+		block, err := candidate.GetBlockByNumber(ctx, number)
+		if err != nil {
+			r.Fetch = append(r.Fetch, fmt.Sprintf("cycle %d: error getting block %d: %v", r.Cycles, number, err))
+			return
+		}
+		candID = block.ID
+	case ref.Number > number:
+		block, err := reference.GetBlockByNumber(ctx, number)
+		if err != nil {
+			fmt.Printf("Error getting block %d from reference %s: %v\n", number, reference.Name, err)
+			return
+		}
+		refID = block.ID
+	}
+	if candID != refID {
+		r.BlockIDs = append(r.BlockIDs, fmt.Sprintf("cycle %d: %s block %d is %s on the candidate but %s on the reference", r.Cycles, name, number, candID, refID))
+	}
+}
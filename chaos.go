@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+	"github.com/paologalligit/justified/pkg/mocknode"
+	"github.com/paologalligit/justified/pkg/monitor"
+)
+
+// chaosFault is a fault injected into the mock node of a chaos run with the
+// checks expected to report it, any of which failing detects the fault.
+type chaosFault struct {
+	name   string
+	inject func(node *mocknode.Node)
+	expect []string
+}
+
+// chaosFaults are injected in order, each one cleared before the next.
+var chaosFaults = []chaosFault{
+	{
+		name:   "delayed responses",
+		inject: func(node *mocknode.Node) { node.Inject(mocknode.Fault{Delay: 3 * time.Second}) },
+		expect: []string{checks.FetchErrors},
+	},
+	{
+		name:   "5xx errors",
+		inject: func(node *mocknode.Node) { node.Inject(mocknode.Fault{Status: 503}) },
+		expect: []string{checks.FetchErrors},
+	},
+	{
+		name:   "stale data",
+		inject: func(node *mocknode.Node) { node.Inject(mocknode.Fault{Stale: true}) },
+		expect: []string{"chain-stalled"},
+	},
+	{
+		name:   "finality reversion",
+		inject: func(node *mocknode.Node) { node.Revert() },
+		// the monotonic checks fail too, but only the re-check of the
+		// finalized blocks tells a reversion from a node going backwards.
+		expect: []string{"finality-reversion"},
+	},
+}
+
+// runChaos monitors a mock node while injecting faults into it on a schedule,
+// exiting with 0 when the monitor detected every fault and with 1 otherwise.
+func runChaos(args []string) int {
+	fs := flag.NewFlagSet("chaos", flag.ExitOnError)
+	settle := fs.Duration("settle", 10*time.Second, "how long the node behaves before and after every fault")
+	timeout := fs.Duration("timeout", 30*time.Second, "how long a fault lasts at most before it is reported as undetected")
+	verbose := fs.Bool("v", false, "print the results of the monitor on top of its failed checks")
+	fs.Parse(args)
+
+	// A fast chain keeps the run short: a block per second and a checkpoint every 10 blocks.
+	node := mocknode.New(mocknode.Normal(), mocknode.Options{Best: 1000, BlockInterval: 1, CheckpointInterval: 10})
+	defer node.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go node.Run(ctx, time.Second)
+
+	cfg := monitor.DefaultConfig()
+	cfg.Nodes = []monitor.NodeConfig{{Name: "mock", URL: node.URL()}}
+	// The node is polled again as soon as a fault is cleared, and finalized
+	// blocks are re-fetched often enough to notice a reversion in time.
+	cfg.CircuitBreaker.Failures = 0
+	cfg.FinalityRecheckCycles = 2
+	cfg, clients, detected, err := monitor.SetupConfig(cfg)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	verbosity := monitor.VerbosityQuiet
+	if *verbose {
+		verbosity = monitor.VerbosityNormal
+	}
+	sink := &chaosSink{failed: make(map[string]time.Time)}
+	opts := monitor.Options{
+		Output:    "text",
+		Verbosity: verbosity,
+		DryRun:    true,
+		Sinks:     map[string]monitor.Sink{"chaos": sink},
+	}
+	m, err := monitor.New("", cfg, clients, detected, opts)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	detectedAll := make(chan bool, 1)
+	go func() {
+		defer m.Stop()
+		detectedAll <- chaosSchedule(ctx, node, sink, *settle, *timeout)
+	}()
+	m.Run()
+	cancel()
+
+	if !<-detectedAll {
+		return 1
+	}
+	return 0
+}
+
+// chaosSchedule injects every chaos fault in turn, reporting whether all of
+// them were detected.
+func chaosSchedule(ctx context.Context, node *mocknode.Node, sink *chaosSink, settle, timeout time.Duration) bool {
+	detected := 0
+	for _, fault := range chaosFaults {
+		if !sleep(ctx, settle) {
+			break
+		}
+		sink.reset()
+		fmt.Printf("Chaos: injecting %s\n", fault.name)
+		injected := time.Now()
+		fault.inject(node)
+		check, ok := sink.wait(ctx, fault.expect, timeout)
+		node.Inject(mocknode.Fault{})
+		if ctx.Err() != nil {
+			break
+		}
+		if ok {
+			detected++
+			fmt.Printf("Chaos: %s detected by %s after %s\n", fault.name, check, time.Since(injected).Round(time.Second))
+		} else {
+			fmt.Printf("Chaos: %s not detected by %s within %s\n", fault.name, strings.Join(fault.expect, ", "), timeout)
+		}
+	}
+	fmt.Printf("Chaos: %d of %d faults detected\n", detected, len(chaosFaults))
+	return detected == len(chaosFaults)
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// chaosSink records the checks failed since the last reset.
+type chaosSink struct {
+	mu     sync.Mutex
+	failed map[string]time.Time
+}
+
+func (s *chaosSink) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, outcome := range outcomes {
+		if _, ok := s.failed[outcome.Name]; !ok {
+			s.failed[outcome.Name] = time.Now()
+		}
+	}
+	return nil
+}
+
+func (s *chaosSink) Close() error {
+	return nil
+}
+
+func (s *chaosSink) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.failed)
+}
+
+// wait returns the first of the checks which failed since the last reset,
+// waiting up to timeout for one to fail.
+func (s *chaosSink) wait(ctx context.Context, names []string, timeout time.Duration) (string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if name, ok := s.first(names); ok {
+			return name, true
+		}
+		select {
+		case <-ctx.Done():
+			return "", false
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *chaosSink) first(names []string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var first string
+	var at time.Time
+	for _, name := range names {
+		if t, ok := s.failed[name]; ok && (first == "" || t.Before(at)) {
+			first, at = name, t
+		}
+	}
+	return first, first != ""
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// daemonEnv is set in the environment of the detached monitor, which must
+// not detach again.
+const daemonEnv = "JUSTIFIED_DAEMON"
+
+// defaultPidFile is where the pid of the detached monitor is written by default.
+const defaultPidFile = "justified.pid"
+
+// daemonStartup is how long the detached monitor must run before it is
+// considered started, to report the config errors to the operator.
+const daemonStartup = time.Second
+
+// detached reports whether the process is the detached monitor.
+func detached() bool {
+	return os.Getenv(daemonEnv) != ""
+}
+
+// daemonize runs the monitor again with the same arguments, detached into a
+// session of its own with its output appended to logPath and its errors to
+// errorLogPath, and writes its pid to pidPath. The monitor keeps the working
+// directory, so that relative paths keep resolving.
+func daemonize(pidPath, logPath, errorLogPath string) int {
+	if pid, err := readPidFile(pidPath); err == nil && alive(pid) {
+		fmt.Printf("Monitor already running as pid %d, see %s\n", pid, pidPath)
+		return 1
+	}
+
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		fmt.Println("Error opening log file: ", err)
+		return 1
+	}
+	defer logFile.Close()
+	errorLogFile := logFile
+	if errorLogPath != "" && errorLogPath != logPath {
+		if errorLogFile, err = os.OpenFile(errorLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644); err != nil {
+			fmt.Println("Error opening error log file: ", err)
+			return 1
+		}
+		defer errorLogFile.Close()
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Println("Error getting executable: ", err)
+		return 1
+	}
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), daemonEnv+"=1")
+	cmd.Stdout, cmd.Stderr = logFile, errorLogFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		fmt.Println("Error starting monitor: ", err)
+		return 1
+	}
+	if err := os.WriteFile(pidPath, []byte(strconv.Itoa(cmd.Process.Pid)+"\n"), 0o644); err != nil {
+		fmt.Println("Error writing pid file: ", err)
+		cmd.Process.Kill()
+		return 1
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	select {
+	case err := <-exited:
+		os.Remove(pidPath)
+		fmt.Printf("Monitor exited on startup (%v), see %s\n", err, errorLogFile.Name())
+		return 1
+	case <-time.After(daemonStartup):
+	}
+	fmt.Printf("Monitor running as pid %d, logging to %s\n", cmd.Process.Pid, logPath)
+	return 0
+}
+
+// runStop terminates the detached monitor of a pid file, waiting for it to exit.
+func runStop(args []string) int {
+	fs := flag.NewFlagSet("stop", flag.ExitOnError)
+	pidPath := fs.String("pidfile", defaultPidFile, "path of the pid file written by -daemon")
+	timeout := fs.Duration("timeout", 30*time.Second, "how long to wait for the monitor to exit before killing it")
+	fs.Parse(args)
+
+	pid, err := readPidFile(*pidPath)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	if !alive(pid) {
+		fmt.Printf("Monitor not running, removing stale pid file %s\n", *pidPath)
+		os.Remove(*pidPath)
+		return 0
+	}
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		fmt.Printf("Error stopping pid %d: %v\n", pid, err)
+		return 1
+	}
+	deadline := time.Now().Add(*timeout)
+	for alive(pid) {
+		if time.Now().After(deadline) {
+			fmt.Printf("Monitor did not exit within %s, killing pid %d\n", *timeout, pid)
+			syscall.Kill(pid, syscall.SIGKILL)
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	os.Remove(*pidPath)
+	fmt.Printf("Monitor stopped, pid %d\n", pid)
+	return 0
+}
+
+// runStatus reports whether the detached monitor of a pid file runs, exiting
+// like an LSB init script: with 0 when it does, 1 when the pid file is stale
+// and 3 when there is none.
+func runStatus(args []string) int {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	pidPath := fs.String("pidfile", defaultPidFile, "path of the pid file written by -daemon")
+	fs.Parse(args)
+
+	pid, err := readPidFile(*pidPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		fmt.Println("Monitor not running")
+		return 3
+	case err != nil:
+		fmt.Println(err)
+		return 1
+	case !alive(pid):
+		fmt.Printf("Monitor not running, stale pid file %s of pid %d\n", *pidPath, pid)
+		return 1
+	}
+	fmt.Printf("Monitor running as pid %d\n", pid)
+	return 0
+}
+
+func readPidFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("error reading pid file: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid pid file %s", path)
+	}
+	return pid, nil
+}
+
+// alive reports whether the process pid exists.
+func alive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paologalligit/justified/pkg/monitor"
+)
+
+// runDashboard prints a Grafana dashboard of the metrics of the monitor, or
+// writes it to -out, ready to be imported.
+func runDashboard(args []string) int {
+	fs := flag.NewFlagSet("dashboard", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to the JSON configuration file, whose chains the dashboard is filtered by")
+	network := fs.String("network", "", "preset of a known network filling the unset settings: "+strings.Join(monitor.NetworkNames(), ", "))
+	title := fs.String("title", "Justified", "title of the dashboard")
+	out := fs.String("out", "", "path the dashboard is written to, stdout when empty")
+	fs.Parse(args)
+
+	cfg, err := monitor.LoadConfig(*configPath, *network)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	dashboard, err := monitor.Dashboard(cfg, *title)
+	if err != nil {
+		fmt.Println("Error generating dashboard: ", err)
+		return 1
+	}
+	if *out == "" {
+		fmt.Println(string(dashboard))
+		return 0
+	}
+	if err := os.WriteFile(*out, dashboard, 0o644); err != nil {
+		fmt.Println("Error writing dashboard: ", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// logRotationCheck is how often the size and age of the log files are checked.
+const logRotationCheck = 10 * time.Second
+
+// backupTimeFormat is the suffix of the rotated log files.
+const backupTimeFormat = "20060102T150405"
+
+// logRotation bounds the log files of the detached monitor.
+type logRotation struct {
+	maxSize    int64         // of a log file in bytes before it is rotated, unbounded when 0.
+	maxAge     time.Duration // of a log file before it is rotated, unbounded when 0.
+	maxBackups int           // rotated files kept per log file, all when 0.
+	compress   bool          // gzip the rotated files.
+}
+
+// logFile is a log file the standard streams fds are written to.
+type logFile struct {
+	path   string
+	fds    []int
+	opened time.Time
+}
+
+// rotateLogs rotates the log files of the detached monitor until it exits:
+// the output to logPath and, when they are distinct files, the errors to
+// errorLogPath. A rotated file is renamed after the time of its rotation,
+// and the stream reopened on a new file at the path.
+func rotateLogs(r logRotation, logPath, errorLogPath string) {
+	files := []*logFile{{path: logPath, fds: []int{1, 2}, opened: time.Now()}}
+	if errorLogPath != "" && errorLogPath != logPath {
+		files = []*logFile{{path: logPath, fds: []int{1}, opened: time.Now()}, {path: errorLogPath, fds: []int{2}, opened: time.Now()}}
+	}
+	for range time.Tick(logRotationCheck) {
+		for _, f := range files {
+			info, err := os.Stat(f.path)
+			if err != nil {
+				fmt.Println("Error checking log file: ", err)
+				continue
+			}
+			if info.Size() == 0 || (r.maxSize == 0 || info.Size() < r.maxSize) && (r.maxAge == 0 || time.Since(f.opened) < r.maxAge) {
+				continue
+			}
+			backup, err := f.rotate()
+			if err != nil {
+				fmt.Println("Error rotating log file: ", err)
+				continue
+			}
+			go r.retain(f.path, backup)
+		}
+	}
+}
+
+// rotate renames the log file and points its fds to a new one, returning the
+// path of the rotated file.
+func (f *logFile) rotate() (string, error) {
+	backup := f.path + "." + time.Now().UTC().Format(backupTimeFormat)
+	if err := os.Rename(f.path, backup); err != nil {
+		return "", err
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	for _, fd := range f.fds {
+		if err := unix.Dup2(int(file.Fd()), fd); err != nil {
+			return "", fmt.Errorf("error redirecting fd %d: %w", fd, err)
+		}
+	}
+	f.opened = time.Now()
+	return backup, nil
+}
+
+// retain compresses the backup of the log file at path if enabled, then
+// removes the oldest backups beyond the limit.
+func (r logRotation) retain(path, backup string) {
+	if r.compress {
+		if err := gzipFile(backup); err != nil {
+			fmt.Println("Error compressing log file: ", err)
+		}
+	}
+	if r.maxBackups == 0 {
+		return
+	}
+	backups, err := filepath.Glob(path + ".*")
+	if err != nil {
+		fmt.Println("Error listing log files: ", err)
+		return
+	}
+	// The time suffixes sort in rotation order, compressed or not.
+	backups = slices.DeleteFunc(backups, func(name string) bool {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(name, path+"."), ".gz")
+		_, err := time.Parse(backupTimeFormat, suffix)
+		return err != nil
+	})
+	slices.Sort(backups)
+	for len(backups) > r.maxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			fmt.Println("Error removing log file: ", err)
+		}
+		backups = backups[1:]
+	}
+}
+
+// gzipFile replaces the file at path with its compressed copy at path.gz.
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp := path + ".gz.tmp"
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
@@ -1,223 +1,126 @@
 package main
 
 import (
-	"encoding/json"
-	"errors"
+	"flag"
 	"fmt"
-	"io"
-	"net/http"
-	"strconv"
-	"time"
-)
+	"os"
+	"strings"
 
-const (
-	BlockInterval            uint64 = 2 // time interval between two consecutive blocks.
-	InitialMaxBlockProposers uint64 = 4
-	CheckpointInterval              = 180 // blocks between two bft checkpoints.
-	NodeURL                         = "http://localhost:8689/"
-	AddressLength                   = 20
+	"github.com/paologalligit/justified/pkg/monitor"
 )
 
-type JSONBlockSummary struct {
-	Number      uint32 `json:"number"`
-	IsFinalized bool   `json:"isFinalized"`
-}
-
-type BlockResult struct {
-	Best           uint32
-	Justified      uint32
-	Finalized      uint32
-	AfterFinalized JSONBlockSummary
-	Error          []string
-}
-
-func (br BlockResult) String() string {
-	return fmt.Sprintf("Best: %d, Justified: %d, Finalized: %d, Error: %v", br.Best, br.Justified, br.Finalized, br.Error)
+// commands are the subcommands selected by the first argument. Without one the monitor runs.
+var commands = map[string]func(args []string) int{
+	"audit":     runAudit,
+	"canary":    runCanary,
+	"chaos":     runChaos,
+	"dashboard": runDashboard,
+	"report":    runReport,
+	"status":    runStatus,
+	"stop":      runStop,
+	"wait":      runWait,
 }
 
-func producer(ch chan<- BlockResult, client *http.Client) {
-	blockResult := &BlockResult{Error: make([]string, 0)}
-
-	for range time.Tick(time.Duration(BlockInterval) * time.Second) {
-		best, err := getBestBlock(client)
-		if err != nil {
-			fmt.Println("Error getting best block: ", err)
-			blockResult.Error = append(blockResult.Error, fmt.Sprint("Error getting best block: ", err))
-		}
-		blockResult.Best = best
-
-		justified, err := getJustifiedBlock(client)
-		if err != nil {
-			blockResult.Error = append(blockResult.Error, fmt.Sprint("Error getting justified block: ", err))
-		}
-		blockResult.Justified = justified
-
-		finalized, err := getFinalizedBlock(client)
-		if err != nil {
-			blockResult.Error = append(blockResult.Error, fmt.Sprint("Error getting finalized block: ", err))
-		}
-		blockResult.Finalized = finalized
-
-		afterFinalized, err := getBlockAfterFinalized(client, finalized)
-		if err != nil {
-			blockResult.Error = append(blockResult.Error, fmt.Sprint("Error getting before finalized block: ", err))
+func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			os.Exit(cmd(os.Args[2:]))
 		}
-		blockResult.AfterFinalized = afterFinalized
-
-		ch <- *blockResult
 	}
-}
 
-func getBestBlock(client *http.Client) (uint32, error) {
-	res, err := client.Get(NodeURL + "blocks/best")
-	if err != nil {
-		return 0, err
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("status code not 200: %s", res.Status)
+	configPath := flag.String("config", "", "path to the JSON configuration file")
+	network := flag.String("network", "", "preset of a known network filling the unset settings: "+strings.Join(monitor.NetworkNames(), ", "))
+	duration := flag.Duration("duration", 0, "run a soak test for this long, e.g. 6h, then print a verdict and exit with its code")
+	verbose := flag.Bool("v", false, "print every poll cycle")
+	debug := flag.Bool("vv", false, "print the full record of every poll cycle")
+	quiet := flag.Bool("q", false, "print only the failed checks")
+	maxCycles := flag.Int("max-cycles", 0, "stop after every node completed this many poll cycles, unbounded when 0")
+	dryRun := flag.Bool("dry-run", false, "log and alert violations but never exit on an exhausted error budget")
+	junitPath := flag.String("junit", "", "path of a JUnit XML report of the check results written on exit")
+	output := flag.String("output", "text", "format of the results printed to stdout: "+strings.Join(monitor.OutputFormats, ", "))
+	pprofAddr := flag.String("pprof", "", "address serving net/http/pprof, e.g. :6060 for localhost:6060, disabled when empty")
+	daemon := flag.Bool("daemon", false, "detach from the terminal, writing the pid to -pidfile and the output to -log, see the stop and status commands")
+	pidFile := flag.String("pidfile", defaultPidFile, "path of the pid file written with -daemon")
+	logFile := flag.String("log", "justified.log", "path of the file the output is appended to with -daemon")
+	errorLogFile := flag.String("error-log", "", "path of the file the errors are appended to with -daemon, defaults to -log")
+	logMaxSize := flag.Int64("log-max-size", 100, "size in MB of a log file before it is rotated with -daemon, unbounded when 0")
+	logMaxAge := flag.Duration("log-max-age", 0, "age of a log file before it is rotated with -daemon, e.g. 24h, unbounded when 0")
+	logMaxBackups := flag.Int("log-max-backups", 10, "rotated files kept per log file, all when 0")
+	logCompress := flag.Bool("log-compress", true, "gzip the rotated log files")
+	flag.Parse()
+
+	if *daemon {
+		if !detached() {
+			os.Exit(daemonize(*pidFile, *logFile, *errorLogFile))
+		}
+		rotation := logRotation{maxSize: *logMaxSize << 20, maxAge: *logMaxAge, maxBackups: *logMaxBackups, compress: *logCompress}
+		go rotateLogs(rotation, *logFile, *errorLogFile)
 	}
 
-	responseBody, err := io.ReadAll(res.Body)
-	if err != nil {
-		return 0, fmt.Errorf("error reading response body: %w", err)
+	if *pprofAddr != "" {
+		go servePprof(*pprofAddr)
 	}
 
-	var block JSONBlockSummary
-	if err = json.Unmarshal(responseBody, &block); err != nil {
-		return 0, fmt.Errorf("unable to unmarshall events - %w", err)
+	if *output == "jsonl" {
+		monitor.ReserveStdout()
 	}
 
-	return block.Number, nil
-}
-
-func getJustifiedBlock(client *http.Client) (uint32, error) {
-	res, err := client.Get(NodeURL + "blocks/justified")
-	if err != nil {
-		return 0, err
+	verbosity := monitor.VerbosityNormal
+	switch {
+	case *quiet:
+		verbosity = monitor.VerbosityQuiet
+	case *debug:
+		verbosity = monitor.VerbosityDebug
+	case *verbose:
+		verbosity = monitor.VerbosityVerbose
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("status code not 200: %s", res.Status)
-	}
+	defer monitor.ExitOnPanic()
 
-	responseBody, err := io.ReadAll(res.Body)
+	cfg, chains, err := monitor.SetupChains(*configPath, *network)
 	if err != nil {
-		return 0, fmt.Errorf("error reading response body: %w", err)
-	}
-
-	var block JSONBlockSummary
-	if err = json.Unmarshal(responseBody, &block); err != nil {
-		return 0, fmt.Errorf("unable to unmarshall events - %w", err)
+		monitor.Terminate(monitor.ExitConfigError, monitor.FatalRecord{Error: err.Error()})
 	}
 
-	return block.Number, nil
-}
-
-func getFinalizedBlock(client *http.Client) (uint32, error) {
-	res, err := client.Get(NodeURL + "blocks/finalized")
+	flushTraces, err := monitor.SetupTracing(cfg.Tracing)
 	if err != nil {
-		return 0, err
+		monitor.Terminate(monitor.ExitConfigError, monitor.FatalRecord{Error: err.Error()})
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("status code not 200: %s", res.Status)
+	opts := monitor.Options{
+		Network:   *network,
+		Output:    *output,
+		Verbosity: verbosity,
+		MaxCycles: *maxCycles,
+		DryRun:    *dryRun,
+		Duration:  *duration,
+		JUnit:     *junitPath != "",
 	}
-
-	responseBody, err := io.ReadAll(res.Body)
+	m, err := monitor.NewChains(*configPath, cfg, chains, opts)
 	if err != nil {
-		return 0, fmt.Errorf("error reading response body: %w", err)
+		monitor.Terminate(monitor.ExitConfigError, monitor.FatalRecord{Error: err.Error()})
 	}
-
-	var block JSONBlockSummary
-	if err = json.Unmarshal(responseBody, &block); err != nil {
-		return 0, fmt.Errorf("unable to unmarshall events - %w", err)
-	}
-
-	return block.Number, nil
-}
-
-func performChecks(r BlockResult) error {
-	if r.Error != nil {
-		return formatError(r.Error)
-	}
-
-	if r.Best < CheckpointInterval*2-1 {
-		if r.Justified != 0 || r.Finalized != 0 {
-			return fmt.Errorf("best block height less than 2 epochs - 1, justified and finalized block should be 0")
-		}
-	} else {
-		if r.Justified-r.Finalized != CheckpointInterval {
-			return fmt.Errorf("justified block number - finalized block number != CheckpointInterval")
+	outcome := m.Run()
+	flushTraces()
+	if *junitPath != "" {
+		if err := m.WriteJUnit(*junitPath); err != nil {
+			fmt.Println("Error writing JUnit report: ", err)
 		}
-		if CheckpointInterval-1 > r.Best-r.Justified || r.Best-r.Justified >= CheckpointInterval*2-1 {
-			return fmt.Errorf("179 <= head number - justified block number < 359")
-		}
-		if r.Best-r.Finalized < CheckpointInterval*2-1 || r.Best-r.Finalized >= CheckpointInterval*3-1 {
-			return fmt.Errorf("finalized block number out of bound")
-		}
-		if r.AfterFinalized.IsFinalized {
-			return fmt.Errorf("after finalized block number should not be finalized")
-		}
-	}
-
-	return nil
-}
-
-func getBlockAfterFinalized(client *http.Client, finalized uint32) (JSONBlockSummary, error) {
-	fmo := finalized + 1
-	res, err := client.Get(NodeURL + "blocks/" + strconv.Itoa(int(fmo)))
-
-	if err != nil {
-		return JSONBlockSummary{}, err
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		return JSONBlockSummary{}, fmt.Errorf("status code not 200: %s", res.Status)
-	}
-
-	responseBody, err := io.ReadAll(res.Body)
-	if err != nil {
-		return JSONBlockSummary{}, fmt.Errorf("error reading response body: %w", err)
 	}
-
-	var block JSONBlockSummary
-	if err = json.Unmarshal(responseBody, &block); err != nil {
-		return JSONBlockSummary{}, fmt.Errorf("unable to unmarshall events - %w", err)
+	if verdict, ok := m.Verdict(outcome); ok {
+		verdict.Print()
+		os.Exit(verdict.ExitCode)
 	}
-
-	return block, nil
-}
-
-func formatError(errs []string) error {
-	s := ""
-	for _, err := range errs {
-		s += err + "\n"
+	if outcome != nil {
+		monitor.BudgetExhausted(*outcome)
 	}
-	return errors.New(s)
-}
-
-func main() {
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	ch := make(chan BlockResult)
 
-	go producer(ch, client)
-
-	for blockResult := range ch {
-		if err := performChecks(blockResult); err != nil {
-			panic("Error while performing check: " + err.Error())
-		}
-	}
 	// go consumer()
 	// Poll each node every second for current block height at /blocks/best endpoint, if any error do nothing.
 	// Poll each node every second for new justified block at /blocks/justified endpoint, if any error do nothing.
 	// Poll each node every second for new finalized block at /blocks/finalized endpoint, if any error do nothing.
-	// If the client cannot receive a response for more than 10 seconds, then terminate the program.
+	// If the client cannot receive a response for more than 10 seconds, then terminate the program
+	// (noResponseSeconds and exitOnNoResponse in the config).
 	// Check justifed and finalized consistency.
 	/*
 		1. As long as the current block height is less than 180:
@@ -228,16 +131,3 @@ func main() {
 			- finalized block number >= 360.
 	*/
 }
-
-// func getCheckPoint(blockNum uint32) uint32 {
-// 	return blockNum / CheckpointInterval * CheckpointInterval
-// }
-
-// func isCheckPoint(blockNum uint32) bool {
-// 	return getCheckPoint(blockNum) == blockNum
-// }
-
-// // save quality at the end of round
-// func getStorePoint(blockNum uint32) uint32 {
-// 	return getCheckPoint(blockNum) + CheckpointInterval - 1
-// }
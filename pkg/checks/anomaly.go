@@ -0,0 +1,82 @@
+package checks
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	// anomalyMinSamples is how many poll cycles the baseline of the lag
+	// anomaly check covers at least before it reports anomalies.
+	anomalyMinSamples = 10
+	// anomalyMinDeviation is the standard deviation, in blocks, a steady
+	// baseline is assumed to have, so that a single block of drift is not
+	// reported as infinitely anomalous.
+	anomalyMinDeviation = 1.0
+)
+
+// lagBaseline is the recent deviation of a finality lag from the one expected
+// at the position of the best block in its epoch.
+type lagBaseline struct {
+	name      string
+	samples   *window
+	threshold float64 // z-score beyond which a deviation is anomalous.
+}
+
+// update compares the deviation of a poll cycle with the baseline, then adds
+// it to the baseline unless it is anomalous, so that a lasting anomaly keeps
+// being reported instead of becoming the new normal.
+func (b *lagBaseline) update(lag, expected int64) error {
+	deviation := float64(lag - expected)
+	mean, stddev := meanDeviation(b.samples.samples)
+	if len(b.samples.samples) >= anomalyMinSamples {
+		if z := (deviation - mean) / max(stddev, anomalyMinDeviation); math.Abs(z) > b.threshold {
+			return fmt.Errorf("%s lag %d blocks deviates from its baseline of %.1f blocks (z-score %.1f)", b.name, lag, float64(expected)+mean, z)
+		}
+	}
+	b.samples.add(deviation)
+	return nil
+}
+
+func meanDeviation(samples []float64) (mean, stddev float64) {
+	if len(samples) == 0 {
+		return 0, 0
+	}
+	for _, v := range samples {
+		mean += v
+	}
+	mean /= float64(len(samples))
+	for _, v := range samples {
+		stddev += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(stddev / float64(len(samples)))
+}
+
+// newLagAnomalyCheck warns when the justified or finalized lag deviates from
+// its recent baseline by more than cfg.AnomalyThreshold standard deviations,
+// which may happen well within the bounds of the lag checks. The lags are
+// compared once the sawtooth of the checkpoints is taken out: head - justified
+// grows by a block every block and drops by an epoch on every justification.
+func newLagAnomalyCheck(cfg Config) Check {
+	t := cfg.Thresholds
+	justified := &lagBaseline{name: "justified", samples: newWindow(cfg.GapWindow), threshold: cfg.AnomalyThreshold}
+	finalized := &lagBaseline{name: "finalized", samples: newWindow(cfg.GapWindow), threshold: cfg.AnomalyThreshold}
+
+	return Check{
+		Name:     "finality-lag-anomaly",
+		Severity: SeverityWarn,
+		Needs:    FieldBest | FieldJustified | FieldFinalized | FieldSynced,
+		Run: func(r BlockResult) error {
+			if cfg.AnomalyThreshold <= 0 || t.CheckpointInterval == 0 || int64(r.Best) < t.justificationStart() {
+				return nil
+			}
+			ci := int64(t.CheckpointInterval)
+			expected := ci - 1 + (int64(r.Best)+1)%ci
+			return errors.Join(
+				justified.update(int64(r.Best)-int64(r.Justified), expected),
+				finalized.update(int64(r.Best)-int64(r.Finalized), expected+ci),
+			)
+		},
+	}
+}
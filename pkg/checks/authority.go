@@ -0,0 +1,187 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+const (
+	// authorityAddress is the built-in contract holding the proposer candidates.
+	authorityAddress = "0x0000000000000000000000417574686f72697479"
+
+	selectorFirst = "0x3df4ddf4" // first()
+	selectorNext  = "0xab73e316" // next(address)
+	selectorGet   = "0xc2bc2efc" // get(address)
+
+	// maxAuthorityCandidates bounds the walk of the candidate list.
+	maxAuthorityCandidates = 1024
+)
+
+// AuthorityStats describes the proposer candidates registered in the authority contract.
+type AuthorityStats struct {
+	Listed  int // candidates in the list.
+	Active  int // candidates currently allowed to propose.
+	Added   []string
+	Removed []string
+}
+
+// authorityTracker periodically reads the authority contract and records
+// changes of the active proposer set.
+type authorityTracker struct {
+	client *client.Client
+	every  int
+	cycles int
+	active map[string]bool
+	latest *AuthorityStats
+}
+
+func newAuthorityTracker(client *client.Client, every int) *authorityTracker {
+	return &authorityTracker{client: client, every: every}
+}
+
+// update reads the contract every t.every calls and returns the latest stats,
+// nil until the contract was read once. Added and Removed are only set on the
+// call that observed the change.
+func (t *authorityTracker) update(ctx context.Context) (*AuthorityStats, error) {
+	if t.every <= 0 {
+		return nil, nil
+	}
+	t.cycles++
+	if t.latest != nil && (t.cycles-1)%t.every != 0 {
+		stats := *t.latest
+		stats.Added, stats.Removed = nil, nil
+		return &stats, nil
+	}
+
+	candidates, err := t.candidates(ctx)
+	if err != nil {
+		return t.latest, err
+	}
+
+	stats := &AuthorityStats{Listed: len(candidates)}
+	active := make(map[string]bool)
+	for addr, isActive := range candidates {
+		if isActive {
+			active[addr] = true
+		}
+	}
+	stats.Active = len(active)
+	if t.active != nil {
+		for addr := range active {
+			if !t.active[addr] {
+				stats.Added = append(stats.Added, addr)
+			}
+		}
+		for addr := range t.active {
+			if !active[addr] {
+				stats.Removed = append(stats.Removed, addr)
+			}
+		}
+		sort.Strings(stats.Added)
+		sort.Strings(stats.Removed)
+	}
+
+	t.active = active
+	t.latest = stats
+	return stats, nil
+}
+
+// candidates walks the candidate list and returns whether each one is active.
+func (t *authorityTracker) candidates(ctx context.Context) (map[string]bool, error) {
+	results, err := t.client.Inspect(ctx, []client.Clause{{To: authorityAddress, Value: "0x0", Data: selectorFirst}})
+	if err != nil {
+		return nil, fmt.Errorf("error calling authority first: %w", err)
+	}
+
+	var list []string
+	for {
+		addr, err := decodeAddress(results[0], 0)
+		if err != nil {
+			return nil, err
+		}
+		if addr == zeroAddress {
+			break
+		}
+		if len(list) == maxAuthorityCandidates {
+			return nil, fmt.Errorf("more than %d authority candidates", maxAuthorityCandidates)
+		}
+		list = append(list, addr)
+
+		results, err = t.client.Inspect(ctx, []client.Clause{{To: authorityAddress, Value: "0x0", Data: selectorNext + encodeAddress(addr)}})
+		if err != nil {
+			return nil, fmt.Errorf("error calling authority next: %w", err)
+		}
+	}
+	if len(list) == 0 {
+		return map[string]bool{}, nil
+	}
+
+	clauses := make([]client.Clause, 0, len(list))
+	for _, addr := range list {
+		clauses = append(clauses, client.Clause{To: authorityAddress, Value: "0x0", Data: selectorGet + encodeAddress(addr)})
+	}
+	results, err = t.client.Inspect(ctx, clauses)
+	if err != nil {
+		return nil, fmt.Errorf("error calling authority get: %w", err)
+	}
+
+	candidates := make(map[string]bool, len(list))
+	for i, addr := range list {
+		// get returns (bool listed, address endorsor, bytes32 identity, bool active).
+		active, err := decodeBool(results[i], 3)
+		if err != nil {
+			return nil, err
+		}
+		candidates[addr] = active
+	}
+	return candidates, nil
+}
+
+func encodeAddress(addr string) string {
+	return strings.Repeat("0", 24) + strings.TrimPrefix(strings.ToLower(addr), "0x")
+}
+
+// word returns the index-th 32 bytes word of the call output, hex encoded.
+func word(r client.CallResult, index int) (string, error) {
+	if r.Reverted || r.VMError != "" {
+		return "", errors.New("contract call reverted: " + r.VMError)
+	}
+	data := strings.TrimPrefix(r.Data, "0x")
+	if len(data) < (index+1)*64 {
+		return "", fmt.Errorf("contract call returned %d bytes, expected at least %d", len(data)/2, (index+1)*32)
+	}
+	return data[index*64 : (index+1)*64], nil
+}
+
+func decodeAddress(r client.CallResult, index int) (string, error) {
+	w, err := word(r, index)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + w[24:], nil
+}
+
+func decodeUint64(r client.CallResult, index int) (uint64, error) {
+	w, err := word(r, index)
+	if err != nil {
+		return 0, err
+	}
+	if strings.TrimLeft(w[:48], "0") != "" {
+		return 0, fmt.Errorf("contract call returned %s, larger than 64 bits", w)
+	}
+	return strconv.ParseUint(w[48:], 16, 64)
+}
+
+func decodeBool(r client.CallResult, index int) (bool, error) {
+	w, err := word(r, index)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimLeft(w, "0") != "", nil
+}
@@ -0,0 +1,96 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// bftTracker fetches the consensus state of a node once per checkpoint round,
+// when the voting of the previous round completed.
+type bftTracker struct {
+	client   *client.Client
+	path     string
+	interval uint32
+	round    uint32 // first block of the round the state was fetched in.
+	latest   *client.BFTState
+}
+
+func newBFTTracker(client *client.Client, path string, interval uint32) *bftTracker {
+	return &bftTracker{client: client, path: path, interval: interval}
+}
+
+// update fetches the state once the best block entered a new round and
+// returns the latest state, nil until it was fetched once or when the
+// endpoint is not configured.
+func (t *bftTracker) update(ctx context.Context, best uint32) (*client.BFTState, error) {
+	if t.path == "" || t.interval == 0 {
+		return nil, nil
+	}
+	round := getCheckPoint(best, t.interval)
+	if t.latest != nil && t.round == round {
+		return t.latest, nil
+	}
+
+	state, err := t.client.GetBFT(ctx, t.path)
+	if err != nil {
+		return t.latest, err
+	}
+	t.round = round
+	t.latest = &state
+	return t.latest, nil
+}
+
+// newBFTQualityCheck fails when the bft quality reported by the node drops.
+func newBFTQualityCheck() Check {
+	var previous *client.BFTState
+	return Check{
+		Name:     "bft-quality",
+		Severity: SeverityWarn,
+		Run: func(r BlockResult) error {
+			if r.BFT == nil {
+				return nil
+			}
+			last := previous
+			previous = r.BFT
+			if last != nil && r.BFT.Quality < last.Quality {
+				return fmt.Errorf("bft quality dropped from %d to %d in round %d", last.Quality, r.BFT.Quality, r.BFT.Round)
+			}
+			return nil
+		},
+	}
+}
+
+// newStuckRoundCheck fails once the bft round or view reported by the node
+// advanced stuck times while the justified block did not, the proposers
+// failing to gather a quorum.
+func newStuckRoundCheck(stuck int) Check {
+	var (
+		last      *client.BFTState
+		justified uint32 // when the justified block last advanced.
+		advances  int    // of the round or view since.
+	)
+	return Check{
+		Name:     "bft-stuck-round",
+		Severity: SeverityWarn,
+		Needs:    FieldJustified,
+		Run: func(r BlockResult) error {
+			if stuck <= 0 || r.BFT == nil {
+				return nil
+			}
+			previous := last
+			last = r.BFT
+			switch {
+			case previous == nil || r.Justified > justified:
+				justified, advances = r.Justified, 0
+			case r.BFT.Round != previous.Round || r.BFT.View != previous.View:
+				advances++
+			}
+			if advances >= stuck {
+				return fmt.Errorf("bft round advanced %d times to round %d view %d without justified block %d advancing", advances, r.BFT.Round, r.BFT.View, justified)
+			}
+			return nil
+		},
+	}
+}
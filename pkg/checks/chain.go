@@ -0,0 +1,164 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+const (
+	BlockInterval            uint64 = 2 // time interval between two consecutive blocks.
+	InitialMaxBlockProposers uint64 = 4
+	CheckpointInterval              = 180 // blocks between two bft checkpoints.
+	AddressLength                   = 20
+)
+
+// ChainParams are the consensus parameters the checks depend on. A zero field
+// means the value is unknown.
+type ChainParams struct {
+	BlockInterval      uint64 // seconds between two consecutive blocks.
+	CheckpointInterval uint32 // blocks between two bft checkpoints.
+	MaxBlockProposers  uint64
+}
+
+// Genesis block IDs of the public networks.
+const (
+	MainnetGenesisID = "0x00000000851caf3cfdb6e899cf5958bfb1ac3413d346d43539627e6be7ec1b4a"
+	TestnetGenesisID = "0x000000000b2bce3c70bc649a02749e8687721b09ed2e15997f466536b20bb127"
+)
+
+// PublicChainParams are the parameters of the public networks.
+var PublicChainParams = ChainParams{BlockInterval: 10, CheckpointInterval: 180, MaxBlockProposers: 101}
+
+// knownGenesis maps the genesis block ID of public networks to their parameters.
+var knownGenesis = map[string]ChainParams{
+	MainnetGenesisID: PublicChainParams,
+	TestnetGenesisID: PublicChainParams,
+}
+
+// detectSampleBlocks is the number of blocks after genesis used to derive the block interval.
+const detectSampleBlocks = 5
+
+const (
+	// paramsAddress is the built-in contract holding the governance parameters.
+	paramsAddress = "0x0000000000000000000000000000506172616d73"
+
+	selectorParamsGet = "0x8eaa6ac0" // get(bytes32)
+)
+
+// keyMaxBlockProposers is the params key of the max block proposers, as a
+// left padded bytes32 ABI word.
+var keyMaxBlockProposers = fmt.Sprintf("%064x", "max-block-proposers")
+
+// DetectChainParams derives the chain parameters from the node. Parameters of
+// known networks are looked up by genesis ID, otherwise the block interval is
+// derived from block timestamps, the checkpoint interval from the distance
+// between the justified and finalized checkpoints once they exist, and the
+// max block proposers read from the params contract when set there. On error
+// the parameters detected so far are returned too.
+func DetectChainParams(ctx context.Context, c *client.Client) (ChainParams, error) {
+	genesis, err := c.GetBlockByNumber(ctx, 0)
+	if err != nil {
+		return ChainParams{}, fmt.Errorf("error getting genesis block: %w", err)
+	}
+
+	if params, ok := knownGenesis[strings.ToLower(genesis.ID)]; ok {
+		return params, nil
+	}
+
+	var params ChainParams
+
+	best, err := c.GetBestBlock(ctx)
+	if err != nil {
+		return params, fmt.Errorf("error getting best block: %w", err)
+	}
+
+	// Block timestamps are always genesis timestamp + k * interval, so the
+	// interval is the gcd of the offsets of a few blocks.
+	for n := uint32(1); n <= detectSampleBlocks && n <= best.Number; n++ {
+		block, err := c.GetBlockByNumber(ctx, n)
+		if err != nil {
+			return params, fmt.Errorf("error getting block %d: %w", n, err)
+		}
+		if block.Timestamp > genesis.Timestamp {
+			params.BlockInterval = gcd(params.BlockInterval, block.Timestamp-genesis.Timestamp)
+		}
+	}
+
+	justified, err := c.GetJustifiedBlock(ctx)
+	if err != nil {
+		return params, fmt.Errorf("error getting justified block: %w", err)
+	}
+	finalized, err := c.GetFinalizedBlock(ctx)
+	if err != nil {
+		return params, fmt.Errorf("error getting finalized block: %w", err)
+	}
+	if finalized.Number > 0 && justified.Number > finalized.Number {
+		params.CheckpointInterval = justified.Number - finalized.Number
+	}
+
+	results, err := c.Inspect(ctx, []client.Clause{{To: paramsAddress, Value: "0x0", Data: selectorParamsGet + keyMaxBlockProposers}})
+	if err != nil {
+		return params, fmt.Errorf("error calling params get: %w", err)
+	}
+	// zero while the parameter is unset, the protocol default applying.
+	if params.MaxBlockProposers, err = decodeUint64(results[0], 0); err != nil {
+		return params, fmt.Errorf("error reading max block proposers: %w", err)
+	}
+
+	return params, nil
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// ResolveChainParams merges the explicitly configured parameters with the
+// detected ones, failing when both are set and disagree. The parameters that
+// are neither configured nor detected fall back to the compile-time defaults.
+func ResolveChainParams(explicit, detected ChainParams) (ChainParams, error) {
+	if explicit.BlockInterval != 0 && detected.BlockInterval != 0 && explicit.BlockInterval != detected.BlockInterval {
+		return ChainParams{}, fmt.Errorf("configured block interval %d conflicts with detected %d", explicit.BlockInterval, detected.BlockInterval)
+	}
+	if explicit.CheckpointInterval != 0 && detected.CheckpointInterval != 0 && explicit.CheckpointInterval != detected.CheckpointInterval {
+		return ChainParams{}, fmt.Errorf("configured checkpoint interval %d conflicts with detected %d", explicit.CheckpointInterval, detected.CheckpointInterval)
+	}
+	if explicit.MaxBlockProposers != 0 && detected.MaxBlockProposers != 0 && explicit.MaxBlockProposers != detected.MaxBlockProposers {
+		return ChainParams{}, fmt.Errorf("configured max block proposers %d conflicts with detected %d", explicit.MaxBlockProposers, detected.MaxBlockProposers)
+	}
+
+	params := ChainParams{
+		BlockInterval:      firstNonZero(explicit.BlockInterval, detected.BlockInterval, BlockInterval),
+		CheckpointInterval: firstNonZero(explicit.CheckpointInterval, detected.CheckpointInterval, CheckpointInterval),
+		MaxBlockProposers:  firstNonZero(explicit.MaxBlockProposers, detected.MaxBlockProposers, InitialMaxBlockProposers),
+	}
+	return params, nil
+}
+
+func firstNonZero[T comparable](values ...T) T {
+	var zero T
+	for _, v := range values {
+		if v != zero {
+			return v
+		}
+	}
+	return zero
+}
+
+func getCheckPoint(blockNum, interval uint32) uint32 {
+	return blockNum / interval * interval
+}
+
+func IsCheckPoint(blockNum, interval uint32) bool {
+	return getCheckPoint(blockNum, interval) == blockNum
+}
+
+// save quality at the end of round
+func getStorePoint(blockNum, interval uint32) uint32 {
+	return getCheckPoint(blockNum, interval) + interval - 1
+}
@@ -0,0 +1,92 @@
+package checks
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveChainParams(t *testing.T) {
+	tests := []struct {
+		name               string
+		explicit, detected ChainParams
+		want               ChainParams
+		conflict           string // in the error, none when empty.
+	}{
+		{
+			name: "defaults",
+			want: ChainParams{BlockInterval: BlockInterval, CheckpointInterval: CheckpointInterval, MaxBlockProposers: InitialMaxBlockProposers},
+		},
+		{
+			name:     "detected",
+			detected: PublicChainParams,
+			want:     PublicChainParams,
+		},
+		{
+			name:     "explicit over the defaults",
+			explicit: ChainParams{CheckpointInterval: 10},
+			detected: ChainParams{BlockInterval: 5},
+			want:     ChainParams{BlockInterval: 5, CheckpointInterval: 10, MaxBlockProposers: InitialMaxBlockProposers},
+		},
+		{
+			name:     "agreeing",
+			explicit: ChainParams{BlockInterval: 10, MaxBlockProposers: 101},
+			detected: PublicChainParams,
+			want:     PublicChainParams,
+		},
+		{
+			name:     "block interval conflict",
+			explicit: ChainParams{BlockInterval: 2},
+			detected: PublicChainParams,
+			conflict: "block interval",
+		},
+		{
+			name:     "checkpoint interval conflict",
+			explicit: ChainParams{CheckpointInterval: 10},
+			detected: PublicChainParams,
+			conflict: "checkpoint interval",
+		},
+		{
+			name:     "max block proposers conflict",
+			explicit: ChainParams{MaxBlockProposers: 4},
+			detected: PublicChainParams,
+			conflict: "max block proposers",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveChainParams(tt.explicit, tt.detected)
+			if tt.conflict != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.conflict) {
+					t.Errorf("got params %+v, error %v, want a %s conflict", got, err, tt.conflict)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("got params %+v, error %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckpoints(t *testing.T) {
+	tests := []struct {
+		block, interval uint32
+		checkpoint      bool
+		storePoint      uint32
+	}{
+		{block: 0, interval: 180, checkpoint: true, storePoint: 179},
+		{block: 1, interval: 180, checkpoint: false, storePoint: 179},
+		{block: 179, interval: 180, checkpoint: false, storePoint: 179},
+		{block: 180, interval: 180, checkpoint: true, storePoint: 359},
+		{block: 545, interval: 180, checkpoint: false, storePoint: 719},
+		{block: 30, interval: 10, checkpoint: true, storePoint: 39},
+	}
+	for _, tt := range tests {
+		if got := IsCheckPoint(tt.block, tt.interval); got != tt.checkpoint {
+			t.Errorf("IsCheckPoint(%d, %d) = %t, want %t", tt.block, tt.interval, got, tt.checkpoint)
+		}
+		if got := getStorePoint(tt.block, tt.interval); got != tt.storePoint {
+			t.Errorf("getStorePoint(%d, %d) = %d, want %d", tt.block, tt.interval, got, tt.storePoint)
+		}
+	}
+}
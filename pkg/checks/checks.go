@@ -0,0 +1,636 @@
+// Package checks polls the blocks of a node and verifies the finality
+// invariants on each poll cycle, alone and across a fleet of nodes.
+package checks
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// Severity tells the monitor how to react when a check fails.
+type Severity int
+
+const (
+	SeverityWarn  Severity = iota // log the violation and keep running.
+	SeverityFatal                 // terminate the monitor.
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarn:
+		return "warn"
+	case SeverityFatal:
+		return "fatal"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Severity) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "warn", "warning":
+		*s = SeverityWarn
+	case "fatal":
+		*s = SeverityFatal
+	default:
+		return fmt.Errorf("unknown severity %q", text)
+	}
+	return nil
+}
+
+// Check is a single invariant evaluated against every BlockResult. It is
+// skipped when any of the fields it needs failed to be fetched.
+type Check struct {
+	Name     string
+	Severity Severity
+	Needs    Field
+	Run      func(r BlockResult) error
+}
+
+// Outcome is the result of running one check against one BlockResult.
+type Outcome struct {
+	Node     string
+	Name     string
+	Severity Severity
+	Err      error
+}
+
+// FetchErrors reports failed requests.
+const FetchErrors = "fetch-errors"
+
+// NoResponse reports nodes which stopped answering.
+const NoResponse = "no-response"
+
+// Thresholds are the parameters every height bound of the checks is derived from.
+type Thresholds struct {
+	CheckpointInterval uint32 `json:"checkpointInterval"` // blocks between two bft checkpoints.
+	// JustifiedLagTolerance widens both ends of the allowed head - justified range.
+	JustifiedLagTolerance uint32 `json:"justifiedLagTolerance"`
+	// FinalizedLagTolerance widens both ends of the allowed head - finalized range.
+	FinalizedLagTolerance uint32 `json:"finalizedLagTolerance"`
+}
+
+// justificationStart is the best block height from which a justified checkpoint is expected.
+func (t Thresholds) justificationStart() int64 {
+	return 2*int64(t.CheckpointInterval) - 1
+}
+
+// justifiedLagBounds returns the allowed [min, max) range of head - justified.
+func (t Thresholds) justifiedLagBounds() (int64, int64) {
+	ci, tol := int64(t.CheckpointInterval), int64(t.JustifiedLagTolerance)
+	return ci - 1 - tol, 2*ci - 1 + tol
+}
+
+// finalizedLagBounds returns the allowed [min, max) range of head - finalized.
+func (t Thresholds) finalizedLagBounds() (int64, int64) {
+	ci, tol := int64(t.CheckpointInterval), int64(t.FinalizedLagTolerance)
+	return 2*ci - 1 - tol, 3*ci - 1 + tol
+}
+
+func defaultChecks(cfg Config) []Check {
+	t := cfg.Thresholds
+	justifying := func(r BlockResult) bool {
+		return int64(r.Best) >= t.justificationStart()
+	}
+
+	return []Check{
+		{
+			Name:     FetchErrors,
+			Severity: SeverityFatal,
+			Run: func(r BlockResult) error {
+				err := r.Err()
+				if err != nil && rateLimited(r) {
+					// the node is only polled again once it allows it.
+					return warning{err}
+				}
+				return err
+			},
+		},
+		{
+			Name:     "genesis-justification",
+			Severity: SeverityFatal,
+			Needs:    FieldBest | FieldJustified | FieldFinalized | FieldSynced,
+			Run: func(r BlockResult) error {
+				if !justifying(r) && (r.Justified != 0 || r.Finalized != 0) {
+					return fmt.Errorf("best block height less than %d, justified and finalized block should be 0", t.justificationStart())
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "justified-finalized-distance",
+			Severity: SeverityFatal,
+			Needs:    FieldBest | FieldJustified | FieldFinalized | FieldSynced,
+			Run: func(r BlockResult) error {
+				if justifying(r) && int64(r.Justified)-int64(r.Finalized) != int64(t.CheckpointInterval) {
+					return fmt.Errorf("justified block number - finalized block number != %d", t.CheckpointInterval)
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "justified-lag",
+			Severity: SeverityFatal,
+			Needs:    FieldBest | FieldJustified | FieldSynced,
+			Run: func(r BlockResult) error {
+				lo, hi := t.justifiedLagBounds()
+				if lag := int64(r.Best) - int64(r.Justified); justifying(r) && (lag < lo || lag >= hi) {
+					return fmt.Errorf("%d <= head number - justified block number < %d, got %d", lo, hi, lag)
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "finalized-lag",
+			Severity: SeverityFatal,
+			Needs:    FieldBest | FieldFinalized | FieldSynced,
+			Run: func(r BlockResult) error {
+				lo, hi := t.finalizedLagBounds()
+				if lag := int64(r.Best) - int64(r.Finalized); justifying(r) && (lag < lo || lag >= hi) {
+					return fmt.Errorf("finalized block number out of bound: %d <= head number - finalized block number < %d, got %d", lo, hi, lag)
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "checkpoint-alignment",
+			Severity: SeverityFatal,
+			Needs:    FieldJustified | FieldFinalized,
+			Run: func(r BlockResult) error {
+				var errs []error
+				if !IsCheckPoint(r.Justified, t.CheckpointInterval) {
+					errs = append(errs, fmt.Errorf("justified block %d is not a checkpoint", r.Justified))
+				}
+				if !IsCheckPoint(r.Finalized, t.CheckpointInterval) {
+					errs = append(errs, fmt.Errorf("finalized block %d is not a checkpoint", r.Finalized))
+				}
+				return errors.Join(errs...)
+			},
+		},
+		{
+			Name:     "after-finalized",
+			Severity: SeverityFatal,
+			Needs:    FieldBest | FieldAfterFinalized | FieldSynced,
+			Run: func(r BlockResult) error {
+				if justifying(r) && r.AfterFinalized.IsFinalized {
+					return fmt.Errorf("after finalized block number should not be finalized")
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "finalized-head",
+			Severity: SeverityFatal,
+			Needs:    FieldFinalizedHead,
+			Run: func(r BlockResult) error {
+				head := r.FinalizedHead
+				if head.ID != r.FinalizedID {
+					return fmt.Errorf("block %d fetched by number is %s, the finalized block is %s", r.Finalized, head.ID, r.FinalizedID)
+				}
+				if !head.IsFinalized {
+					return fmt.Errorf("finalized block %d is not flagged as finalized", r.Finalized)
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "after-finalized-missing",
+			Severity: SeverityWarn,
+			Needs:    FieldFinalized,
+			Run: func(r BlockResult) error {
+				if cfg.AfterFinalizedMissing > 0 && r.AfterFinalizedMissing >= cfg.AfterFinalizedMissing {
+					return fmt.Errorf("block %d after the finalized one not found for %d poll cycles", r.Finalized+1, r.AfterFinalizedMissing)
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "finalized-spot-check",
+			Severity: SeverityFatal,
+			Needs:    FieldFinalized,
+			Run: func(r BlockResult) error {
+				var errs []error
+				for _, block := range r.SpotChecked {
+					if !block.IsFinalized {
+						errs = append(errs, fmt.Errorf("block %d is below finalized block %d but not flagged as finalized", block.Number, r.Finalized))
+					}
+				}
+				return errors.Join(errs...)
+			},
+		},
+		{
+			Name:     "best-reorg",
+			Severity: SeverityWarn,
+			Run: func(r BlockResult) error {
+				if r.Reorg != nil {
+					return errors.New(r.Reorg.String())
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "parent-linkage",
+			Severity: SeverityWarn,
+			Run: func(r BlockResult) error {
+				// a reorg explains the new best chain not linking to the old one.
+				if len(r.LinkageErrors) > 0 && r.Reorg == nil {
+					return joinMessages(r.LinkageErrors)
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "justified-reorg",
+			Severity: SeverityFatal,
+			Needs:    FieldBest | FieldJustified,
+			Run: func(r BlockResult) error {
+				if r.Reorg != nil && r.Reorg.ForkHeight < r.Justified {
+					return fmt.Errorf("%s reaches justified block %d", r.Reorg, r.Justified)
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "quorum-outliers",
+			Severity: SeverityWarn,
+			Run: func(r BlockResult) error {
+				if len(r.Outliers) > 0 {
+					return joinMessages(r.Outliers)
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "round-quorum",
+			Severity: SeverityFatal,
+			Run: func(r BlockResult) error {
+				if q := r.Quality; q != nil && q.Votes < quorum(cfg.MaxBlockProposers) {
+					return fmt.Errorf("round %d got %d votes, below the bft threshold of %d", q.Checkpoint, q.Votes, quorum(cfg.MaxBlockProposers))
+				}
+				return nil
+			},
+		},
+		newFetchWarningCheck("quality-fetch-errors", func(r BlockResult) error { return r.QualityErr }),
+		newQualityDegradationCheck(),
+		newLagAnomalyCheck(cfg),
+		{
+			Name:     "block-interval",
+			Severity: SeverityWarn,
+			Run: func(r BlockResult) error {
+				s := r.BlockSpacing
+				expected := float64(cfg.BlockInterval)
+				if s.Count < cfg.BlockIntervalWindow || s.Count == 0 {
+					return nil
+				}
+				if deviation := s.Avg - expected; deviation > expected*cfg.BlockIntervalTolerance || -deviation > expected*cfg.BlockIntervalTolerance {
+					return fmt.Errorf("average block interval %.2fs over the last %d best block advances deviates from %.0fs", s.Avg, s.Count, expected)
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "proposer-share",
+			Severity: SeverityWarn,
+			Run: func(r BlockResult) error {
+				p := r.Proposers
+				if p.Blocks == cfg.ProposerWindow && cfg.MaxProposerShare > 0 && p.TopShare > cfg.MaxProposerShare {
+					return fmt.Errorf("signer %s produced %.0f%% of the last %d blocks", p.TopSigner, p.TopShare*100, p.Blocks)
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "proposer-count",
+			Severity: SeverityWarn,
+			Run: func(r BlockResult) error {
+				p := r.Proposers
+				if p.Blocks == cfg.ProposerWindow && p.Blocks > 0 && uint64(p.Active) < cfg.MaxBlockProposers {
+					return fmt.Errorf("%d proposers active in the last %d blocks, expected %d", p.Active, p.Blocks, cfg.MaxBlockProposers)
+				}
+				return nil
+			},
+		},
+		newFetchWarningCheck("bft-fetch-errors", func(r BlockResult) error { return r.BFTErr }),
+		{
+			Name:     "bft-votes",
+			Severity: SeverityWarn,
+			Run: func(r BlockResult) error {
+				b := r.BFT
+				if b == nil || b.Checkpoint == 0 {
+					return nil
+				}
+				var errs []error
+				if b.Votes < b.Quorum {
+					errs = append(errs, fmt.Errorf("round of checkpoint %d got %d votes, below the quorum of %d", b.Checkpoint, b.Votes, b.Quorum))
+				}
+				if q := r.Quality; q != nil && q.Checkpoint == b.Checkpoint && q.Votes != b.Votes {
+					errs = append(errs, fmt.Errorf("node counted %d votes for checkpoint %d, its blocks carry %d", b.Votes, b.Checkpoint, q.Votes))
+				}
+				return errors.Join(errs...)
+			},
+		},
+		{
+			Name:     "bft-quorum-size",
+			Severity: SeverityFatal,
+			Run: func(r BlockResult) error {
+				if b := r.BFT; b != nil && b.Quorum < quorum(cfg.MaxBlockProposers) {
+					return fmt.Errorf("node requires %d votes to justify a checkpoint, below the bft threshold of %d", b.Quorum, quorum(cfg.MaxBlockProposers))
+				}
+				return nil
+			},
+		},
+		newBFTQualityCheck(),
+		newStuckRoundCheck(cfg.StuckRounds),
+		newFetchWarningCheck("authority-fetch-errors", func(r BlockResult) error { return r.AuthorityErr }),
+		{
+			Name:     "authority-set-size",
+			Severity: SeverityWarn,
+			Run: func(r BlockResult) error {
+				if a := r.Authority; a != nil && a.Active < quorum(cfg.MaxBlockProposers) {
+					return fmt.Errorf("%d active proposers in the authority contract, below the bft threshold of %d", a.Active, quorum(cfg.MaxBlockProposers))
+				}
+				return nil
+			},
+		},
+		newFetchWarningCheck("peers-fetch-errors", func(r BlockResult) error { return r.PeersErr }),
+		{
+			Name:     "peer-count",
+			Severity: SeverityWarn,
+			Run: func(r BlockResult) error {
+				if r.Peers != nil && *r.Peers < cfg.MinPeers {
+					return fmt.Errorf("%d peers connected, below the minimum of %d", *r.Peers, cfg.MinPeers)
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "missed-slots",
+			Severity: SeverityWarn,
+			Run: func(r BlockResult) error {
+				if e := r.Slots.Previous; e != nil && cfg.MaxMissedSlotRate > 0 && e.MissRate() > cfg.MaxMissedSlotRate {
+					return fmt.Errorf("epoch %d missed %d of %d slots (%.1f%%)", e.Epoch, e.Missed, e.Missed+e.Produced, e.MissRate()*100)
+				}
+				return nil
+			},
+		},
+		newStallCheck(time.Duration(cfg.StallIntervals*cfg.BlockInterval) * time.Second),
+		newNoResponseCheck(time.Duration(cfg.NoResponseSeconds) * time.Second),
+		newClockSkewCheck(time.Duration(cfg.MaxClockSkewSeconds)*time.Second, cfg.BlockIntervalWindow),
+		{
+			Name:     "finality-reversion",
+			Severity: SeverityFatal,
+			Run: func(r BlockResult) error {
+				if len(r.Reversions) == 0 {
+					return nil
+				}
+				errs := make([]error, 0, len(r.Reversions))
+				for _, reversion := range r.Reversions {
+					errs = append(errs, errors.New("finality reversion: "+reversion.String()))
+				}
+				return errors.Join(errs...)
+			},
+		},
+		{
+			Name:     "finality-rollback",
+			Severity: SeverityFatal,
+			Run: func(r BlockResult) error {
+				if r.Rollback != nil {
+					return fmt.Errorf("safety violation: %s", r.Rollback)
+				}
+				return nil
+			},
+		},
+		newMonotonicityCheck("justified-monotonic", "justified", FieldJustified, func(r BlockResult) uint32 { return r.Justified }),
+		// a finalized block going backwards is a safety violation.
+		newMonotonicityCheck("finalized-monotonic", "safety violation: finalized", FieldFinalized, func(r BlockResult) uint32 { return r.Finalized }),
+	}
+}
+
+// newQualityDegradationCheck fails once for every round with fewer votes than the previous one.
+func newQualityDegradationCheck() Check {
+	var prev *RoundQuality
+
+	return Check{
+		Name:     "round-quality",
+		Severity: SeverityWarn,
+		Run: func(r BlockResult) error {
+			q := r.Quality
+			if q == nil || (prev != nil && q.Checkpoint == prev.Checkpoint) {
+				return nil
+			}
+			last := prev
+			prev = q
+			if last != nil && q.Votes < last.Votes {
+				return fmt.Errorf("round %d quality degraded: %d votes, previous round %d had %d", q.Checkpoint, q.Votes, last.Checkpoint, last.Votes)
+			}
+			return nil
+		},
+	}
+}
+
+// newStallCheck fails while the best block has not advanced for longer than maxStall.
+func newStallCheck(maxStall time.Duration) Check {
+	var (
+		best     uint32
+		advanced time.Time
+	)
+
+	return Check{
+		Name:     "chain-stalled",
+		Severity: SeverityWarn,
+		Needs:    FieldBest,
+		Run: func(r BlockResult) error {
+			if advanced.IsZero() || r.Best != best {
+				best, advanced = r.Best, r.Time
+				return nil
+			}
+			if stalled := r.Time.Sub(advanced); maxStall > 0 && stalled > maxStall {
+				return fmt.Errorf("chain stalled: best block %d has not advanced for %s", best, stalled.Round(time.Second))
+			}
+			return nil
+		},
+	}
+}
+
+// newNoResponseCheck fails while the node has not answered any of the best,
+// justified and finalized requests for longer than silence.
+func newNoResponseCheck(silence time.Duration) Check {
+	var answered time.Time
+
+	return Check{
+		Name:     NoResponse,
+		Severity: SeverityFatal,
+		Run: func(r BlockResult) error {
+			if answered.IsZero() || r.BestErr == nil || r.JustifiedErr == nil || r.FinalizedErr == nil {
+				answered = r.Time
+				return nil
+			}
+			silent := r.Time.Sub(answered)
+			if silence <= 0 || silent <= silence {
+				return nil
+			}
+			err := fmt.Errorf("no response from the node for %s", silent.Round(time.Second))
+			if rateLimited(r) {
+				// the node is only polled again once it allows it.
+				return warning{err}
+			}
+			return err
+		},
+	}
+}
+
+// newClockSkewCheck fails while the clock of the monitor is skewed by more
+// than maxSkew from the block timestamps. A new best block is seen within a
+// block interval after its timestamp, so the skew is estimated from the
+// earliest one was seen over the last window best block advances: before
+// its timestamp when the clock is behind, long after when it is ahead.
+func newClockSkewCheck(maxSkew time.Duration, window int) Check {
+	var (
+		best    uint32
+		offsets []time.Duration // between the poll cycles and the timestamps of the new best blocks.
+	)
+
+	return Check{
+		Name:     "clock-skew",
+		Severity: SeverityWarn,
+		Needs:    FieldBest | FieldSynced,
+		Run: func(r BlockResult) error {
+			if maxSkew <= 0 || r.BestTimestamp == 0 || r.Best == best {
+				return nil
+			}
+			first := best == 0
+			best = r.Best
+			if first {
+				// the best block of the first poll may be from any time in the last interval.
+				return nil
+			}
+			offsets = append(offsets, r.Time.Sub(time.Unix(int64(r.BestTimestamp), 0)))
+			if len(offsets) > max(window, 1) {
+				offsets = offsets[1:]
+			}
+			if len(offsets) < window {
+				return nil
+			}
+			switch skew := slices.Min(offsets); {
+			case skew < -maxSkew:
+				return fmt.Errorf("clock behind the block timestamps by %s: new best blocks were seen before they were produced", (-skew).Round(time.Second))
+			case skew > maxSkew:
+				return fmt.Errorf("clock ahead of the block timestamps by %s, or the node receives the blocks late: the last %d best blocks were all seen that late", skew.Round(time.Second), len(offsets))
+			}
+			return nil
+		},
+	}
+}
+
+// newMonotonicityCheck fails when the height returned by height decreases
+// between two consecutive polls.
+func newMonotonicityCheck(name, what string, needs Field, height func(BlockResult) uint32) Check {
+	var (
+		prev uint32
+		seen bool
+	)
+
+	return Check{
+		Name:     name,
+		Severity: SeverityFatal,
+		Needs:    needs,
+		Run: func(r BlockResult) error {
+			h := height(r)
+			last, ok := prev, seen
+			prev, seen = h, true
+			if ok && h < last {
+				return fmt.Errorf("%s block number decreased from %d to %d", what, last, h)
+			}
+			return nil
+		},
+	}
+}
+
+// New returns the default checks followed by the plugins, with the
+// severities overridden by the config.
+func New(cfg Config) []Check {
+	checks := defaultChecks(cfg)
+	for _, plugin := range cfg.Plugins {
+		checks = append(checks, newPluginCheck(plugin))
+	}
+	for i := range checks {
+		if severity, ok := cfg.Severities[checks[i].Name]; ok {
+			checks[i].Severity = severity
+		}
+	}
+	return checks
+}
+
+// ValidateSeverities makes sure the plugins are valid and every severity
+// override names an existing check.
+func ValidateSeverities(cfg Config) error {
+	names := make(map[string]bool)
+	for _, check := range defaultChecks(cfg) {
+		names[check.Name] = true
+	}
+	for _, check := range defaultFleetChecks(cfg) {
+		names[check.Name] = true
+	}
+	if err := validatePlugins(cfg, names); err != nil {
+		return err
+	}
+
+	for name := range cfg.Severities {
+		if !names[name] {
+			return fmt.Errorf("unknown check %q in severities", name)
+		}
+	}
+	return nil
+}
+
+// Perform runs every check whose fields were fetched against r and
+// returns the failed ones.
+func Perform(checks []Check, r BlockResult) []Outcome {
+	var failed []Outcome
+	for _, check := range checks {
+		if !r.Fetched(check.Needs) {
+			continue
+		}
+		span := startCheckSpan(r, check.Name)
+		err := check.Run(r)
+		endSpan(span, err)
+		if err != nil {
+			severity := check.Severity
+			if errors.As(err, new(warning)) {
+				severity = SeverityWarn
+			}
+			failed = append(failed, Outcome{Node: r.Node, Name: check.Name, Severity: severity, Err: err})
+		}
+	}
+	return failed
+}
+
+// newFetchWarningCheck warns of the failed requests of an optional source of
+// data, left out of the fetch-errors check: only the checks reading the data
+// depend on it, and are skipped until it is fetched.
+func newFetchWarningCheck(name string, fetchErr func(r BlockResult) error) Check {
+	return Check{
+		Name:     name,
+		Severity: SeverityWarn,
+		Run:      fetchErr,
+	}
+}
+
+// rateLimited reports whether every failed request of r was rate limited.
+func rateLimited(r BlockResult) bool {
+	for _, err := range r.Errs() {
+		if !errors.Is(err, client.ErrRateLimited) {
+			return false
+		}
+	}
+	return true
+}
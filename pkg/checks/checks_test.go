@@ -0,0 +1,80 @@
+package checks
+
+import "testing"
+
+func TestSeverityUnmarshalText(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    Severity
+		invalid bool
+	}{
+		{text: "warn", want: SeverityWarn},
+		{text: "Warning", want: SeverityWarn},
+		{text: "FATAL", want: SeverityFatal},
+		{text: "error", invalid: true},
+		{text: "", invalid: true},
+	}
+	for _, tt := range tests {
+		var s Severity
+		err := s.UnmarshalText([]byte(tt.text))
+		if (err != nil) != tt.invalid || !tt.invalid && s != tt.want {
+			t.Errorf("UnmarshalText(%q) = %s, error %v, want %s, invalid %t", tt.text, s, err, tt.want, tt.invalid)
+		}
+	}
+}
+
+func TestLagBounds(t *testing.T) {
+	tests := []struct {
+		name                       string
+		thresholds                 Thresholds
+		justifiedMin, justifiedMax int64
+		finalizedMin, finalizedMax int64
+		justificationStart         int64
+	}{
+		{
+			name:         "exact",
+			thresholds:   Thresholds{CheckpointInterval: 180},
+			justifiedMin: 179, justifiedMax: 359,
+			finalizedMin: 359, finalizedMax: 539,
+			justificationStart: 359,
+		},
+		{
+			name:         "tolerances",
+			thresholds:   Thresholds{CheckpointInterval: 10, JustifiedLagTolerance: 2, FinalizedLagTolerance: 3},
+			justifiedMin: 7, justifiedMax: 21,
+			finalizedMin: 16, finalizedMax: 32,
+			justificationStart: 19,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if lo, hi := tt.thresholds.justifiedLagBounds(); lo != tt.justifiedMin || hi != tt.justifiedMax {
+				t.Errorf("justified lag bounds [%d, %d), want [%d, %d)", lo, hi, tt.justifiedMin, tt.justifiedMax)
+			}
+			if lo, hi := tt.thresholds.finalizedLagBounds(); lo != tt.finalizedMin || hi != tt.finalizedMax {
+				t.Errorf("finalized lag bounds [%d, %d), want [%d, %d)", lo, hi, tt.finalizedMin, tt.finalizedMax)
+			}
+			if got := tt.thresholds.justificationStart(); got != tt.justificationStart {
+				t.Errorf("justification start %d, want %d", got, tt.justificationStart)
+			}
+		})
+	}
+}
+
+func TestQuorum(t *testing.T) {
+	tests := []struct {
+		proposers uint64
+		want      int
+	}{
+		{proposers: 1, want: 1},
+		{proposers: 3, want: 3},
+		{proposers: 4, want: 3},
+		{proposers: 6, want: 5},
+		{proposers: 101, want: 68},
+	}
+	for _, tt := range tests {
+		if got := quorum(tt.proposers); got != tt.want {
+			t.Errorf("quorum(%d) = %d, want %d", tt.proposers, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package checks
+
+// Config holds the settings of the checks and of the trackers feeding them.
+type Config struct {
+	// MaxNodeLagCheckpoints is how many checkpoints a node may lag behind the
+	// others before the cross-node-lag check fails.
+	MaxNodeLagCheckpoints uint32 `json:"maxNodeLagCheckpoints"`
+
+	// BlockInterval and MaxBlockProposers are detected from the node when
+	// left unset, as is Thresholds.CheckpointInterval.
+	BlockInterval     uint64 `json:"blockInterval"`
+	MaxBlockProposers uint64 `json:"maxBlockProposers"`
+
+	// FinalityRecheckCycles is how many poll cycles pass between two re-fetches
+	// of the recorded finalized blocks. Zero disables the re-fetch.
+	FinalityRecheckCycles int `json:"finalityRecheckCycles"`
+
+	// SpotCheckSamples is how many random blocks below the finalized one are
+	// fetched every cycle to verify they are flagged as finalized.
+	SpotCheckSamples int `json:"spotCheckSamples"`
+
+	// ReorgWindow is how many recent best blocks are remembered to detect reorgs.
+	ReorgWindow int `json:"reorgWindow"`
+
+	// SyncIntervals is how many block intervals old the best block of a node
+	// may be when the monitor starts before the node is considered syncing.
+	// The finality range checks are suspended until it catches up. Zero
+	// disables the detection.
+	SyncIntervals uint64 `json:"syncIntervals"`
+
+	// StallIntervals is how many block intervals the best block may stay at the
+	// same height before the chain is reported as stalled.
+	StallIntervals uint64 `json:"stallIntervals"`
+
+	// NoResponseSeconds is how long a node may go without answering any of
+	// the best, justified and finalized requests before the fatal
+	// no-response check fails. Zero disables the check.
+	NoResponseSeconds int `json:"noResponseSeconds"`
+
+	// MaxClockSkewSeconds is how far the clock of the monitor may drift from
+	// the block timestamps before the clock-skew check fails, estimated over
+	// the last BlockIntervalWindow best block advances. Zero disables the check.
+	MaxClockSkewSeconds int `json:"maxClockSkewSeconds"`
+
+	// BlockIntervalWindow is how many best block advances the average block
+	// spacing covers, and BlockIntervalTolerance the fraction of BlockInterval
+	// the average may deviate by.
+	BlockIntervalWindow    int     `json:"blockIntervalWindow"`
+	BlockIntervalTolerance float64 `json:"blockIntervalTolerance"`
+
+	// ProposerWindow is how many recent blocks the proposer rotation checks
+	// cover. A signer producing more than MaxProposerShare of them is reported.
+	ProposerWindow   int     `json:"proposerWindow"`
+	MaxProposerShare float64 `json:"maxProposerShare"`
+
+	// MaxMissedSlotRate is the fraction of slots of an epoch which may pass
+	// without a block before the missed-slots check fails.
+	MaxMissedSlotRate float64 `json:"maxMissedSlotRate"`
+
+	// AfterFinalizedMissing is how many poll cycles in a row the block after
+	// the finalized one may be missing from the node, the after-finalized
+	// check skipped meanwhile, before the after-finalized-missing check fails.
+	// Zero disables the check.
+	AfterFinalizedMissing int `json:"afterFinalizedMissing"`
+
+	// AuthorityPollCycles is how many poll cycles pass between two reads of
+	// the authority contract. Zero disables the reads.
+	AuthorityPollCycles int `json:"authorityPollCycles"`
+
+	// PeersPollCycles is how many poll cycles pass between two fetches of the
+	// peers of the node. Zero disables the fetches. The peer-count check fails
+	// when the node has fewer than MinPeers peers.
+	PeersPollCycles int `json:"peersPollCycles"`
+	MinPeers        int `json:"minPeers"`
+
+	// BFTPath is the path of the debug endpoint the nodes expose their
+	// consensus state at, fetched once per checkpoint round. Empty disables
+	// the bft checks.
+	BFTPath string `json:"bftPath"`
+	// StuckRounds is how many times the bft round or view of a node may
+	// advance without the justified block advancing before the
+	// bft-stuck-round check fails. Zero disables the check.
+	StuckRounds int `json:"stuckRounds"`
+
+	// LatencyWindow is how many recent checkpoints the finality latency statistics cover.
+	LatencyWindow int `json:"latencyWindow"`
+	// GapWindow is how many recent poll cycles the statistics of the gaps
+	// between the best block and the justified and finalized ones cover.
+	GapWindow int `json:"gapWindow"`
+	// PropagationWindow is how many recent best block heights the statistics
+	// of their propagation across the nodes cover.
+	PropagationWindow int `json:"propagationWindow"`
+	// AnomalyThreshold is how many standard deviations the finality lags
+	// may deviate from their baseline over the GapWindow before a warning.
+	// Zero disables the detection.
+	AnomalyThreshold float64 `json:"anomalyThreshold"`
+
+	// Plugins are checks implemented by external programs.
+	Plugins []PluginConfig `json:"plugins"`
+
+	// Severities overrides the default severity of checks by name,
+	// e.g. {"after-finalized": "warn"}.
+	Severities map[string]Severity `json:"severities"`
+
+	Thresholds Thresholds `json:"thresholds"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		FinalityRecheckCycles:  30,
+		SpotCheckSamples:       3,
+		AfterFinalizedMissing:  5,
+		ReorgWindow:            64,
+		MaxNodeLagCheckpoints:  1,
+		StallIntervals:         5,
+		MaxClockSkewSeconds:    5,
+		SyncIntervals:          30,
+		LatencyWindow:          100,
+		GapWindow:              100,
+		PropagationWindow:      100,
+		AnomalyThreshold:       4,
+		StuckRounds:            3,
+		BlockIntervalWindow:    30,
+		BlockIntervalTolerance: 0.5,
+		ProposerWindow:         360,
+		MaxProposerShare:       0.5,
+		AuthorityPollCycles:    30,
+		PeersPollCycles:        10,
+		MinPeers:               1,
+		MaxMissedSlotRate:      0.1,
+	}
+}
+
+// ChainParams returns the explicitly configured chain parameters.
+func (cfg Config) ChainParams() ChainParams {
+	return ChainParams{
+		BlockInterval:      cfg.BlockInterval,
+		CheckpointInterval: cfg.Thresholds.CheckpointInterval,
+		MaxBlockProposers:  cfg.MaxBlockProposers,
+	}
+}
+
+func (cfg *Config) SetChainParams(params ChainParams) {
+	cfg.BlockInterval = params.BlockInterval
+	cfg.Thresholds.CheckpointInterval = params.CheckpointInterval
+	cfg.MaxBlockProposers = params.MaxBlockProposers
+}
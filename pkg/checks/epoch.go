@@ -0,0 +1,52 @@
+package checks
+
+import "time"
+
+// EpochProgress is the progress of the best block through the checkpoint
+// epoch being voted. The checkpoint opening the epoch is justified by the
+// last block of the epoch, and finalized by the last block of the next one.
+type EpochProgress struct {
+	Epoch      uint32 // checkpoint number / checkpoint interval.
+	Checkpoint uint32 // first block of the epoch.
+	Position   uint32 // blocks produced towards the justification, out of Length.
+	Length     uint32 // blocks of an epoch.
+
+	// Blocks still to be produced, and how long that takes at the block
+	// interval, before the checkpoint is justified and finalized.
+	JustifiedIn  uint32
+	JustifiedETA time.Duration
+	FinalizedIn  uint32
+	FinalizedETA time.Duration
+}
+
+// Fraction is the fraction of the epoch produced so far.
+func (e EpochProgress) Fraction() float64 {
+	return float64(e.Position) / float64(e.Length)
+}
+
+// newEpochProgress returns the progress of the epoch of the best block. The
+// first epoch has no checkpoint to vote on, its progress is the one towards
+// the checkpoint of the second epoch.
+func newEpochProgress(best uint32, checkpointInterval uint32, blockInterval uint64) *EpochProgress {
+	if checkpointInterval == 0 {
+		return nil
+	}
+	// The last block of an epoch justifies its checkpoint, the following one
+	// belongs to the next vote.
+	epoch := max((best+1)/checkpointInterval, 1)
+	checkpoint := epoch * checkpointInterval
+	justifiedAt := checkpoint + checkpointInterval - 1
+	p := &EpochProgress{
+		Epoch:       epoch,
+		Checkpoint:  checkpoint,
+		Length:      checkpointInterval,
+		JustifiedIn: justifiedAt - best,
+		FinalizedIn: justifiedAt + checkpointInterval - best,
+	}
+	if p.JustifiedIn < checkpointInterval {
+		p.Position = checkpointInterval - p.JustifiedIn
+	}
+	p.JustifiedETA = time.Duration(uint64(p.JustifiedIn)*blockInterval) * time.Second
+	p.FinalizedETA = time.Duration(uint64(p.FinalizedIn)*blockInterval) * time.Second
+	return p
+}
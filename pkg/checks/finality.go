@@ -0,0 +1,128 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// maxRecordedFinalized bounds the number of finalized heights kept for re-checking.
+const maxRecordedFinalized = 64
+
+// FinalityReversion reports a finalized height that now resolves to a different block.
+type FinalityReversion struct {
+	Number     uint32
+	RecordedID string
+	CurrentID  string
+}
+
+func (fr FinalityReversion) String() string {
+	return fmt.Sprintf("block %d was finalized as %s, node now reports %s", fr.Number, fr.RecordedID, fr.CurrentID)
+}
+
+// FinalizedMark is a finalized block of a node, persisted to verify its
+// finality once the monitor restarts.
+type FinalizedMark struct {
+	Number uint32 `json:"number"`
+	ID     string `json:"id"`
+}
+
+// FinalityRollback reports a node whose finalized block is below the one it
+// reported before the monitor restarted.
+type FinalityRollback struct {
+	Recorded  FinalizedMark
+	Finalized uint32
+}
+
+func (fr FinalityRollback) String() string {
+	return fmt.Sprintf("finalized block %d is below block %d (%s) finalized before the restart", fr.Finalized, fr.Recorded.Number, fr.Recorded.ID)
+}
+
+// finalityTracker records the ID of every finalized block seen and re-fetches
+// them periodically to make sure finalized blocks never change.
+type finalityTracker struct {
+	client  *client.Client
+	every   int // re-check the recorded heights every this many updates.
+	cycles  int
+	ids     map[uint32]string
+	order   []uint32
+	resumed *FinalizedMark // to verify on the next update.
+}
+
+// resume records mark as if it was seen finalized, re-checking it on the next update.
+func (t *finalityTracker) resume(mark FinalizedMark) {
+	t.record(mark.Number, mark.ID)
+	t.resumed = &mark
+}
+
+func newFinalityTracker(client *client.Client, every int) *finalityTracker {
+	return &finalityTracker{client: client, every: every, ids: make(map[uint32]string)}
+}
+
+// update records the current finalized block and returns the recorded heights
+// whose block ID changed.
+func (t *finalityTracker) update(ctx context.Context, finalized client.JSONBlockSummary) ([]FinalityReversion, *FinalityRollback, error) {
+	var reversions []FinalityReversion
+	var rollback *FinalityRollback
+	resumed := t.resumed
+	t.resumed = nil
+	if resumed != nil && finalized.Number < resumed.Number {
+		rollback = &FinalityRollback{Recorded: *resumed, Finalized: finalized.Number}
+	}
+
+	if id, ok := t.ids[finalized.Number]; ok {
+		if id != finalized.ID {
+			reversions = append(reversions, FinalityReversion{Number: finalized.Number, RecordedID: id, CurrentID: finalized.ID})
+		}
+	} else if finalized.ID != "" {
+		t.record(finalized.Number, finalized.ID)
+	}
+
+	t.cycles++
+	if resumed == nil && (t.every <= 0 || t.cycles%t.every != 0) {
+		return reversions, rollback, nil
+	}
+
+	numbers := make([]uint32, 0, len(t.order))
+	for _, number := range t.order {
+		if number != finalized.Number {
+			numbers = append(numbers, number)
+		}
+	}
+	blocks, err := t.client.GetBlocksConcurrently(ctx, numbers)
+	if err != nil {
+		return reversions, rollback, fmt.Errorf("error re-fetching finalized blocks: %w", err)
+	}
+	for i, block := range blocks {
+		if number := numbers[i]; block.ID != t.ids[number] {
+			reversions = append(reversions, FinalityReversion{Number: number, RecordedID: t.ids[number], CurrentID: block.ID})
+		}
+	}
+
+	return reversions, rollback, nil
+}
+
+func (t *finalityTracker) record(number uint32, id string) {
+	if len(t.order) == maxRecordedFinalized {
+		delete(t.ids, t.order[0])
+		t.order = t.order[1:]
+	}
+	t.ids[number] = id
+	t.order = append(t.order, number)
+}
+
+// spotCheckFinalized fetches up to samples random blocks below finalized, which
+// must all report isFinalized.
+func spotCheckFinalized(ctx context.Context, client *client.Client, finalized uint32, samples int) ([]client.JSONBlockSummary, error) {
+	if finalized <= 1 {
+		return nil, nil
+	}
+
+	numbers := make([]uint32, 0, samples)
+	for i := 0; i < samples; i++ {
+		numbers = append(numbers, 1+rand.Uint32N(finalized-1))
+	}
+	return client.GetBlocksConcurrently(ctx, numbers)
+}
@@ -0,0 +1,208 @@
+package checks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// FleetCheck is an invariant evaluated across the latest results of all monitored nodes.
+type FleetCheck struct {
+	Name     string
+	Severity Severity
+	Run      func(r BlockResult, others []BlockResult) error
+}
+
+func defaultFleetChecks(cfg Config) []FleetCheck {
+	maxLag := int64(cfg.MaxNodeLagCheckpoints) * int64(cfg.Thresholds.CheckpointInterval)
+
+	return []FleetCheck{
+		newFinalityConflictCheck(),
+		{
+			Name:     "cross-node-lag",
+			Severity: SeverityWarn,
+			Run: func(r BlockResult, others []BlockResult) error {
+				var errs []error
+				for _, o := range others {
+					if lag := int64(o.Finalized) - int64(r.Finalized); lag > maxLag {
+						errs = append(errs, fmt.Errorf("finalized block %d is %d blocks behind %s", r.Finalized, lag, o.Node))
+					}
+					if lag := int64(o.Justified) - int64(r.Justified); lag > maxLag {
+						errs = append(errs, fmt.Errorf("justified block %d is %d blocks behind %s", r.Justified, lag, o.Node))
+					}
+				}
+				return errors.Join(errs...)
+			},
+		},
+	}
+}
+
+// finalizationHistory is how many finalized heights the fleet remembers to
+// catch nodes finalizing different blocks at the same height, even when they
+// report it at different times.
+const finalizationHistory = 256
+
+// finalization is the block a node reported as finalized at a height.
+type finalization struct {
+	node  string
+	block client.JSONBlockSummary
+}
+
+// newFinalityConflictCheck fails when two nodes finalized different blocks
+// at the same height, the catastrophic double finalization, reporting both
+// blocks in full, or justified different blocks at the same height.
+func newFinalityConflictCheck() FleetCheck {
+	finalized := make(map[uint32]finalization)
+	var heights []uint32 // of finalized, oldest first.
+
+	return FleetCheck{
+		Name:     "cross-node-finality-conflict",
+		Severity: SeverityFatal,
+		Run: func(r BlockResult, others []BlockResult) error {
+			var errs []error
+			block := r.FinalizedHead
+			if block.ID != r.FinalizedID {
+				// not fetched by number this cycle.
+				block = client.JSONBlockSummary{Number: r.Finalized, ID: r.FinalizedID, IsFinalized: true}
+			}
+			first, ok := finalized[r.Finalized]
+			switch {
+			case !ok:
+				finalized[r.Finalized] = finalization{node: r.Node, block: block}
+				heights = append(heights, r.Finalized)
+				if len(heights) > finalizationHistory {
+					delete(finalized, heights[0])
+					heights = heights[1:]
+				}
+			case first.node != r.Node && first.block.ID != block.ID:
+				errs = append(errs, fmt.Errorf("double finalization of block %d: %s on %s but %s on %s", r.Finalized, blockDetails(block), r.Node, blockDetails(first.block), first.node))
+			}
+			for _, o := range others {
+				if o.Justified == r.Justified && o.JustifiedID != r.JustifiedID {
+					errs = append(errs, fmt.Errorf("justified block %d is %s on %s but %s on %s", r.Justified, r.JustifiedID, r.Node, o.JustifiedID, o.Node))
+				}
+			}
+			return errors.Join(errs...)
+		},
+	}
+}
+
+// blockDetails returns block as JSON, for forensics.
+func blockDetails(block client.JSONBlockSummary) string {
+	data, err := json.Marshal(block)
+	if err != nil {
+		return block.ID
+	}
+	return string(data)
+}
+
+// NewFleetChecks returns the default fleet checks with the severities overridden by the config.
+func NewFleetChecks(cfg Config) []FleetCheck {
+	checks := defaultFleetChecks(cfg)
+	for i := range checks {
+		if severity, ok := cfg.Severities[checks[i].Name]; ok {
+			checks[i].Severity = severity
+		}
+	}
+	return checks
+}
+
+// Fleet compares the results of a node with the latest ones of the other nodes.
+type Fleet struct {
+	Checks []FleetCheck
+	Latest map[string]BlockResult
+
+	propagation *propagationTracker
+}
+
+// NewFleet returns a fleet running the fleet checks configured in cfg.
+func NewFleet(cfg Config) *Fleet {
+	return &Fleet{Checks: NewFleetChecks(cfg), Latest: make(map[string]BlockResult), propagation: newPropagationTracker(cfg.PropagationWindow)}
+}
+
+// FleetNeeds are the fields every fleet check depends on.
+const FleetNeeds = FieldJustified | FieldFinalized
+
+// Update records r and returns the fleet checks it fails. Results without
+// their justified and finalized blocks are neither checked nor used for comparison.
+func (f *Fleet) Update(r BlockResult) []Outcome {
+	if !r.Fetched(FleetNeeds) {
+		delete(f.Latest, r.Node)
+		return nil
+	}
+	f.Latest[r.Node] = r
+
+	others := make([]BlockResult, 0, len(f.Latest)-1)
+	for node, o := range f.Latest {
+		if node != r.Node {
+			others = append(others, o)
+		}
+	}
+	if len(others) == 0 {
+		return nil
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i].Node < others[j].Node })
+
+	var failed []Outcome
+	for _, check := range f.Checks {
+		span := startCheckSpan(r, check.Name)
+		err := check.Run(r, others)
+		endSpan(span, err)
+		if err != nil {
+			failed = append(failed, Outcome{Node: r.Node, Name: check.Name, Severity: check.Severity, Err: err})
+		}
+	}
+	return failed
+}
+
+// NodeLag is how many blocks a node is behind the most advanced of the other
+// nodes, 0 when it is the most advanced one.
+type NodeLag struct {
+	Best      uint32
+	Justified uint32
+	Finalized uint32
+}
+
+// Lag returns how far r is behind the latest results of the other nodes, nil
+// when r misses a block or when no other node has a result.
+func (f *Fleet) Lag(r BlockResult) *NodeLag {
+	if !r.Fetched(FieldBest | FieldJustified | FieldFinalized) {
+		return nil
+	}
+	var lag *NodeLag
+	for node, o := range f.Latest {
+		if node == r.Node || !o.Fetched(FieldBest) {
+			continue
+		}
+		if lag == nil {
+			lag = &NodeLag{}
+		}
+		lag.Best = max(lag.Best, behind(r.Best, o.Best))
+		lag.Justified = max(lag.Justified, behind(r.Justified, o.Justified))
+		lag.Finalized = max(lag.Finalized, behind(r.Finalized, o.Finalized))
+	}
+	return lag
+}
+
+// Propagation records when the node of r reported its best block heights and
+// returns how long the recent heights took to reach the nodes.
+func (f *Fleet) Propagation(r BlockResult) Propagation {
+	return f.propagation.record(r)
+}
+
+// behind returns how many blocks n is behind other.
+func behind(n, other uint32) uint32 {
+	if other > n {
+		return other - n
+	}
+	return 0
+}
+
+// Remove forgets the latest result of node.
+func (f *Fleet) Remove(node string) {
+	delete(f.Latest, node)
+	f.propagation.remove(node)
+}
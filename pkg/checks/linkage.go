@@ -0,0 +1,62 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// maxLinkageGap bounds the number of skipped blocks fetched to verify the
+// linkage when the best block advanced by more than one.
+const maxLinkageGap = 32
+
+// linkageTracker verifies every new best block links to the previously seen one.
+type linkageTracker struct {
+	client *client.Client
+	last   client.JSONBlockSummary
+}
+
+func newLinkageTracker(client *client.Client) *linkageTracker {
+	return &linkageTracker{client: client}
+}
+
+// update returns the blocks produced since the previous best block, up to
+// best, and the linkage errors between them.
+func (t *linkageTracker) update(ctx context.Context, best client.JSONBlockSummary) ([]client.JSONBlockSummary, []string, error) {
+	last := t.last
+	t.last = best
+	if last.ID == "" {
+		return []client.JSONBlockSummary{best}, nil, nil
+	}
+	if best.Number <= last.Number {
+		return nil, nil, nil
+	}
+
+	gap := best.Number - last.Number - 1
+	if gap > maxLinkageGap {
+		return []client.JSONBlockSummary{best}, nil, nil
+	}
+
+	// Fetch the skipped blocks so the whole chain from last to best is linked.
+	skipped, err := t.client.GetBlockRange(ctx, last.Number+1, best.Number-1)
+	if err != nil {
+		return []client.JSONBlockSummary{best}, nil, err
+	}
+	chain := make([]client.JSONBlockSummary, 0, gap+2)
+	chain = append(chain, last)
+	chain = append(chain, skipped...)
+	chain = append(chain, best)
+
+	var errs []string
+	for i := 1; i < len(chain); i++ {
+		prev, block := chain[i-1], chain[i]
+		switch {
+		case block.ID == "" || block.Number != prev.Number+1:
+			errs = append(errs, fmt.Sprintf("block %d is missing", prev.Number+1))
+		case block.ParentID != prev.ID:
+			errs = append(errs, fmt.Sprintf("block %d parentID %s does not match block %d ID %s", block.Number, block.ParentID, prev.Number, prev.ID))
+		}
+	}
+	return chain[1:], errs, nil
+}
@@ -0,0 +1,39 @@
+package checks
+
+import (
+	"context"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// peerTracker periodically fetches the peers of a node.
+type peerTracker struct {
+	client *client.Client
+	every  int
+	cycles int
+	latest *int
+}
+
+func newPeerTracker(client *client.Client, every int) *peerTracker {
+	return &peerTracker{client: client, every: every}
+}
+
+// update fetches the peers every t.every calls, failed fetches included, and
+// returns the latest peer count, nil until the peers were fetched once.
+func (t *peerTracker) update(ctx context.Context) (*int, error) {
+	if t.every <= 0 {
+		return nil, nil
+	}
+	t.cycles++
+	if (t.cycles-1)%t.every != 0 {
+		return t.latest, nil
+	}
+
+	peers, err := t.client.GetPeers(ctx)
+	if err != nil {
+		return t.latest, err
+	}
+	count := len(peers)
+	t.latest = &count
+	return t.latest, nil
+}
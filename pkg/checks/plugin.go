@@ -0,0 +1,114 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// defaultPluginTimeout bounds a run of a check plugin by default. Plugins run
+// in the poll loop, so they should answer well within the block interval.
+const defaultPluginTimeout = 2 * time.Second
+
+// PluginConfig is a check implemented by an external program. The program is
+// passed the JSON BlockResult of every poll cycle on stdin and answers on
+// stdout with {"status": "pass", "warn" or "fail", "message": "..."}. A
+// failed plugin fails with the severity of the check, fatal by default,
+// and a plugin warning or which cannot be run warns.
+type PluginConfig struct {
+	Name           string   `json:"name"`
+	Command        []string `json:"command"` // program and arguments, run without a shell.
+	TimeoutSeconds int      `json:"timeoutSeconds"`
+}
+
+// pluginAnswer is the output of a check plugin.
+type pluginAnswer struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// warning is the error of a check which failed with SeverityWarn, whatever
+// its severity.
+type warning struct {
+	err error
+}
+
+func (w warning) Error() string {
+	return w.err.Error()
+}
+
+func (w warning) Unwrap() error {
+	return w.err
+}
+
+func newPluginCheck(plugin PluginConfig) Check {
+	timeout := defaultPluginTimeout
+	if plugin.TimeoutSeconds > 0 {
+		timeout = time.Duration(plugin.TimeoutSeconds) * time.Second
+	}
+
+	return Check{
+		Name:     plugin.Name,
+		Severity: SeverityFatal,
+		Run: func(r BlockResult) error {
+			answer, err := runPlugin(plugin.Command, timeout, r)
+			if err != nil {
+				return warning{fmt.Errorf("error running check plugin: %w", err)}
+			}
+			switch answer.Status {
+			case "pass":
+				return nil
+			case "warn":
+				return warning{errors.New(answer.Message)}
+			case "fail":
+				return errors.New(answer.Message)
+			}
+			return warning{fmt.Errorf("check plugin answered unknown status %q", answer.Status)}
+		},
+	}
+}
+
+func runPlugin(command []string, timeout time.Duration, r BlockResult) (pluginAnswer, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return pluginAnswer{}, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return pluginAnswer{}, fmt.Errorf("%w: %s", err, msg)
+		}
+		return pluginAnswer{}, err
+	}
+	var answer pluginAnswer
+	if err := json.Unmarshal(out, &answer); err != nil {
+		return pluginAnswer{}, fmt.Errorf("error decoding answer: %w", err)
+	}
+	return answer, nil
+}
+
+// validatePlugins makes sure every plugin has a command and a name of its own.
+func validatePlugins(cfg Config, names map[string]bool) error {
+	for i, plugin := range cfg.Plugins {
+		switch {
+		case plugin.Name == "":
+			return fmt.Errorf("plugin %d has no name", i)
+		case len(plugin.Command) == 0:
+			return fmt.Errorf("plugin %s has no command", plugin.Name)
+		case names[plugin.Name]:
+			return fmt.Errorf("plugin %s is named after another check", plugin.Name)
+		}
+		names[plugin.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// Poller runs the poll cycles of a node, keeping the trackers that compare
+// every cycle with the previous ones.
+type Poller struct {
+	client           *client.Client
+	spotCheckSamples int
+	// of the chain, to tell the progress of the epochs.
+	blockInterval      uint64
+	checkpointInterval uint32
+
+	quality   *qualityTracker
+	finality  *finalityTracker
+	reorgs    *reorgDetector
+	latency   *latencyTracker
+	spacing   *spacingTracker
+	gaps      *gapTracker
+	linkage   *linkageTracker
+	proposers *proposerTracker
+	slots     *slotTracker
+	authority *authorityTracker
+	peers     *peerTracker
+	sync      *syncTracker
+	bft       *bftTracker
+
+	afterFinalizedMissing int // poll cycles in a row.
+}
+
+// NewPoller returns the poller of the node served by client.
+func NewPoller(client *client.Client, cfg Config) *Poller {
+	return &Poller{
+		client:             client,
+		spotCheckSamples:   cfg.SpotCheckSamples,
+		blockInterval:      cfg.BlockInterval,
+		checkpointInterval: cfg.Thresholds.CheckpointInterval,
+		quality:            newQualityTracker(client, cfg.Thresholds.CheckpointInterval),
+		finality:           newFinalityTracker(client, cfg.FinalityRecheckCycles),
+		reorgs:             newReorgDetector(client, cfg.ReorgWindow),
+		latency:            newLatencyTracker(cfg.LatencyWindow),
+		spacing:            newSpacingTracker(cfg.BlockIntervalWindow),
+		gaps:               newGapTracker(cfg.GapWindow),
+		linkage:            newLinkageTracker(client),
+		proposers:          newProposerTracker(cfg.ProposerWindow),
+		slots:              newSlotTracker(cfg.BlockInterval, cfg.Thresholds.CheckpointInterval),
+		authority:          newAuthorityTracker(client, cfg.AuthorityPollCycles),
+		peers:              newPeerTracker(client, cfg.PeersPollCycles),
+		bft:                newBFTTracker(client, cfg.BFTPath, cfg.Thresholds.CheckpointInterval),
+		sync:               newSyncTracker(client.Name, time.Duration(cfg.SyncIntervals*cfg.BlockInterval)*time.Second),
+	}
+}
+
+// Resume verifies on the next poll cycle that the node did not roll back from
+// mark, finalized on the node before the monitor restarted.
+func (p *Poller) Resume(mark FinalizedMark) {
+	p.finality.resume(mark)
+}
+
+// SetLogger makes the poller log when the node starts and stops syncing.
+func (p *Poller) SetLogger(log client.Logger) {
+	p.sync.log = log
+}
+
+// Poll fetches the blocks of a poll cycle started at now from the node and
+// updates the trackers with them. ctx bounds the whole cycle.
+func (p *Poller) Poll(ctx context.Context, now time.Time) BlockResult {
+	blockResult := BlockResult{Node: p.client.Name, Time: now}
+
+	var (
+		best, justified, finalized, afterFinalized, finalizedHead client.JSONBlockSummary
+		bestErr, justifiedErr, finalizedErr, afterErr, headErr    error
+	)
+	var g errgroup.Group
+	g.Go(func() error {
+		best, bestErr = p.client.GetBestBlock(ctx)
+		return nil
+	})
+	g.Go(func() error {
+		justified, justifiedErr = p.client.GetJustifiedBlock(ctx)
+		return nil
+	})
+	g.Go(func() error {
+		if finalized, finalizedErr = p.client.GetFinalizedBlock(ctx); finalizedErr == nil {
+			afterFinalized, afterErr = p.client.GetBlockAfterFinalized(ctx, finalized.Number)
+			finalizedHead, headErr = p.client.GetBlockByNumber(ctx, finalized.Number)
+		}
+		return nil
+	})
+	g.Wait()
+
+	if bestErr != nil {
+		blockResult.BestErr = fmt.Errorf("error getting best block: %w", bestErr)
+	}
+	if justifiedErr != nil {
+		blockResult.JustifiedErr = fmt.Errorf("error getting justified block: %w", justifiedErr)
+	}
+	if finalizedErr != nil {
+		blockResult.FinalizedErr = fmt.Errorf("error getting finalized block: %w", finalizedErr)
+	}
+	if headErr != nil {
+		blockResult.FinalizedHeadErr = fmt.Errorf("error getting finalized block by number: %w", headErr)
+	}
+	switch {
+	case errors.Is(afterErr, client.ErrNotFound):
+		// Missing for a poll cycle is no failure of the node, lasting is.
+		p.afterFinalizedMissing++
+		blockResult.AfterFinalizedMissing = p.afterFinalizedMissing
+		afterErr = nil
+	case afterErr == nil && finalizedErr == nil:
+		p.afterFinalizedMissing = 0
+	}
+	if afterErr != nil {
+		blockResult.AfterFinalizedErr = fmt.Errorf("error getting after finalized block: %w", afterErr)
+	}
+
+	blockResult.Syncing = p.sync.update(now, best, bestErr == nil)
+
+	// A node whose endpoint is degraded is not polled further until it is probed again.
+	blockResult.Degraded = p.client.Degraded()
+	if errors.Is(bestErr, client.ErrCircuitOpen) {
+		// The other blocks are left unset, so that the checks comparing them
+		// with the previous poll cycles are skipped too.
+		if blockResult.JustifiedErr == nil {
+			blockResult.JustifiedErr = fmt.Errorf("error getting justified block: %w", bestErr)
+		}
+		if blockResult.FinalizedErr == nil {
+			blockResult.FinalizedErr = fmt.Errorf("error getting finalized block: %w", bestErr)
+		}
+		if blockResult.AfterFinalizedErr == nil {
+			blockResult.AfterFinalizedErr = fmt.Errorf("error getting after finalized block: %w", bestErr)
+		}
+		if blockResult.FinalizedHeadErr == nil {
+			blockResult.FinalizedHeadErr = fmt.Errorf("error getting finalized block by number: %w", bestErr)
+		}
+		return blockResult
+	}
+
+	blockResult.Best = best.Number
+	blockResult.BestID = best.ID
+	blockResult.BestTimestamp = best.Timestamp
+	if bestErr == nil {
+		blockResult.BlockSpacing = p.spacing.update(best)
+		blockResult.Epoch = newEpochProgress(best.Number, p.checkpointInterval, p.blockInterval)
+
+		newBlocks, linkageErrors, err := p.linkage.update(ctx, best)
+		if err != nil {
+			blockResult.LinkageErr = fmt.Errorf("error checking parent linkage: %w", err)
+		}
+		blockResult.LinkageErrors = linkageErrors
+		blockResult.Proposers = p.proposers.update(newBlocks)
+		blockResult.Slots = p.slots.update(newBlocks)
+
+		reorg, err := p.reorgs.update(ctx, best)
+		if err != nil {
+			blockResult.ReorgErr = fmt.Errorf("error checking best chain for reorgs: %w", err)
+		}
+		blockResult.Reorg = reorg
+	}
+
+	blockResult.Justified = justified.Number
+	blockResult.JustifiedID = justified.ID
+
+	blockResult.Finalized = finalized.Number
+	blockResult.FinalizedID = finalized.ID
+	if finalizedErr == nil {
+		reversions, rollback, err := p.finality.update(ctx, finalized)
+		if err != nil {
+			blockResult.ReversionErr = fmt.Errorf("error re-checking finalized blocks: %w", err)
+		}
+		blockResult.Reversions = reversions
+		blockResult.Rollback = rollback
+
+		spotChecked, err := spotCheckFinalized(ctx, p.client, finalized.Number, p.spotCheckSamples)
+		if err != nil {
+			blockResult.SpotCheckErr = fmt.Errorf("error spot-checking finalized blocks: %w", err)
+		}
+		blockResult.SpotChecked = spotChecked
+	}
+
+	if bestErr == nil && finalizedErr == nil {
+		var justifiedNumber uint32
+		if justifiedErr == nil {
+			justifiedNumber = justified.Number
+		}
+		blockResult.Latency = p.latency.update(now, best.Number, justifiedNumber, finalized)
+	}
+
+	blockResult.AfterFinalized = afterFinalized
+	blockResult.FinalizedHead = finalizedHead
+	blockResult.Gaps = p.gaps.update(blockResult)
+
+	if bestErr == nil {
+		roundQuality, err := p.quality.update(ctx, best.Number)
+		if err != nil {
+			blockResult.QualityErr = fmt.Errorf("error getting round quality: %w", err)
+		}
+		blockResult.Quality = roundQuality
+
+		bft, err := p.bft.update(ctx, best.Number)
+		if err != nil {
+			blockResult.BFTErr = fmt.Errorf("error getting bft state: %w", err)
+		}
+		blockResult.BFT = bft
+	}
+
+	authorityStats, err := p.authority.update(ctx)
+	if err != nil {
+		blockResult.AuthorityErr = fmt.Errorf("error reading authority contract: %w", err)
+	}
+	blockResult.Authority = authorityStats
+
+	peers, err := p.peers.update(ctx)
+	if err != nil {
+		blockResult.PeersErr = fmt.Errorf("error getting peers: %w", err)
+	}
+	blockResult.Peers = peers
+
+	blockResult.Outliers = p.client.TakeOutliers()
+	blockResult.Serving = p.client.Serving()
+	blockResult.TimedOut = errors.Is(ctx.Err(), context.DeadlineExceeded)
+	return blockResult
+}
@@ -0,0 +1,111 @@
+package checks
+
+import "time"
+
+// propagationHistory is how many best block heights not yet reported by
+// every node the fleet remembers, a node lagging further behind no longer
+// delaying the measures.
+const propagationHistory = 256
+
+// Propagation summarizes how long the recent best block heights took to
+// reach the nodes of the fleet, in seconds, as seen by the poll cycles.
+type Propagation struct {
+	Spread Stats // between the first and the last node reporting a height.
+	Delay  Stats // between the first node and this one reporting a height.
+}
+
+// heightSeen is when the nodes first reported a best block height.
+type heightSeen struct {
+	first, last time.Time
+}
+
+// propagationTracker records when every node first reports the best block
+// heights, until all of them did.
+type propagationTracker struct {
+	windowSize int
+	best       map[string]uint32 // of the synced nodes.
+	pending    map[uint32]*heightSeen
+	heights    []uint32 // of pending, lowest first.
+	done       uint32   // highest height no longer tracked.
+	spread     *window
+	delays     map[string]*window
+}
+
+func newPropagationTracker(windowSize int) *propagationTracker {
+	return &propagationTracker{
+		windowSize: windowSize,
+		best:       make(map[string]uint32),
+		pending:    make(map[uint32]*heightSeen),
+		spread:     newWindow(windowSize),
+		delays:     make(map[string]*window),
+	}
+}
+
+// record records the heights the best block of r advanced through, and
+// returns the propagation statistics of the fleet and of the node of r.
+func (t *propagationTracker) record(r BlockResult) Propagation {
+	if !r.Fetched(FieldBest | FieldSynced) {
+		// a syncing node would delay every height it catches up with.
+		t.remove(r.Node)
+		return t.stats(r.Node)
+	}
+	previous, known := t.best[r.Node]
+	t.best[r.Node] = r.Best
+	if !known {
+		// the heights the node reached before are not known.
+		t.done = max(t.done, r.Best)
+	}
+	delays, ok := t.delays[r.Node]
+	if !ok {
+		delays = newWindow(t.windowSize)
+		t.delays[r.Node] = delays
+	}
+
+	from := max(previous, t.done, r.Best-min(r.Best, propagationHistory)) + 1
+	for h := from; known && h <= r.Best; h++ {
+		seen, ok := t.pending[h]
+		if !ok {
+			seen = &heightSeen{first: r.Time}
+			t.pending[h] = seen
+			t.heights = append(t.heights, h)
+		}
+		seen.last = r.Time
+		if len(t.best) > 1 {
+			delays.add(r.Time.Sub(seen.first).Seconds())
+		}
+	}
+	t.complete()
+	return t.stats(r.Node)
+}
+
+// complete measures the spread of the heights every node reported, and
+// drops the oldest ones beyond the history.
+func (t *propagationTracker) complete() {
+	lowest := ^uint32(0)
+	for _, best := range t.best {
+		lowest = min(lowest, best)
+	}
+	for len(t.heights) > 0 && (t.heights[0] <= lowest || len(t.heights) > propagationHistory) {
+		h := t.heights[0]
+		if seen := t.pending[h]; h <= lowest && len(t.best) > 1 {
+			t.spread.add(seen.last.Sub(seen.first).Seconds())
+		}
+		delete(t.pending, h)
+		t.heights = t.heights[1:]
+		t.done = max(t.done, h)
+	}
+}
+
+func (t *propagationTracker) stats(node string) Propagation {
+	p := Propagation{Spread: t.spread.stats()}
+	if delays, ok := t.delays[node]; ok {
+		p.Delay = delays.stats()
+	}
+	return p
+}
+
+// remove forgets node, which no longer delays the heights it did not report.
+func (t *propagationTracker) remove(node string) {
+	delete(t.best, node)
+	delete(t.delays, node)
+}
@@ -0,0 +1,51 @@
+package checks
+
+import "github.com/paologalligit/justified/pkg/client"
+
+// ProposerStats summarizes who produced the recent blocks.
+type ProposerStats struct {
+	Blocks    int    // blocks in the window.
+	Active    int    // distinct signers in the window.
+	TopSigner string // signer of most blocks in the window.
+	TopShare  float64
+}
+
+// proposerTracker keeps the signers of the last blocks in a sliding window.
+type proposerTracker struct {
+	size    int
+	signers []string
+	counts  map[string]int
+}
+
+func newProposerTracker(size int) *proposerTracker {
+	return &proposerTracker{size: size, counts: make(map[string]int)}
+}
+
+func (t *proposerTracker) update(blocks []client.JSONBlockSummary) ProposerStats {
+	for _, block := range blocks {
+		if t.size <= 0 || block.Signer == "" || block.Signer == zeroAddress {
+			continue
+		}
+		if len(t.signers) == t.size {
+			oldest := t.signers[0]
+			t.signers = t.signers[1:]
+			if t.counts[oldest]--; t.counts[oldest] == 0 {
+				delete(t.counts, oldest)
+			}
+		}
+		t.signers = append(t.signers, block.Signer)
+		t.counts[block.Signer]++
+	}
+
+	stats := ProposerStats{Blocks: len(t.signers), Active: len(t.counts)}
+	top := 0
+	for signer, count := range t.counts {
+		if count > top || (count == top && signer < stats.TopSigner) {
+			top, stats.TopSigner = count, signer
+		}
+	}
+	if stats.Blocks > 0 {
+		stats.TopShare = float64(top) / float64(stats.Blocks)
+	}
+	return stats
+}
@@ -0,0 +1,109 @@
+package checks
+
+import (
+	"context"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// zeroAddress is the signer reported for the genesis block.
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// RoundQuality is the vote tally of a completed checkpoint round, recorded at its store point.
+type RoundQuality struct {
+	Checkpoint uint32
+	Votes      int // distinct signers that set the COM flag during the round.
+	Proposers  int // distinct signers that produced a block during the round.
+}
+
+// qualityBatchBlocks bounds the blocks of a round fetched per poll cycle, for
+// the rounds tallied from their start to fit the cycle deadline.
+const qualityBatchBlocks = 32
+
+// qualityTracker tallies the votes of a round as its blocks are produced, and
+// records its quality once its store point is reached.
+type qualityTracker struct {
+	client   *client.Client
+	interval uint32
+	latest   *RoundQuality
+	tally    *roundTally // of the round being fetched, nil between two rounds.
+}
+
+// roundTally is the tally of the blocks of a round fetched so far.
+type roundTally struct {
+	checkpoint uint32
+	next       uint32 // first block of the round not fetched yet.
+	lastID     string // of the block before next, empty at the checkpoint.
+	votes      map[string]bool
+	proposers  map[string]bool
+}
+
+func newQualityTracker(client *client.Client, interval uint32) *qualityTracker {
+	return &qualityTracker{client: client, interval: interval}
+}
+
+func newRoundTally(checkpoint uint32) *roundTally {
+	return &roundTally{checkpoint: checkpoint, next: checkpoint, votes: make(map[string]bool), proposers: make(map[string]bool)}
+}
+
+// update fetches the blocks produced since the previous call, up to best and
+// qualityBatchBlocks at a time, and returns the quality of the latest
+// recorded round. The latest round completed at best is tallied from its
+// start when not recorded yet, then every round as it is produced.
+func (t *qualityTracker) update(ctx context.Context, best uint32) (*RoundQuality, error) {
+	if best+1 < t.interval {
+		return nil, nil
+	}
+	completed := getCheckPoint(best+1-t.interval, t.interval)
+	for {
+		if t.tally == nil || t.tally.checkpoint < completed {
+			checkpoint := completed
+			if t.latest != nil && t.latest.Checkpoint >= completed {
+				checkpoint += t.interval
+			}
+			t.tally = newRoundTally(checkpoint)
+		}
+
+		storePoint := getStorePoint(t.tally.checkpoint, t.interval)
+		to := min(best, storePoint, t.tally.next+qualityBatchBlocks-1)
+		if t.tally.next <= to {
+			blocks, err := t.client.GetBlockRange(ctx, t.tally.next, to)
+			if err != nil {
+				return t.latest, err
+			}
+			if t.tally.lastID != "" && blocks[0].ParentID != t.tally.lastID {
+				// a reorg replaced blocks already tallied.
+				t.tally = newRoundTally(t.tally.checkpoint)
+				continue
+			}
+			t.tally.add(blocks)
+		}
+		if t.tally.next <= storePoint {
+			return t.latest, nil
+		}
+		t.latest = t.tally.quality()
+		t.tally = nil
+	}
+}
+
+func (r *roundTally) add(blocks []client.JSONBlockSummary) {
+	for _, block := range blocks {
+		if block.Signer != "" && block.Signer != zeroAddress {
+			r.proposers[block.Signer] = true
+			if block.COM {
+				r.votes[block.Signer] = true
+			}
+		}
+	}
+	r.next += uint32(len(blocks))
+	r.lastID = blocks[len(blocks)-1].ID
+}
+
+func (r *roundTally) quality() *RoundQuality {
+	return &RoundQuality{Checkpoint: r.checkpoint, Votes: len(r.votes), Proposers: len(r.proposers)}
+}
+
+// quorum is the minimum number of votes for a round to be justified, more than 2/3 of the proposers.
+func quorum(maxBlockProposers uint64) int {
+	return int(maxBlockProposers*2/3) + 1
+}
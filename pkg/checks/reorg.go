@@ -0,0 +1,114 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// Reorg describes a change of the best chain below previously seen blocks.
+type Reorg struct {
+	ForkHeight uint32 // highest height still matching the previously seen chain.
+	Depth      uint32 // number of previously seen blocks no longer on the best chain.
+	OldID      string // previously seen block right above the fork.
+	NewID      string // block now at the same height.
+}
+
+func (r Reorg) String() string {
+	return fmt.Sprintf("reorg of depth %d above block %d: %s replaced by %s", r.Depth, r.ForkHeight, r.OldID, r.NewID)
+}
+
+type seenBlock struct {
+	number uint32
+	id     string
+	valid  bool
+}
+
+// reorgDetector keeps a ring buffer of the recent best chain, indexed by height.
+type reorgDetector struct {
+	client  *client.Client
+	ring    []seenBlock
+	highest uint32
+}
+
+func newReorgDetector(client *client.Client, size int) *reorgDetector {
+	return &reorgDetector{client: client, ring: make([]seenBlock, size)}
+}
+
+func (d *reorgDetector) lookup(number uint32) (string, bool) {
+	if len(d.ring) == 0 {
+		return "", false
+	}
+	entry := d.ring[number%uint32(len(d.ring))]
+	if !entry.valid || entry.number != number {
+		return "", false
+	}
+	return entry.id, true
+}
+
+func (d *reorgDetector) store(number uint32, id string) {
+	if len(d.ring) == 0 {
+		return
+	}
+	d.ring[number%uint32(len(d.ring))] = seenBlock{number: number, id: id, valid: true}
+}
+
+// canonicalID returns the ID of the block at number on the chain ending at best.
+func (d *reorgDetector) canonicalID(ctx context.Context, best client.JSONBlockSummary, number uint32) (string, error) {
+	switch {
+	case number == best.Number:
+		return best.ID, nil
+	case number+1 == best.Number:
+		return best.ParentID, nil
+	}
+	block, err := d.client.GetBlockByNumber(ctx, number)
+	if err != nil {
+		return "", fmt.Errorf("error getting block %d: %w", number, err)
+	}
+	return block.ID, nil
+}
+
+// update records the new best block and reports a reorg if a previously seen
+// height now resolves to a different block.
+func (d *reorgDetector) update(ctx context.Context, best client.JSONBlockSummary) (*Reorg, error) {
+	var (
+		reorg  *Reorg
+		height = min(best.Number, d.highest)
+	)
+
+	// Walk down the recorded chain until it agrees with the new one.
+	for {
+		recorded, ok := d.lookup(height)
+		if !ok {
+			break
+		}
+		id, err := d.canonicalID(ctx, best, height)
+		if err != nil {
+			return nil, err
+		}
+		if id == recorded {
+			break
+		}
+		reorg = &Reorg{OldID: recorded, NewID: id}
+		d.store(height, id)
+		if height == 0 {
+			break
+		}
+		height--
+	}
+
+	if reorg != nil {
+		reorg.ForkHeight = height
+		reorg.Depth = max(d.highest, best.Number) - height
+	}
+
+	// Blocks above a lower best block are no longer on the best chain.
+	for h := best.Number + 1; h <= d.highest && h-best.Number <= uint32(len(d.ring)); h++ {
+		d.ring[h%uint32(len(d.ring))].valid = false
+	}
+	d.store(best.Number, best.ID)
+	d.highest = best.Number
+
+	return reorg, nil
+}
@@ -0,0 +1,103 @@
+package checks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// forkedChain serves the blocks of chain a up to forkAt, of chain b above.
+type forkedChain struct {
+	forkAt atomic.Uint32 // no fork when 0.
+}
+
+func (c *forkedChain) block(number uint32) client.JSONBlockSummary {
+	block := client.JSONBlockSummary{Number: number, ID: c.id(number)}
+	if number > 0 {
+		block.ParentID = c.id(number - 1)
+	}
+	return block
+}
+
+func (c *forkedChain) id(number uint32) string {
+	if forkAt := c.forkAt.Load(); forkAt > 0 && number > forkAt {
+		return fmt.Sprintf("b-%d", number)
+	}
+	return fmt.Sprintf("a-%d", number)
+}
+
+func (c *forkedChain) serveBlock(w http.ResponseWriter, r *http.Request) {
+	number, err := strconv.ParseUint(r.PathValue("revision"), 10, 32)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(c.block(uint32(number)))
+}
+
+func TestReorgDetector(t *testing.T) {
+	type step struct {
+		forkAt uint32
+		best   uint32
+		want   *Reorg
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name:  "linked",
+			steps: []step{{best: 10}, {best: 11}, {best: 13}},
+		},
+		{
+			name: "tip replaced",
+			steps: []step{{best: 10}, {best: 11},
+				{forkAt: 9, best: 11, want: &Reorg{ForkHeight: 9, Depth: 2, OldID: "a-10", NewID: "b-10"}},
+				{forkAt: 9, best: 12}},
+		},
+		{
+			name: "below the best parent",
+			steps: []step{{best: 10}, {best: 11}, {best: 12}, {best: 13}, {best: 14},
+				{forkAt: 11, best: 15, want: &Reorg{ForkHeight: 11, Depth: 4, OldID: "a-12", NewID: "b-12"}}},
+		},
+		{
+			name: "lower best",
+			steps: []step{{best: 10}, {best: 11}, {best: 12},
+				{forkAt: 10, best: 11, want: &Reorg{ForkHeight: 10, Depth: 2, OldID: "a-11", NewID: "b-11"}},
+				// block 12 of chain a was forgotten.
+				{forkAt: 10, best: 12}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := &forkedChain{}
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /blocks/{revision}", chain.serveBlock)
+			server := httptest.NewServer(mux)
+			defer server.Close()
+			detector := newReorgDetector(client.New("node", client.NewHTTPBackend(&http.Client{}, server.URL, time.Second, client.Auth{}, 0)), 16)
+
+			for i, step := range tt.steps {
+				chain.forkAt.Store(step.forkAt)
+				reorg, err := detector.update(context.Background(), chain.block(step.best))
+				if err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+				switch {
+				case step.want == nil && reorg != nil:
+					t.Errorf("step %d: got %s, want none", i, reorg)
+				case step.want != nil && (reorg == nil || *reorg != *step.want):
+					t.Errorf("step %d: got %v, want %s", i, reorg, step.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package checks
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// BlockResult holds the blocks fetched from a node in a poll cycle.
+type BlockResult struct {
+	Node           string
+	Chain          string    `json:",omitempty"` // of the node, empty when a single chain is monitored.
+	Time           time.Time // when the poll cycle started.
+	Best           uint32
+	BestID         string
+	BestTimestamp  uint64 `json:",omitempty"` // unix time the best block was produced at.
+	Justified      uint32
+	JustifiedID    string
+	Finalized      uint32
+	AfterFinalized client.JSONBlockSummary
+	FinalizedID    string
+	FinalizedHead  client.JSONBlockSummary // the finalized block fetched by number.
+	Quality        *RoundQuality           // latest completed round, nil before the first store point.
+	Reversions     []FinalityReversion
+	Rollback       *FinalityRollback         `json:",omitempty"` // below the finalized block persisted before a restart.
+	SpotChecked    []client.JSONBlockSummary // random blocks below the finalized one.
+	Reorg          *Reorg                    // reorg of the best chain since the previous poll, if any.
+	Outliers       []string                  // quorum members that disagreed with the majority.
+	TimedOut       bool                      // the poll cycle was cancelled at its deadline.
+	Degraded       []string                  // endpoints whose circuit breaker is open.
+	Serving        string                    `json:",omitempty"` // endpoint of a failover node which answered last.
+	Lag            *NodeLag                  `json:",omitempty"` // behind the other nodes of the fleet, nil when alone.
+	Propagation    Propagation               // of the best block heights across the fleet.
+	Syncing        bool                      `json:",omitempty"` // the node is catching up with the network.
+	Epoch          *EpochProgress            `json:",omitempty"` // of the best block, nil when it was not fetched.
+	LinkageErrors  []string                  // new best blocks not linked to the previously seen ones.
+	Proposers      ProposerStats
+	Slots          SlotStats
+	Authority      *AuthorityStats // nil until the authority contract was read.
+	Peers          *int            // connected peers, nil until the peers were fetched.
+	Latency        FinalityLatency
+	Gaps           FinalityGaps
+	BlockSpacing   Stats            // seconds per block over the recent best blocks.
+	BFT            *client.BFTState `json:",omitempty"` // nil until fetched from the debug endpoint.
+
+	// AfterFinalizedMissing is how many poll cycles in a row the block after
+	// the finalized one was not found, AfterFinalized being left unset.
+	AfterFinalizedMissing int `json:",omitempty"`
+
+	// Errors of the poll cycle, nil when the field they describe was fetched.
+	BestErr           error `json:"-"`
+	JustifiedErr      error `json:"-"`
+	FinalizedErr      error `json:"-"`
+	AfterFinalizedErr error `json:"-"`
+	FinalizedHeadErr  error `json:"-"`
+	LinkageErr        error `json:"-"`
+	ReorgErr          error `json:"-"`
+	ReversionErr      error `json:"-"`
+	SpotCheckErr      error `json:"-"`
+	QualityErr        error `json:"-"` // not in Errs.
+	BFTErr            error `json:"-"` // not in Errs.
+	AuthorityErr      error `json:"-"` // not in Errs.
+	PeersErr          error `json:"-"` // not in Errs.
+	CycleErr          error `json:"-"`
+
+	Trace trace.SpanContext `json:"-"` // span of the poll cycle.
+	// State is the state of the poller after the cycle, when snapshots are taken.
+	State *PollerState `json:"-"`
+}
+
+func (br BlockResult) String() string {
+	return fmt.Sprintf("Node: %s, Best: %d, Justified: %d, Finalized: %d, Error: %v", br.Node, br.Best, br.Justified, br.Finalized, br.Err())
+}
+
+// Err joins the errors of the poll cycle, nil when it fully succeeded.
+func (br BlockResult) Err() error {
+	return errors.Join(br.Errs()...)
+}
+
+// Errs returns the non-nil errors of the poll cycle fetching the best,
+// justified and finalized chains. The errors of the other sources of data,
+// QualityErr, BFTErr, AuthorityErr and PeersErr, are left out, reported by
+// their own checks.
+func (br BlockResult) Errs() []error {
+	var errs []error
+	for _, err := range []error{br.BestErr, br.JustifiedErr, br.FinalizedErr, br.AfterFinalizedErr, br.FinalizedHeadErr,
+		br.LinkageErr, br.ReorgErr, br.ReversionErr, br.SpotCheckErr, br.CycleErr} {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Field is a set of BlockResult fields a check depends on.
+type Field uint
+
+const (
+	FieldBest Field = 1 << iota
+	FieldJustified
+	FieldFinalized
+	FieldAfterFinalized
+	// FieldSynced is set once the node caught up with the network.
+	FieldSynced
+	FieldFinalizedHead
+)
+
+// Fetched reports whether all the fields were fetched without error.
+func (br BlockResult) Fetched(fields Field) bool {
+	return (fields&FieldBest == 0 || br.BestErr == nil) &&
+		(fields&FieldJustified == 0 || br.JustifiedErr == nil) &&
+		(fields&FieldFinalized == 0 || br.FinalizedErr == nil) &&
+		(fields&FieldAfterFinalized == 0 || br.FinalizedErr == nil && br.AfterFinalizedErr == nil && br.AfterFinalizedMissing == 0) &&
+		(fields&FieldSynced == 0 || !br.Syncing) &&
+		(fields&FieldFinalizedHead == 0 || br.FinalizedErr == nil && br.FinalizedHeadErr == nil)
+}
+
+// joinMessages joins the messages into one error, nil when there are none.
+func joinMessages(msgs []string) error {
+	errs := make([]error, 0, len(msgs))
+	for _, msg := range msgs {
+		errs = append(errs, errors.New(msg))
+	}
+	return errors.Join(errs...)
+}
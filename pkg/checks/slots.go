@@ -0,0 +1,67 @@
+package checks
+
+import "github.com/paologalligit/justified/pkg/client"
+
+// EpochSlots counts the produced blocks and missed slots of one checkpoint epoch.
+type EpochSlots struct {
+	Epoch    uint32 // checkpoint number / checkpoint interval.
+	Produced uint64
+	Missed   uint64
+}
+
+// MissRate is the fraction of slots of the epoch without a block.
+func (e EpochSlots) MissRate() float64 {
+	if e.Produced+e.Missed == 0 {
+		return 0
+	}
+	return float64(e.Missed) / float64(e.Produced+e.Missed)
+}
+
+// SlotStats holds the slot counters of the current and the last completed epoch.
+type SlotStats struct {
+	Current  EpochSlots
+	Previous *EpochSlots // nil until an epoch was completed.
+	Missed   uint64      // missed slots since the monitor started.
+}
+
+// slotTracker derives missed slots from the timestamps of consecutive blocks.
+type slotTracker struct {
+	blockInterval      uint64
+	checkpointInterval uint32
+	last               client.JSONBlockSummary
+	stats              SlotStats
+}
+
+func newSlotTracker(blockInterval uint64, checkpointInterval uint32) *slotTracker {
+	return &slotTracker{blockInterval: blockInterval, checkpointInterval: checkpointInterval}
+}
+
+// update counts the slots elapsed between the previously seen block and blocks,
+// which must be the blocks produced since it in ascending order.
+func (t *slotTracker) update(blocks []client.JSONBlockSummary) SlotStats {
+	for _, block := range blocks {
+		if t.last.ID == "" || block.Number <= t.last.Number || block.Timestamp <= t.last.Timestamp || t.blockInterval == 0 {
+			t.last = block
+			continue
+		}
+
+		epoch := block.Number / t.checkpointInterval
+		if epoch != t.stats.Current.Epoch {
+			if t.stats.Current.Produced > 0 {
+				previous := t.stats.Current
+				t.stats.Previous = &previous
+			}
+			t.stats.Current = EpochSlots{Epoch: epoch}
+		}
+
+		slots := (block.Timestamp - t.last.Timestamp) / t.blockInterval
+		produced := uint64(block.Number - t.last.Number)
+		t.stats.Current.Produced += produced
+		if slots > produced {
+			t.stats.Current.Missed += slots - produced
+			t.stats.Missed += slots - produced
+		}
+		t.last = block
+	}
+	return t.stats
+}
@@ -0,0 +1,112 @@
+package checks
+
+import (
+	"slices"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// PollerState is the state a poller accumulated over its poll cycles, to be
+// snapshotted and restored across restarts of the monitor. The recent best
+// chain kept for the reorg detection is left out, a restart usually lasting
+// longer than it covers.
+type PollerState struct {
+	Finalized []FinalizedMark `json:"finalized"` // recorded for re-checking, oldest first.
+
+	LastBest   client.JSONBlockSummary `json:"lastBest"`   // linked to the next best block.
+	LastSpaced client.JSONBlockSummary `json:"lastSpaced"` // the block spacing is measured from.
+	Spacing    []float64               `json:"spacing"`
+
+	LatencyFinalized uint32    `json:"latencyFinalized"`
+	LatencySeconds   []float64 `json:"latencySeconds"`
+	LatencyBlocks    []float64 `json:"latencyBlocks"`
+	Promotion        []float64 `json:"promotion"`
+
+	JustifiedGaps []float64 `json:"justifiedGaps"`
+	FinalizedGaps []float64 `json:"finalizedGaps"`
+
+	Signers  []string                `json:"signers"` // of the recent blocks, oldest first.
+	LastSlot client.JSONBlockSummary `json:"lastSlot"`
+	Slots    SlotStats               `json:"slots"`
+	Synced   bool                    `json:"synced"`
+}
+
+// State returns a copy of the state of the poller.
+func (p *Poller) State() PollerState {
+	s := PollerState{
+		LastBest:         p.linkage.last,
+		LastSpaced:       p.spacing.last,
+		Spacing:          slices.Clone(p.spacing.spacing.samples),
+		LatencyFinalized: p.latency.finalized,
+		LatencySeconds:   slices.Clone(p.latency.seconds.samples),
+		LatencyBlocks:    slices.Clone(p.latency.blocks.samples),
+		Promotion:        slices.Clone(p.latency.promotion.samples),
+		JustifiedGaps:    slices.Clone(p.gaps.justified.samples),
+		FinalizedGaps:    slices.Clone(p.gaps.finalized.samples),
+		Signers:          slices.Clone(p.proposers.signers),
+		LastSlot:         p.slots.last,
+		Slots:            p.slots.stats,
+		Synced:           p.sync.synced,
+	}
+	if previous := s.Slots.Previous; previous != nil {
+		copied := *previous
+		s.Slots.Previous = &copied
+	}
+	for _, number := range p.finality.order {
+		s.Finalized = append(s.Finalized, FinalizedMark{Number: number, ID: p.finality.ids[number]})
+	}
+	return s
+}
+
+// Restore resumes the poller from the state of a previous one, before its
+// first poll cycle. The windows keep the most recent samples they can hold.
+func (p *Poller) Restore(s PollerState) {
+	for _, mark := range s.Finalized {
+		if _, ok := p.finality.ids[mark.Number]; !ok {
+			p.finality.record(mark.Number, mark.ID)
+		}
+	}
+	p.linkage.last = s.LastBest
+	p.spacing.last = s.LastSpaced
+	addAll(p.spacing.spacing, s.Spacing)
+	p.latency.finalized = s.LatencyFinalized
+	addAll(p.latency.seconds, s.LatencySeconds)
+	addAll(p.latency.blocks, s.LatencyBlocks)
+	addAll(p.latency.promotion, s.Promotion)
+	addAll(p.gaps.justified, s.JustifiedGaps)
+	addAll(p.gaps.finalized, s.FinalizedGaps)
+	p.proposers.update(signed(s.Signers))
+	p.slots.last = s.LastSlot
+	p.slots.stats = s.Slots
+	if s.Synced {
+		p.sync.synced = true
+	}
+}
+
+func addAll(w *window, samples []float64) {
+	for _, v := range samples {
+		w.add(v)
+	}
+}
+
+// signed returns blocks signed by signers, to replay them into a proposer tracker.
+func signed(signers []string) []client.JSONBlockSummary {
+	blocks := make([]client.JSONBlockSummary, len(signers))
+	for i, signer := range signers {
+		blocks[i].Signer = signer
+	}
+	return blocks
+}
+
+// Replay runs checks against the results of previous poll cycles, oldest
+// first, so that the checks comparing a cycle with the previous ones resume
+// from them. The outcomes are discarded.
+func Replay(checks []Check, results []BlockResult) {
+	for _, r := range results {
+		for _, check := range checks {
+			if r.Fetched(check.Needs) {
+				check.Run(r)
+			}
+		}
+	}
+}
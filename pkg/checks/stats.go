@@ -0,0 +1,168 @@
+package checks
+
+import (
+	"sort"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// window keeps the last samples added to it.
+type window struct {
+	size    int
+	samples []float64
+}
+
+func newWindow(size int) *window {
+	return &window{size: size}
+}
+
+func (w *window) add(v float64) {
+	if w.size <= 0 {
+		return
+	}
+	if len(w.samples) == w.size {
+		w.samples = w.samples[1:]
+	}
+	w.samples = append(w.samples, v)
+}
+
+// Stats summarizes the samples of a window.
+type Stats struct {
+	Count  int
+	Min    float64
+	Avg    float64
+	Median float64
+	P95    float64
+}
+
+func (w *window) stats() Stats {
+	if len(w.samples) == 0 {
+		return Stats{}
+	}
+	sorted := append([]float64(nil), w.samples...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	return Stats{
+		Count:  len(sorted),
+		Min:    sorted[0],
+		Avg:    sum / float64(len(sorted)),
+		Median: percentile(sorted, 0.5),
+		P95:    percentile(sorted, 0.95),
+	}
+}
+
+// percentile returns the nearest-rank percentile p of the sorted samples.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+// FinalityLatency summarizes how long recent checkpoints took to become
+// finalized, both in wall-clock seconds and in blocks produced meanwhile,
+// and how long they took from justified to finalized. A growing promotion
+// time is an early sign of votes going missing.
+type FinalityLatency struct {
+	Seconds   Stats
+	Blocks    Stats
+	Promotion Stats // seconds from the poll cycle a checkpoint was first justified in to the one it was finalized in.
+}
+
+// latencyTracker measures the finality latency of every newly finalized checkpoint.
+type latencyTracker struct {
+	finalized uint32
+	seconds   *window
+	blocks    *window
+
+	justified   uint32
+	justifiedAt map[uint32]time.Time // of the checkpoints seen becoming justified, until finalized.
+	promotion   *window
+}
+
+func newLatencyTracker(size int) *latencyTracker {
+	return &latencyTracker{seconds: newWindow(size), blocks: newWindow(size), justifiedAt: make(map[uint32]time.Time), promotion: newWindow(size)}
+}
+
+// update records the latency of finalized if it was not finalized at the
+// previous call, and when justified was justified first, zero if unknown.
+func (t *latencyTracker) update(now time.Time, best, justified uint32, finalized client.JSONBlockSummary) FinalityLatency {
+	if justified > t.justified {
+		// A checkpoint justified before the first call was justified earlier than seen.
+		if t.justified != 0 {
+			t.justifiedAt[justified] = now
+		}
+		t.justified = justified
+	}
+	if finalized.Number > t.finalized {
+		if t.finalized != 0 {
+			produced := time.Unix(int64(finalized.Timestamp), 0)
+			t.seconds.add(now.Sub(produced).Seconds())
+			t.blocks.add(float64(best - finalized.Number))
+		}
+		if at, ok := t.justifiedAt[finalized.Number]; ok {
+			t.promotion.add(now.Sub(at).Seconds())
+		}
+		for n := range t.justifiedAt {
+			if n <= finalized.Number {
+				delete(t.justifiedAt, n)
+			}
+		}
+		t.finalized = finalized.Number
+	}
+	return FinalityLatency{Seconds: t.seconds.stats(), Blocks: t.blocks.stats(), Promotion: t.promotion.stats()}
+}
+
+// FinalityGaps summarizes the blocks between the best block and the justified
+// and finalized ones over the recent poll cycles.
+type FinalityGaps struct {
+	Justified Stats
+	Finalized Stats
+}
+
+// gapTracker records the finality gaps of every poll cycle.
+type gapTracker struct {
+	justified *window
+	finalized *window
+}
+
+func newGapTracker(size int) *gapTracker {
+	return &gapTracker{justified: newWindow(size), finalized: newWindow(size)}
+}
+
+// update records the gaps of the blocks fetched in a poll cycle, r telling
+// which ones were. The gaps of a syncing node are not representative and
+// are left out.
+func (t *gapTracker) update(r BlockResult) FinalityGaps {
+	if r.Fetched(FieldBest | FieldJustified | FieldSynced) {
+		t.justified.add(float64(int64(r.Best) - int64(r.Justified)))
+	}
+	if r.Fetched(FieldBest | FieldFinalized | FieldSynced) {
+		t.finalized.add(float64(int64(r.Best) - int64(r.Finalized)))
+	}
+	return FinalityGaps{Justified: t.justified.stats(), Finalized: t.finalized.stats()}
+}
+
+// spacingTracker measures the average spacing between the best blocks seen.
+type spacingTracker struct {
+	last    client.JSONBlockSummary
+	spacing *window
+}
+
+func newSpacingTracker(size int) *spacingTracker {
+	return &spacingTracker{spacing: newWindow(size)}
+}
+
+// update records the seconds per block produced since the previous best block.
+func (t *spacingTracker) update(best client.JSONBlockSummary) Stats {
+	if t.last.Number != 0 && best.Number > t.last.Number && best.Timestamp >= t.last.Timestamp {
+		t.spacing.add(float64(best.Timestamp-t.last.Timestamp) / float64(best.Number-t.last.Number))
+	}
+	if best.Number != t.last.Number {
+		t.last = best
+	}
+	return t.spacing.stats()
+}
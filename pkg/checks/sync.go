@@ -0,0 +1,43 @@
+package checks
+
+import (
+	"time"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// syncTracker tells whether a node is still catching up with the network,
+// from the first poll until its best block is recent. A node falling behind
+// later on is lagging, not syncing, and is checked as usual.
+type syncTracker struct {
+	name    string
+	maxAge  time.Duration // of the best block of a synced node, the tracker is disabled when 0.
+	syncing bool          // the node was seen syncing.
+	synced  bool
+	log     client.Logger
+}
+
+func newSyncTracker(name string, maxAge time.Duration) *syncTracker {
+	return &syncTracker{name: name, maxAge: maxAge, synced: maxAge == 0}
+}
+
+// update records the best block fetched at now, if ok, and reports whether
+// the node is still syncing.
+func (t *syncTracker) update(now time.Time, best client.JSONBlockSummary, ok bool) bool {
+	if t.synced || !ok {
+		return !t.synced
+	}
+	age := now.Sub(time.Unix(int64(best.Timestamp), 0))
+	if age <= t.maxAge {
+		if t.syncing {
+			t.log.Printf("Node %s synced at block %d", t.name, best.Number)
+		}
+		t.synced = true
+		return false
+	}
+	if !t.syncing {
+		t.log.Printf("Node %s is syncing, best block %d is %s old: the finality range checks are suspended until it catches up", t.name, best.Number, age.Round(time.Second))
+	}
+	t.syncing = true
+	return true
+}
@@ -0,0 +1,29 @@
+package checks
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records the spans of the checks. It does nothing unless tracing is configured.
+var tracer = otel.Tracer("github.com/paologalligit/justified/pkg/checks")
+
+// startCheckSpan starts the span of a check as a child of the poll cycle of r.
+func startCheckSpan(r BlockResult, name string) trace.Span {
+	ctx := trace.ContextWithSpanContext(context.Background(), r.Trace)
+	_, span := tracer.Start(ctx, "check "+name, trace.WithAttributes(attribute.String("node", r.Node), attribute.String("check", name)))
+	return span
+}
+
+// endSpan records err on span, if any, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
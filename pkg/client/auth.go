@@ -0,0 +1,47 @@
+package client
+
+import (
+	"encoding/base64"
+	"net/http"
+)
+
+// Auth holds the credentials sent to a node behind an authenticating proxy.
+type Auth struct {
+	Headers     map[string]string `json:"headers"`     // added as is, e.g. {"X-API-Key": "..."}.
+	BearerToken string            `json:"bearerToken"` // sent as Authorization: Bearer.
+	Username    string            `json:"username"`    // basic auth, with Password.
+	Password    string            `json:"password"`
+}
+
+// header returns the headers of every request authenticated with a.
+func (a Auth) header() http.Header {
+	header := make(http.Header)
+	for key, value := range a.Headers {
+		header.Set(key, value)
+	}
+	if a.BearerToken != "" {
+		header.Set("Authorization", "Bearer "+a.BearerToken)
+	}
+	if a.Username != "" || a.Password != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(a.Username+":"+a.Password)))
+	}
+	return header
+}
+
+// Redacted returns a without the secrets, to be logged.
+func (a Auth) Redacted() Auth {
+	redacted := Auth{Username: a.Username}
+	if len(a.Headers) > 0 {
+		redacted.Headers = make(map[string]string, len(a.Headers))
+		for key := range a.Headers {
+			redacted.Headers[key] = "redacted"
+		}
+	}
+	if a.BearerToken != "" {
+		redacted.BearerToken = "redacted"
+	}
+	if a.Password != "" {
+		redacted.Password = "redacted"
+	}
+	return redacted
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for the requests refused by an open circuit.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// BreakerBackend refuses the requests to an endpoint which failed repeatedly
+// until its cooldown elapsed. The first request after the cooldown probes the
+// endpoint: it closes the circuit on success and re-opens it on failure. An
+// endpoint is a URL of a node, every member of a quorum or failover node
+// having its own circuit, shared by all the requests to the URL.
+type BreakerBackend struct {
+	name     string
+	backend  Backend
+	failures int
+	cooldown time.Duration
+	log      Logger
+
+	mu       sync.Mutex
+	failed   int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewBreakerBackend(name string, b Backend, failures int, cooldown time.Duration) *BreakerBackend {
+	return &BreakerBackend{name: name, backend: b, failures: failures, cooldown: cooldown}
+}
+
+// SetLogger makes the backend log when the endpoint degrades and recovers.
+func (b *BreakerBackend) SetLogger(log Logger) {
+	b.log = log
+}
+
+func (b *BreakerBackend) GetBlock(ctx context.Context, revision string) (JSONBlockSummary, error) {
+	if err := b.allow(); err != nil {
+		return JSONBlockSummary{}, err
+	}
+	block, err := b.backend.GetBlock(ctx, revision)
+	b.record(ctx, err)
+	return block, err
+}
+
+func (b *BreakerBackend) Inspect(ctx context.Context, clauses []Clause) ([]CallResult, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	results, err := b.backend.Inspect(ctx, clauses)
+	b.record(ctx, err)
+	return results, err
+}
+
+func (b *BreakerBackend) Peers(ctx context.Context) ([]PeerStats, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	peers, err := b.backend.Peers(ctx)
+	b.record(ctx, err)
+	return peers, err
+}
+
+func (b *BreakerBackend) BFT(ctx context.Context, path string) (BFTState, error) {
+	if err := b.allow(); err != nil {
+		return BFTState{}, err
+	}
+	state, err := b.backend.BFT(ctx, path)
+	b.record(ctx, err)
+	return state, err
+}
+
+// allow fails with errCircuitOpen while the circuit is open and the endpoint
+// is not due for a probe.
+func (b *BreakerBackend) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failed < b.failures {
+		return nil
+	}
+	if !b.probing && time.Since(b.openedAt) >= b.cooldown {
+		b.probing = true
+		return nil
+	}
+	return fmt.Errorf("%s: %w", b.name, ErrCircuitOpen)
+}
+
+func (b *BreakerBackend) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// A request cancelled by the caller says nothing about the endpoint, nor
+	// does a rate limited or throttled one, retried once the node or the
+	// limiter allows it: only the probe they may have been is released.
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) || errors.Is(err, ErrRateLimited) || errors.Is(err, ErrThrottled) {
+		b.probing = false
+		return
+	}
+
+	if err == nil {
+		if b.failed >= b.failures {
+			b.log.Printf("Endpoint %s recovered", b.name)
+		}
+		b.failed = 0
+		b.probing = false
+		return
+	}
+
+	b.failed++
+	if b.failed >= b.failures {
+		if b.failed == b.failures || b.probing {
+			b.log.Printf("Endpoint %s degraded after %d failures, next probe in %s: %v", b.name, b.failed, b.cooldown, err)
+		}
+		b.openedAt = time.Now()
+		b.probing = false
+	}
+}
+
+// open reports whether the circuit of the endpoint is open.
+func (b *BreakerBackend) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failed >= b.failures
+}
+
+// Degraded returns the endpoints of the node whose circuit is open.
+func (c *Client) Degraded() []string {
+	var breakers []*BreakerBackend
+	switch b := c.backend.(type) {
+	case *BreakerBackend:
+		breakers = append(breakers, b)
+	case *QuorumBackend:
+		breakers = append(breakers, memberBreakers(b.members)...)
+	case *FailoverBackend:
+		breakers = append(breakers, memberBreakers(b.members)...)
+	}
+
+	var degraded []string
+	for _, b := range breakers {
+		if b.open() {
+			degraded = append(degraded, b.name)
+		}
+	}
+	return degraded
+}
+
+func memberBreakers(members []QuorumMember) []*BreakerBackend {
+	var breakers []*BreakerBackend
+	for _, member := range members {
+		if b, ok := member.Backend.(*BreakerBackend); ok {
+			breakers = append(breakers, b)
+		}
+	}
+	return breakers
+}
@@ -0,0 +1,120 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+const breakerCooldown = 50 * time.Millisecond
+
+func newBreakerClient(t *testing.T) (*testNode, *client.Client) {
+	node := newTestNode(t, 10, "a")
+	return node, client.New("node", client.NewBreakerBackend("endpoint", node.backend(), 2, breakerCooldown))
+}
+
+func degraded(c *client.Client) bool {
+	return slices.Contains(c.Degraded(), "endpoint")
+}
+
+func TestBreakerStates(t *testing.T) {
+	node, c := newBreakerClient(t)
+	ctx := context.Background()
+
+	node.status.Store(http.StatusInternalServerError)
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetBestBlock(ctx); err == nil {
+			t.Fatalf("request %d succeeded on a failing node", i)
+		}
+	}
+	if !degraded(c) {
+		t.Fatal("circuit closed after 2 failures")
+	}
+	requests := node.requests.Load()
+	if _, err := c.GetBestBlock(ctx); !errors.Is(err, client.ErrCircuitOpen) {
+		t.Errorf("request on an open circuit: got error %v, want ErrCircuitOpen", err)
+	}
+	if node.requests.Load() != requests {
+		t.Error("request on an open circuit reached the node")
+	}
+
+	// a failed probe re-opens the circuit for another cooldown.
+	time.Sleep(breakerCooldown)
+	if _, err := c.GetBestBlock(ctx); err == nil || errors.Is(err, client.ErrCircuitOpen) {
+		t.Errorf("probe of a failing node: got error %v, want the failure", err)
+	}
+	if _, err := c.GetBestBlock(ctx); !errors.Is(err, client.ErrCircuitOpen) {
+		t.Errorf("request after a failed probe: got error %v, want ErrCircuitOpen", err)
+	}
+
+	// a successful probe closes the circuit.
+	node.status.Store(http.StatusOK)
+	time.Sleep(breakerCooldown)
+	if _, err := c.GetBestBlock(ctx); err != nil {
+		t.Fatalf("probe of a recovered node: %v", err)
+	}
+	if degraded(c) {
+		t.Error("circuit open after a successful probe")
+	}
+}
+
+// cancelled cancels a request hanging on node.
+func cancelled(c *client.Client, node *testNode) error {
+	node.hang.Store(true)
+	defer node.hang.Store(false)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := c.GetBestBlock(ctx)
+	return err
+}
+
+func TestBreakerCancelledClosed(t *testing.T) {
+	node, c := newBreakerClient(t)
+	ctx := context.Background()
+
+	node.status.Store(http.StatusInternalServerError)
+	c.GetBestBlock(ctx)
+	for i := 0; i < 3; i++ {
+		if err := cancelled(c, node); !errors.Is(err, context.Canceled) {
+			t.Fatalf("cancelled request: got error %v, want context.Canceled", err)
+		}
+	}
+	if degraded(c) {
+		t.Fatal("cancelled requests opened the circuit")
+	}
+	// the failure before the cancelled requests still counts.
+	c.GetBestBlock(ctx)
+	if !degraded(c) {
+		t.Error("circuit closed after 2 failures around cancelled requests")
+	}
+}
+
+func TestBreakerCancelledProbe(t *testing.T) {
+	node, c := newBreakerClient(t)
+	ctx := context.Background()
+
+	node.status.Store(http.StatusInternalServerError)
+	c.GetBestBlock(ctx)
+	c.GetBestBlock(ctx)
+	time.Sleep(breakerCooldown)
+	if err := cancelled(c, node); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled probe: got error %v, want context.Canceled", err)
+	}
+	if !degraded(c) {
+		t.Fatal("cancelled probe closed the circuit")
+	}
+
+	// the endpoint is probed again at once.
+	node.status.Store(http.StatusOK)
+	if _, err := c.GetBestBlock(ctx); err != nil {
+		t.Fatalf("probe after a cancelled one: %v", err)
+	}
+	if degraded(c) {
+		t.Error("circuit open after a successful probe")
+	}
+}
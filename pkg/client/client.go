@@ -0,0 +1,406 @@
+// Package client fetches blocks from VeChain Thor nodes over their REST API
+// or a GraphQL gateway, optionally through a circuit breaker, a quorum of
+// endpoints or a failover list of endpoints.
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// ErrNotFound is returned for the blocks a node does not have.
+var ErrNotFound = errors.New("not found")
+
+// JSONBlockSummary is a block as returned by /blocks/{revision}.
+type JSONBlockSummary struct {
+	Number      uint32 `json:"number"`
+	ID          string `json:"id"`
+	ParentID    string `json:"parentID"`
+	Timestamp   uint64 `json:"timestamp"`
+	Signer      string `json:"signer"`
+	COM         bool   `json:"com"` // the signer voted for the round.
+	IsFinalized bool   `json:"isFinalized"`
+}
+
+// Backend fetches the blocks of a monitored node.
+type Backend interface {
+	// GetBlock fetches the block at revision, which is either a block number,
+	// a block ID or one of best, justified and finalized.
+	GetBlock(ctx context.Context, revision string) (JSONBlockSummary, error)
+	// Inspect executes read-only contract calls against the best block.
+	Inspect(ctx context.Context, clauses []Clause) ([]CallResult, error)
+	// Peers fetches the peers the node is connected to.
+	Peers(ctx context.Context) ([]PeerStats, error)
+	// BFT fetches the consensus state the node exposes at path.
+	BFT(ctx context.Context, path string) (BFTState, error)
+}
+
+// Clause is a contract call sent to /accounts/*.
+type Clause struct {
+	To    string `json:"to"`
+	Value string `json:"value"`
+	Data  string `json:"data"`
+}
+
+// CallResult is the outcome of a clause executed by /accounts/*.
+type CallResult struct {
+	Data     string `json:"data"`
+	Reverted bool   `json:"reverted"`
+	VMError  string `json:"vmError"`
+}
+
+// PeerStats is a peer of a node as returned by /node/network/peers.
+type PeerStats struct {
+	Name        string `json:"name"`
+	BestBlockID string `json:"bestBlockID"`
+	TotalScore  uint64 `json:"totalScore"`
+	PeerID      string `json:"peerID"`
+	NetAddr     string `json:"netAddr"`
+	Inbound     bool   `json:"inbound"`
+	Duration    uint64 `json:"duration"` // seconds since the peer connected.
+}
+
+// BFTState is the consensus state exposed by the debug endpoint of a node.
+// Thor does not expose one upstream, so the endpoint is expected to answer
+// this document, e.g. from a patched node or a sidecar.
+type BFTState struct {
+	Round      uint32 `json:"round"`      // checkpoint round being voted on.
+	View       uint32 `json:"view"`       // within the round, bumped when the proposers change.
+	Checkpoint uint32 `json:"checkpoint"` // of the previous round, whose voting completed.
+	Votes      int    `json:"votes"`      // committed votes the node counted for the previous round.
+	Quorum     int    `json:"quorum"`     // votes the node requires to justify a checkpoint.
+	Quality    uint32 `json:"quality"`    // justified rounds, never decreasing.
+}
+
+// DefaultMaxResponseSize bounds the decompressed body of the responses by default.
+const DefaultMaxResponseSize = 8 << 20
+
+// HTTPBackend fetches blocks from the REST API of a single node.
+type HTTPBackend struct {
+	client  *http.Client
+	baseURL string
+	timeout time.Duration // bounds every request, on top of the caller's context.
+	header  http.Header   // sent with every request.
+	maxBody int64         // bytes of a decompressed response body.
+	log     Logger        // of the rate limits, see SetLogger.
+
+	mu           sync.Mutex
+	cache        map[string]cachedBlock // by named revision.
+	limitedUntil time.Time              // Retry-After of the last 429 answer.
+}
+
+// cachedBlock is the last answer to a named revision with its validators.
+type cachedBlock struct {
+	etag         string
+	lastModified string
+	block        JSONBlockSummary
+}
+
+// NewHTTPBackend returns the backend of the node at baseURL, refusing the
+// responses larger than maxBody bytes, DefaultMaxResponseSize when zero.
+func NewHTTPBackend(client *http.Client, baseURL string, timeout time.Duration, auth Auth, maxBody int64) *HTTPBackend {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	return newHTTPBackend(client, baseURL, timeout, auth, maxBody)
+}
+
+// SetLogger makes the backend log when the node rate limits it.
+func (b *HTTPBackend) SetLogger(log Logger) {
+	b.log = log
+}
+
+func newHTTPBackend(client *http.Client, baseURL string, timeout time.Duration, auth Auth, maxBody int64) *HTTPBackend {
+	if maxBody <= 0 {
+		maxBody = DefaultMaxResponseSize
+	}
+	return &HTTPBackend{client: client, baseURL: baseURL, timeout: timeout, header: auth.header(), maxBody: maxBody, cache: make(map[string]cachedBlock)}
+}
+
+// Client fetches the blocks of a monitored node through its backend.
+type Client struct {
+	Name    string
+	backend Backend
+}
+
+// New returns the client of the node called name fetching its blocks from b.
+func New(name string, b Backend) *Client {
+	return &Client{Name: name, backend: b}
+}
+
+// Find returns the client of the node called name, or nil.
+func Find(clients []*Client, name string) *Client {
+	for _, c := range clients {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func (c *Client) GetBlock(ctx context.Context, revision string) (JSONBlockSummary, error) {
+	return c.backend.GetBlock(ctx, revision)
+}
+
+func (c *Client) Inspect(ctx context.Context, clauses []Clause) ([]CallResult, error) {
+	return c.backend.Inspect(ctx, clauses)
+}
+
+func (c *Client) GetPeers(ctx context.Context) ([]PeerStats, error) {
+	return c.backend.Peers(ctx)
+}
+
+func (c *Client) GetBFT(ctx context.Context, path string) (BFTState, error) {
+	return c.backend.BFT(ctx, path)
+}
+
+// GetBlock fetches the block at revision. Named revisions such as justified
+// keep answering the same block for many polls, so they are requested
+// conditionally and a 304 Not Modified answer reuses the cached block. Block
+// numbers and IDs are requested unconditionally.
+func (b *HTTPBackend) GetBlock(ctx context.Context, revision string) (JSONBlockSummary, error) {
+	if _, err := strconv.ParseUint(revision, 10, 32); err == nil || strings.HasPrefix(revision, "0x") {
+		block, _, _, err := b.getBlock(ctx, revision, nil)
+		return block, err
+	}
+
+	b.mu.Lock()
+	cached, ok := b.cache[revision]
+	b.mu.Unlock()
+
+	header := make(http.Header)
+	if ok && cached.etag != "" {
+		header.Set("If-None-Match", cached.etag)
+	}
+	if ok && cached.lastModified != "" {
+		header.Set("If-Modified-Since", cached.lastModified)
+	}
+
+	block, resHeader, notModified, err := b.getBlock(ctx, revision, header)
+	if err != nil {
+		return JSONBlockSummary{}, err
+	}
+	if notModified {
+		if !ok {
+			return JSONBlockSummary{}, fmt.Errorf("status 304 for %s without a cached block", revision)
+		}
+		return cached.block, nil
+	}
+
+	if etag, lastModified := resHeader.Get("ETag"), resHeader.Get("Last-Modified"); etag != "" || lastModified != "" {
+		b.mu.Lock()
+		b.cache[revision] = cachedBlock{etag: etag, lastModified: lastModified, block: block}
+		b.mu.Unlock()
+	}
+	return block, nil
+}
+
+// getBlock requests the block at revision, failing with ErrNotFound when the
+// node answers null for a block it does not have.
+func (b *HTTPBackend) getBlock(ctx context.Context, revision string, header http.Header) (JSONBlockSummary, http.Header, bool, error) {
+	var block *JSONBlockSummary
+	resHeader, notModified, err := b.do(ctx, http.MethodGet, "blocks/"+revision, nil, header, &block)
+	if err != nil || notModified {
+		return JSONBlockSummary{}, resHeader, notModified, err
+	}
+	if block == nil {
+		return JSONBlockSummary{}, nil, false, fmt.Errorf("block %s: %w", revision, ErrNotFound)
+	}
+	return *block, resHeader, false, nil
+}
+
+func (b *HTTPBackend) Inspect(ctx context.Context, clauses []Clause) ([]CallResult, error) {
+	body, err := json.Marshal(map[string][]Clause{"clauses": clauses})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []CallResult
+	if _, _, err := b.do(ctx, http.MethodPost, "accounts/*", body, nil, &results); err != nil {
+		return nil, err
+	}
+	if len(results) != len(clauses) {
+		return nil, fmt.Errorf("got %d results for %d clauses", len(results), len(clauses))
+	}
+	return results, nil
+}
+
+func (b *HTTPBackend) Peers(ctx context.Context) ([]PeerStats, error) {
+	var peers []PeerStats
+	if _, _, err := b.do(ctx, http.MethodGet, "node/network/peers", nil, nil, &peers); err != nil {
+		return nil, err
+	}
+	return peers, nil
+}
+
+func (b *HTTPBackend) BFT(ctx context.Context, path string) (BFTState, error) {
+	var state BFTState
+	if _, _, err := b.do(ctx, http.MethodGet, strings.TrimPrefix(path, "/"), nil, nil, &state); err != nil {
+		return BFTState{}, err
+	}
+	return state, nil
+}
+
+// do sends a request to path with the extra header and unmarshalls the JSON
+// response into out, decompressed when gzipped. It returns the response header and whether the node
+// answered 304 Not Modified, in which case out is left untouched.
+func (b *HTTPBackend) do(ctx context.Context, method, path string, body []byte, header http.Header, out any) (http.Header, bool, error) {
+	if err := b.allow(); err != nil {
+		return nil, false, err
+	}
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	for key, values := range b.header {
+		req.Header[key] = values
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	// Set explicitly, the transport leaves the body compressed for us to
+	// bound its decompressed size.
+	req.Header.Set("Accept-Encoding", "gzip")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := b.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified {
+		return res.Header, true, nil
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return nil, false, fmt.Errorf("status code 404: %w", ErrNotFound)
+	}
+	if res.StatusCode == http.StatusTooManyRequests {
+		return nil, false, b.limit(res.Header)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, false, &StatusError{Code: res.StatusCode, Status: res.Status}
+	}
+
+	var reader io.Reader = res.Body
+	if res.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(res.Body)
+		if err != nil {
+			return nil, false, fmt.Errorf("error decompressing response body: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	responseBody, err := io.ReadAll(io.LimitReader(reader, b.maxBody+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading response body: %w", err)
+	}
+	if int64(len(responseBody)) > b.maxBody {
+		return nil, false, fmt.Errorf("response body larger than %d bytes", b.maxBody)
+	}
+
+	if err = json.Unmarshal(responseBody, out); err != nil {
+		return nil, false, fmt.Errorf("unable to unmarshall events - %w", err)
+	}
+
+	return res.Header, false, nil
+}
+
+func (c *Client) GetBlockByNumber(ctx context.Context, number uint32) (JSONBlockSummary, error) {
+	return c.GetBlock(ctx, strconv.FormatUint(uint64(number), 10))
+}
+
+// maxBlockConcurrency bounds the concurrent requests of GetBlocksConcurrently.
+const maxBlockConcurrency = 8
+
+// GetBlocksConcurrently fetches the blocks at numbers with a request each, up
+// to maxBlockConcurrency at a time, keeping their order. The requests are
+// answered at slightly different times: the blocks may straddle a new best,
+// justified or finalized block. GetBlockRange returns a consistent range.
+func (c *Client) GetBlocksConcurrently(ctx context.Context, numbers []uint32) ([]JSONBlockSummary, error) {
+	blocks := make([]JSONBlockSummary, len(numbers))
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxBlockConcurrency)
+	for i, number := range numbers {
+		g.Go(func() error {
+			block, err := c.GetBlockByNumber(ctx, number)
+			if err != nil {
+				return fmt.Errorf("error getting block %d: %w", number, err)
+			}
+			blocks[i] = block
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// GetBlockRange fetches the blocks from from to to included as a single
+// chain, anchored at the block at to. The blocks are fetched concurrently by
+// number, and those a reorg replaced meanwhile, not linked to the block
+// after them, are fetched again by the parent ID of that block.
+func (c *Client) GetBlockRange(ctx context.Context, from, to uint32) ([]JSONBlockSummary, error) {
+	if from > to {
+		return nil, nil
+	}
+	numbers := make([]uint32, 0, to-from+1)
+	for n := from; ; n++ {
+		numbers = append(numbers, n)
+		if n == to {
+			break
+		}
+	}
+	blocks, err := c.GetBlocksConcurrently(ctx, numbers)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(blocks) - 2; i >= 0; i-- {
+		if parentID := blocks[i+1].ParentID; blocks[i].ID != parentID {
+			if blocks[i], err = c.GetBlock(ctx, parentID); err != nil {
+				return nil, fmt.Errorf("error getting block %d %s: %w", numbers[i], parentID, err)
+			}
+		}
+	}
+	return blocks, nil
+}
+
+func (c *Client) GetBestBlock(ctx context.Context) (JSONBlockSummary, error) {
+	return c.GetBlock(ctx, "best")
+}
+
+func (c *Client) GetJustifiedBlock(ctx context.Context) (JSONBlockSummary, error) {
+	return c.GetBlock(ctx, "justified")
+}
+
+func (c *Client) GetFinalizedBlock(ctx context.Context) (JSONBlockSummary, error) {
+	return c.GetBlock(ctx, "finalized")
+}
+
+// GetBlockAfterFinalized fetches the block following the finalized one,
+// failing with ErrNotFound when the node does not have it.
+func (c *Client) GetBlockAfterFinalized(ctx context.Context, finalized uint32) (JSONBlockSummary, error) {
+	return c.GetBlockByNumber(ctx, finalized+1)
+}
@@ -0,0 +1,141 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// testNode serves a chain of blocks at /blocks/{revision}, block n having ID
+// fork-n, and answers with status instead while it is not 200.
+type testNode struct {
+	server   *httptest.Server
+	status   atomic.Int32
+	hang     atomic.Bool   // the requests wait for the caller to cancel them.
+	stale    atomic.Uint32 // the blocks below, fetched by number, are of another fork.
+	requests atomic.Int32
+	best     uint32
+	fork     string
+}
+
+func newTestNode(t *testing.T, best uint32, fork string) *testNode {
+	n := &testNode{best: best, fork: fork}
+	n.status.Store(http.StatusOK)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /blocks/{revision}", n.serveBlock)
+	n.server = httptest.NewServer(mux)
+	t.Cleanup(n.server.Close)
+	return n
+}
+
+func (n *testNode) serveBlock(w http.ResponseWriter, r *http.Request) {
+	n.requests.Add(1)
+	if n.hang.Load() {
+		<-r.Context().Done()
+		return
+	}
+	if status := int(n.status.Load()); status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	revision := r.PathValue("revision")
+	number, err := strconv.ParseUint(revision, 10, 32)
+	switch {
+	case revision == "best":
+		number, err = uint64(n.best), nil
+	case strings.HasPrefix(revision, n.fork+"-"):
+		number, err = strconv.ParseUint(strings.TrimPrefix(revision, n.fork+"-"), 10, 32)
+	case err != nil:
+		w.Write([]byte("null"))
+		return
+	}
+	if err != nil || number > uint64(n.best) {
+		w.Write([]byte("null"))
+		return
+	}
+	fork := n.fork
+	if revision == strconv.FormatUint(number, 10) && uint32(number) < n.stale.Load() {
+		fork = "stale"
+	}
+	json.NewEncoder(w).Encode(chainBlock(fork, uint32(number)))
+}
+
+// chainBlock returns block number of the chain of fork.
+func chainBlock(fork string, number uint32) client.JSONBlockSummary {
+	block := client.JSONBlockSummary{Number: number, ID: fmt.Sprintf("%s-%d", fork, number)}
+	if number > 0 {
+		block.ParentID = fmt.Sprintf("%s-%d", fork, number-1)
+	}
+	return block
+}
+
+func (n *testNode) backend() *client.HTTPBackend {
+	return client.NewHTTPBackend(&http.Client{}, n.server.URL, time.Second, client.Auth{}, 0)
+}
+
+func TestGetBlockNotFound(t *testing.T) {
+	node := newTestNode(t, 10, "a")
+	c := client.New("node", node.backend())
+
+	if _, err := c.GetBlockByNumber(context.Background(), 11); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("block after the best one: got error %v, want ErrNotFound", err)
+	}
+	if _, err := c.GetBlock(context.Background(), "b-5"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("block off the chain: got error %v, want ErrNotFound", err)
+	}
+	block, err := c.GetBlock(context.Background(), "a-5")
+	if err != nil || block.Number != 5 {
+		t.Errorf("block by ID: got block %d, error %v, want block 5", block.Number, err)
+	}
+}
+
+func TestGetBlockRange(t *testing.T) {
+	tests := []struct {
+		name  string
+		stale uint32
+	}{
+		{name: "linked"},
+		// the lower blocks were answered before a reorg replaced them.
+		{name: "reorg", stale: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newTestNode(t, 10, "a")
+			node.stale.Store(tt.stale)
+			c := client.New("node", node.backend())
+
+			blocks, err := c.GetBlockRange(context.Background(), 3, 7)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(blocks) != 5 {
+				t.Fatalf("got %d blocks, want 5", len(blocks))
+			}
+			for i, b := range blocks {
+				if want := chainBlock("a", uint32(3+i)); b != want {
+					t.Errorf("block %d is %+v, want %+v", i, b, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetBlockRangeNotFound(t *testing.T) {
+	node := newTestNode(t, 10, "a")
+	c := client.New("node", node.backend())
+
+	if _, err := c.GetBlockRange(context.Background(), 8, 12); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("range after the best block: got error %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// DialerConfig binds the connections to a node to a local address, e.g. on
+// multihomed hosts reaching the node over a management network only.
+type DialerConfig struct {
+	LocalAddr string `json:"localAddr"` // source IP of the connections.
+	Interface string `json:"interface"` // whose first address of the IP version is the source IP, exclusive with LocalAddr.
+	// IPVersion is 4 or 6 to connect over IPv4 or IPv6 only. It defaults to
+	// the version of the source IP, or to both without one.
+	IPVersion int `json:"ipVersion"`
+}
+
+// Load returns a dial function for http.Transport connecting as configured,
+// with the timeouts of base.
+func (c DialerConfig) Load(base net.Dialer) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	if c.IPVersion != 0 && c.IPVersion != 4 && c.IPVersion != 6 {
+		return nil, fmt.Errorf("unsupported IP version %d", c.IPVersion)
+	}
+	if c.LocalAddr != "" && c.Interface != "" {
+		return nil, errors.New("localAddr and interface are exclusive")
+	}
+
+	var local net.IP
+	switch {
+	case c.LocalAddr != "":
+		if local = net.ParseIP(c.LocalAddr); local == nil {
+			return nil, fmt.Errorf("invalid local address %q", c.LocalAddr)
+		}
+		if c.IPVersion != 0 && ipVersion(local) != c.IPVersion {
+			return nil, fmt.Errorf("local address %s is not an IPv%d address", local, c.IPVersion)
+		}
+	case c.Interface != "":
+		var err error
+		if local, err = interfaceAddr(c.Interface, c.IPVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	version := c.IPVersion
+	if local != nil {
+		base.LocalAddr = &net.TCPAddr{IP: local}
+		version = ipVersion(local)
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if version != 0 && network == "tcp" {
+			network = fmt.Sprintf("tcp%d", version)
+		}
+		return base.DialContext(ctx, network, addr)
+	}, nil
+}
+
+// interfaceAddr returns the first global address of the interface called
+// name, of the IP version if not zero, IPv4 first otherwise.
+func interfaceAddr(name string, version int) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("error getting interface %s: %w", name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("error getting addresses of interface %s: %w", name, err)
+	}
+	var v6 net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		// Link-local addresses would need the zone of the interface to be dialed from.
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		switch ipVersion(ipNet.IP) {
+		case 4:
+			if version != 6 {
+				return ipNet.IP, nil
+			}
+		case 6:
+			if version == 6 {
+				return ipNet.IP, nil
+			}
+			if v6 == nil && version == 0 {
+				v6 = ipNet.IP
+			}
+		}
+	}
+	if v6 != nil {
+		return v6, nil
+	}
+	if version != 0 {
+		return nil, fmt.Errorf("interface %s has no IPv%d address", name, version)
+	}
+	return nil, fmt.Errorf("interface %s has no address", name)
+}
+
+func ipVersion(ip net.IP) int {
+	if ip.To4() != nil {
+		return 4
+	}
+	return 6
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// FailoverBackend answers from the first member able to in order of priority,
+// so the primary member serves whenever it answers. With circuit breakers on
+// the members, a failing member is skipped until it is probed again.
+type FailoverBackend struct {
+	name    string // of the node, for the logs.
+	members []QuorumMember
+	log     Logger
+
+	mu      sync.Mutex
+	serving int // member which answered the last request, -1 before the first answer.
+}
+
+func NewFailoverBackend(name string, members []QuorumMember) *FailoverBackend {
+	return &FailoverBackend{name: name, members: members, serving: -1}
+}
+
+// SetLogger makes the backend log when the node fails over to another member.
+func (f *FailoverBackend) SetLogger(log Logger) {
+	f.log = log
+}
+
+func (f *FailoverBackend) GetBlock(ctx context.Context, revision string) (JSONBlockSummary, error) {
+	return failoverCall(ctx, f, func(b Backend) (JSONBlockSummary, error) { return b.GetBlock(ctx, revision) })
+}
+
+func (f *FailoverBackend) Inspect(ctx context.Context, clauses []Clause) ([]CallResult, error) {
+	return failoverCall(ctx, f, func(b Backend) ([]CallResult, error) { return b.Inspect(ctx, clauses) })
+}
+
+func (f *FailoverBackend) Peers(ctx context.Context) ([]PeerStats, error) {
+	return failoverCall(ctx, f, func(b Backend) ([]PeerStats, error) { return b.Peers(ctx) })
+}
+
+func (f *FailoverBackend) BFT(ctx context.Context, path string) (BFTState, error) {
+	return failoverCall(ctx, f, func(b Backend) (BFTState, error) { return b.BFT(ctx, path) })
+}
+
+// failoverCall runs call against every member in turn until one answers,
+// failing with the errors of all of them otherwise.
+func failoverCall[T any](ctx context.Context, f *FailoverBackend, call func(Backend) (T, error)) (T, error) {
+	var errs []error
+	for i, member := range f.members {
+		value, err := call(member.Backend)
+		if err == nil {
+			f.serve(i)
+			return value, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", member.Name, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	var zero T
+	return zero, errors.Join(errs...)
+}
+
+func (f *FailoverBackend) serve(i int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.serving >= 0 && f.serving != i {
+		f.log.Printf("Node %s failed over from %s to %s", f.name, f.members[f.serving].Name, f.members[i].Name)
+	}
+	f.serving = i
+}
+
+// Serving returns the member which answered the last request, empty before
+// the first answer.
+func (f *FailoverBackend) Serving() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.serving < 0 {
+		return ""
+	}
+	return f.members[f.serving].Name
+}
+
+// Serving returns the endpoint which answered the last request, if the node
+// fails over across several endpoints.
+func (c *Client) Serving() string {
+	if f, ok := c.backend.(*FailoverBackend); ok {
+		return f.Serving()
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+func TestFailover(t *testing.T) {
+	primary, secondary := newTestNode(t, 10, "a"), newTestNode(t, 10, "b")
+	c := client.New("node", client.NewFailoverBackend("node", []client.QuorumMember{
+		{Name: "primary", Backend: primary.backend()},
+		{Name: "secondary", Backend: secondary.backend()},
+	}))
+	ctx := context.Background()
+
+	if serving := c.Serving(); serving != "" {
+		t.Errorf("serving %q before the first request", serving)
+	}
+
+	steps := []struct {
+		name              string
+		primary, standby  int
+		want, wantServing string
+	}{
+		{name: "primary", primary: http.StatusOK, standby: http.StatusOK, want: "a-5", wantServing: "primary"},
+		{name: "primary down", primary: http.StatusInternalServerError, standby: http.StatusOK, want: "b-5", wantServing: "secondary"},
+		{name: "primary back", primary: http.StatusOK, standby: http.StatusInternalServerError, want: "a-5", wantServing: "primary"},
+		{name: "all down", primary: http.StatusInternalServerError, standby: http.StatusInternalServerError, wantServing: "primary"},
+	}
+	for _, step := range steps {
+		primary.status.Store(int32(step.primary))
+		secondary.status.Store(int32(step.standby))
+
+		block, err := c.GetBlockByNumber(ctx, 5)
+		if step.want == "" {
+			if err == nil || !strings.Contains(err.Error(), "primary") || !strings.Contains(err.Error(), "secondary") {
+				t.Errorf("%s: got block %s, error %v, want the errors of both members", step.name, block.ID, err)
+			}
+		} else if err != nil || block.ID != step.want {
+			t.Errorf("%s: got block %s, error %v, want %s", step.name, block.ID, err, step.want)
+		}
+		if serving := c.Serving(); serving != step.wantServing {
+			t.Errorf("%s: serving %q, want %q", step.name, serving, step.wantServing)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// RawResponse is the answer of an endpoint to a request as received, status
+// line and headers included, kept for post-mortem analysis.
+type RawResponse struct {
+	Endpoint string
+	Path     string
+	Dump     []byte // nil when the request failed.
+	Err      error
+}
+
+// Dump requests path, relative to the URLs of the endpoints, from every
+// endpoint of the node and returns their raw answers. The requests bypass the
+// caches, rate limits, retries and circuit breakers, to capture what the
+// endpoints serve at the time. The endpoints of a GraphQL gateway are not
+// requested.
+func (c *Client) Dump(ctx context.Context, path string) []RawResponse {
+	backends := httpBackends(c.backend)
+	responses := make([]RawResponse, len(backends))
+	for i, b := range backends {
+		dump, err := b.dump(ctx, path)
+		responses[i] = RawResponse{Endpoint: b.baseURL, Path: path, Dump: dump, Err: err}
+	}
+	return responses
+}
+
+// httpBackends returns the REST endpoints behind b.
+func httpBackends(b Backend) []*HTTPBackend {
+	var members []QuorumMember
+	switch b := b.(type) {
+	case *HTTPBackend:
+		return []*HTTPBackend{b}
+	case *BreakerBackend:
+		return httpBackends(b.backend)
+	case *LimitedBackend:
+		return httpBackends(b.backend)
+	case *RetryBackend:
+		return httpBackends(b.backend)
+	case *QuorumBackend:
+		members = b.members
+	case *FailoverBackend:
+		members = b.members
+	}
+	var backends []*HTTPBackend
+	for _, member := range members {
+		backends = append(backends, httpBackends(member.Backend)...)
+	}
+	return backends
+}
+
+// dump requests path and returns the answer as received, with its body
+// decompressed and truncated to the maximum response size.
+func (b *HTTPBackend) dump(ctx context.Context, path string) ([]byte, error) {
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.baseURL+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range b.header {
+		req.Header[key] = values
+	}
+	res, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s\r\n", res.Proto, res.Status)
+	res.Header.Write(&buf)
+	buf.WriteString("\r\n")
+	if _, err := io.Copy(&buf, io.LimitReader(res.Body, b.maxBody)); err != nil {
+		return buf.Bytes(), fmt.Errorf("error reading response body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
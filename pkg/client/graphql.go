@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The queries sent to a GraphQL gateway. Thor has no GraphQL API upstream,
+// so the gateway is expected to expose this schema, mirroring the REST API:
+//
+//	type Query {
+//	  block(revision: String!): Block # null when the node does not have it.
+//	  peers: [Peer!]!
+//	  call(clauses: [ClauseInput!]!): [CallResult!]!
+//	}
+//
+// with the fields of Block, Peer and CallResult named after the JSON fields
+// of JSONBlockSummary, PeerStats and CallResult.
+const (
+	blockQuery = `query Block($revision: String!) {
+  block(revision: $revision) { number id parentID timestamp signer com isFinalized }
+}`
+	peersQuery = `query Peers {
+  peers { name bestBlockID totalScore peerID netAddr inbound duration }
+}`
+	callQuery = `query Call($clauses: [ClauseInput!]!) {
+  call(clauses: $clauses) { data reverted vmError }
+}`
+)
+
+// GraphQLBackend fetches blocks from a GraphQL gateway fronting a single node.
+type GraphQLBackend struct {
+	http *HTTPBackend
+}
+
+// graphQLRequest is the body of a GraphQL request.
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+// graphQLResponse is the body of a GraphQL answer.
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// NewGraphQLBackend returns the backend posting its queries to endpoint,
+// refusing the responses larger than maxBody bytes as NewHTTPBackend does.
+func NewGraphQLBackend(client *http.Client, endpoint string, timeout time.Duration, auth Auth, maxBody int64) *GraphQLBackend {
+	return &GraphQLBackend{http: newHTTPBackend(client, endpoint, timeout, auth, maxBody)}
+}
+
+func (b *GraphQLBackend) GetBlock(ctx context.Context, revision string) (JSONBlockSummary, error) {
+	var data struct {
+		Block *JSONBlockSummary `json:"block"`
+	}
+	if err := b.query(ctx, blockQuery, map[string]any{"revision": revision}, &data); err != nil {
+		return JSONBlockSummary{}, err
+	}
+	if data.Block == nil {
+		return JSONBlockSummary{}, fmt.Errorf("block %s: %w", revision, ErrNotFound)
+	}
+	return *data.Block, nil
+}
+
+func (b *GraphQLBackend) Inspect(ctx context.Context, clauses []Clause) ([]CallResult, error) {
+	var data struct {
+		Call []CallResult `json:"call"`
+	}
+	if err := b.query(ctx, callQuery, map[string]any{"clauses": clauses}, &data); err != nil {
+		return nil, err
+	}
+	if len(data.Call) != len(clauses) {
+		return nil, fmt.Errorf("got %d results for %d clauses", len(data.Call), len(clauses))
+	}
+	return data.Call, nil
+}
+
+func (b *GraphQLBackend) Peers(ctx context.Context) ([]PeerStats, error) {
+	var data struct {
+		Peers []PeerStats `json:"peers"`
+	}
+	if err := b.query(ctx, peersQuery, nil, &data); err != nil {
+		return nil, err
+	}
+	return data.Peers, nil
+}
+
+// BFT is unsupported, the debug endpoint being served next to the node
+// rather than through the gateway.
+func (b *GraphQLBackend) BFT(ctx context.Context, path string) (BFTState, error) {
+	return BFTState{}, errors.New("the BFT state is not available over GraphQL")
+}
+
+// RateLimited returns until when the gateway refuses the requests, or the
+// zero time when it does not.
+func (b *GraphQLBackend) RateLimited() time.Time {
+	return b.http.RateLimited()
+}
+
+// query posts query with variables and unmarshalls the data of the answer
+// into out. The errors of the answer are returned joined.
+func (b *GraphQLBackend) query(ctx context.Context, query string, variables map[string]any, out any) error {
+	body, err := json.Marshal(graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+	var res graphQLResponse
+	if _, _, err := b.http.do(ctx, http.MethodPost, "", body, nil, &res); err != nil {
+		return err
+	}
+	if len(res.Errors) > 0 {
+		messages := make([]string, len(res.Errors))
+		for i, e := range res.Errors {
+			messages[i] = e.Message
+		}
+		return fmt.Errorf("graphql errors: %s", strings.Join(messages, "; "))
+	}
+	if err := json.Unmarshal(res.Data, out); err != nil {
+		return fmt.Errorf("error decoding graphql data: %w", err)
+	}
+	return nil
+}
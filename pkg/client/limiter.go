@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrThrottled is returned for the requests which gave up waiting for the limiter.
+var ErrThrottled = errors.New("throttled by the request rate limit")
+
+// Limiter is a token bucket bounding the rate of the requests sent through
+// the backends sharing it.
+type Limiter struct {
+	rate  float64 // tokens added per second.
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64 // negative when requests wait for tokens to come.
+	last   time.Time
+}
+
+// NewLimiter returns a limiter allowing rate requests per second, and bursts
+// of up to burst requests, at least one.
+func NewLimiter(rate float64, burst int) *Limiter {
+	burst = max(burst, 1)
+	return &Limiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait blocks until a request may be sent, or fails with ErrThrottled when
+// ctx is done first.
+func (l *Limiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// The request is not sent, so its token goes to the next one.
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return fmt.Errorf("%w: %w", ErrThrottled, context.Cause(ctx))
+	}
+}
+
+// LimitedBackend waits for a limiter before every request to its backend.
+type LimitedBackend struct {
+	backend Backend
+	limiter *Limiter
+}
+
+func NewLimitedBackend(b Backend, limiter *Limiter) *LimitedBackend {
+	return &LimitedBackend{backend: b, limiter: limiter}
+}
+
+func (b *LimitedBackend) GetBlock(ctx context.Context, revision string) (JSONBlockSummary, error) {
+	if err := b.limiter.Wait(ctx); err != nil {
+		return JSONBlockSummary{}, err
+	}
+	return b.backend.GetBlock(ctx, revision)
+}
+
+func (b *LimitedBackend) Inspect(ctx context.Context, clauses []Clause) ([]CallResult, error) {
+	if err := b.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return b.backend.Inspect(ctx, clauses)
+}
+
+func (b *LimitedBackend) Peers(ctx context.Context) ([]PeerStats, error) {
+	if err := b.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return b.backend.Peers(ctx)
+}
+
+func (b *LimitedBackend) BFT(ctx context.Context, path string) (BFTState, error) {
+	if err := b.limiter.Wait(ctx); err != nil {
+		return BFTState{}, err
+	}
+	return b.backend.BFT(ctx, path)
+}
@@ -0,0 +1,44 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+func TestLimiter(t *testing.T) {
+	node := newTestNode(t, 10, "a")
+	limiter := client.NewLimiter(10, 2)
+	c := client.New("node", client.NewLimitedBackend(node.backend(), limiter))
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetBestBlock(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed >= 50*time.Millisecond {
+		t.Errorf("burst of 2 requests took %s", elapsed)
+	}
+
+	// the next token comes in 100ms.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetBestBlock(ctx); !errors.Is(err, client.ErrThrottled) {
+		t.Errorf("request past the burst: got error %v, want ErrThrottled", err)
+	}
+	if got := node.requests.Load(); got != 2 {
+		t.Errorf("throttled request reached the node: got %d requests, want 2", got)
+	}
+
+	start = time.Now()
+	if _, err := c.GetBestBlock(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("request past the burst took %s, want it delayed", elapsed)
+	}
+}
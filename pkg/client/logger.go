@@ -0,0 +1,13 @@
+package client
+
+// Logger receives the notable events of the backends, such as an endpoint
+// degraded or recovered, formatted as by fmt.Printf without a trailing
+// newline. The events are discarded unless a logger is set with SetLogger.
+type Logger func(format string, args ...any)
+
+// Printf logs the event with l, unless l is nil.
+func (l Logger) Printf(format string, args ...any) {
+	if l != nil {
+		l(format, args...)
+	}
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// QuorumMember is an endpoint of a node polled in quorum or failover mode.
+type QuorumMember struct {
+	Name    string
+	Backend Backend
+}
+
+// QuorumBackend queries every member and answers with the result given by
+// the majority of them, recording the members that disagreed.
+type QuorumBackend struct {
+	members []QuorumMember
+
+	mu       sync.Mutex
+	outliers []string
+}
+
+func NewQuorumBackend(members []QuorumMember) *QuorumBackend {
+	return &QuorumBackend{members: members}
+}
+
+func (q *QuorumBackend) GetBlock(ctx context.Context, revision string) (JSONBlockSummary, error) {
+	return quorumCall(q, revision,
+		func(b Backend) (JSONBlockSummary, error) { return b.GetBlock(ctx, revision) },
+		func(block JSONBlockSummary) string { return fmt.Sprintf("block %d %s", block.Number, block.ID) },
+	)
+}
+
+func (q *QuorumBackend) Inspect(ctx context.Context, clauses []Clause) ([]CallResult, error) {
+	return quorumCall(q, "contract call",
+		func(b Backend) ([]CallResult, error) { return b.Inspect(ctx, clauses) },
+		func(results []CallResult) string {
+			data, _ := json.Marshal(results)
+			return string(data)
+		},
+	)
+}
+
+func (q *QuorumBackend) BFT(ctx context.Context, path string) (BFTState, error) {
+	return quorumCall(q, "bft state",
+		func(b Backend) (BFTState, error) { return b.BFT(ctx, path) },
+		func(state BFTState) string { return fmt.Sprintf("%+v", state) },
+	)
+}
+
+// Peers answers with the peers of the member connected to the fewest, the
+// most isolated one, since the members are expected to have peers of their own.
+func (q *QuorumBackend) Peers(ctx context.Context) ([]PeerStats, error) {
+	peers := make([][]PeerStats, len(q.members))
+	errs := make([]error, len(q.members))
+	var wg sync.WaitGroup
+	for i, member := range q.members {
+		wg.Add(1)
+		go func(i int, member QuorumMember) {
+			defer wg.Done()
+			if peers[i], errs[i] = member.Backend.Peers(ctx); errs[i] != nil {
+				errs[i] = fmt.Errorf("%s: %w", member.Name, errs[i])
+			}
+		}(i, member)
+	}
+	wg.Wait()
+
+	fewest := -1
+	for i := range q.members {
+		if errs[i] == nil && (fewest < 0 || len(peers[i]) < len(peers[fewest])) {
+			fewest = i
+		}
+	}
+	if fewest < 0 {
+		return nil, errors.Join(errs...)
+	}
+	return peers[fewest], nil
+}
+
+// quorumCall runs call against every member concurrently and returns the
+// answer whose key is shared by a strict majority of the members.
+func quorumCall[T any](q *QuorumBackend, what string, call func(Backend) (T, error), key func(T) string) (T, error) {
+	type answer struct {
+		value T
+		key   string
+		err   error
+	}
+	answers := make([]answer, len(q.members))
+
+	var wg sync.WaitGroup
+	for i, member := range q.members {
+		wg.Add(1)
+		go func(i int, member QuorumMember) {
+			defer wg.Done()
+			value, err := call(member.Backend)
+			answers[i] = answer{value: value, err: err}
+			if err == nil {
+				answers[i].key = key(value)
+			}
+		}(i, member)
+	}
+	wg.Wait()
+
+	votes := make(map[string]int)
+	for _, a := range answers {
+		if a.err == nil {
+			votes[a.key]++
+		}
+	}
+
+	majority := -1
+	for i, a := range answers {
+		if a.err == nil && votes[a.key]*2 > len(q.members) {
+			majority = i
+			break
+		}
+	}
+
+	var outliers []string
+	for i, a := range answers {
+		switch {
+		case a.err != nil:
+			outliers = append(outliers, fmt.Sprintf("%s failed to answer %s: %v", q.members[i].Name, what, a.err))
+		case majority >= 0 && a.key != answers[majority].key:
+			outliers = append(outliers, fmt.Sprintf("%s answered %s with %s, majority answered %s", q.members[i].Name, what, a.key, answers[majority].key))
+		}
+	}
+	sort.Strings(outliers)
+
+	q.mu.Lock()
+	q.outliers = append(q.outliers, outliers...)
+	q.mu.Unlock()
+
+	if majority < 0 {
+		var zero T
+		return zero, errors.New("no majority answer for " + what)
+	}
+	return answers[majority].value, nil
+}
+
+// TakeOutliers returns the disagreements recorded since the previous call.
+func (q *QuorumBackend) TakeOutliers() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	outliers := q.outliers
+	q.outliers = nil
+	return outliers
+}
+
+// TakeOutliers returns the quorum disagreements recorded since the previous
+// call, if the node is backed by a quorum of nodes.
+func (c *Client) TakeOutliers() []string {
+	if q, ok := c.backend.(*QuorumBackend); ok {
+		return q.TakeOutliers()
+	}
+	return nil
+}
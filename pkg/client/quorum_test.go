@@ -0,0 +1,61 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+func TestQuorum(t *testing.T) {
+	tests := []struct {
+		name     string
+		forks    []string
+		failing  int // member answering 500, -1 for none.
+		want     string
+		outliers []string // prefixes of the disagreements.
+	}{
+		{name: "unanimous", forks: []string{"a", "a", "a"}, failing: -1, want: "a-5"},
+		{name: "majority", forks: []string{"a", "b", "a"}, failing: -1, want: "a-5", outliers: []string{"m1 answered 5 with block 5 b-5"}},
+		{name: "failed member", forks: []string{"a", "a", "a"}, failing: 0, want: "a-5", outliers: []string{"m0 failed to answer"}},
+		{name: "no majority", forks: []string{"a", "b", "c"}, failing: -1},
+		{name: "no majority of the members", forks: []string{"a", "a", "b"}, failing: 1, outliers: []string{"m1 failed to answer"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var members []client.QuorumMember
+			for i, fork := range tt.forks {
+				node := newTestNode(t, 10, fork)
+				if i == tt.failing {
+					node.status.Store(http.StatusInternalServerError)
+				}
+				members = append(members, client.QuorumMember{Name: fmt.Sprintf("m%d", i), Backend: node.backend()})
+			}
+			c := client.New("node", client.NewQuorumBackend(members))
+
+			block, err := c.GetBlockByNumber(context.Background(), 5)
+			switch {
+			case tt.want == "" && (err == nil || !strings.Contains(err.Error(), "no majority")):
+				t.Errorf("got block %s, error %v, want no majority", block.ID, err)
+			case tt.want != "" && (err != nil || block.ID != tt.want):
+				t.Errorf("got block %s, error %v, want %s", block.ID, err, tt.want)
+			}
+
+			outliers := c.TakeOutliers()
+			if len(outliers) != len(tt.outliers) {
+				t.Fatalf("got outliers %q, want %q", outliers, tt.outliers)
+			}
+			for i, prefix := range tt.outliers {
+				if !strings.HasPrefix(outliers[i], prefix) {
+					t.Errorf("got outlier %q, want %q", outliers[i], prefix)
+				}
+			}
+			if outliers := c.TakeOutliers(); len(outliers) != 0 {
+				t.Errorf("outliers %q taken twice", outliers)
+			}
+		})
+	}
+}
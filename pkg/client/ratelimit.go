@@ -0,0 +1,100 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrRateLimited is returned for the requests answered 429 Too Many Requests,
+// and for those refused until the node allows requests again.
+var ErrRateLimited = errors.New("rate limited")
+
+// defaultRetryAfter is how long a node answering 429 is left alone when it
+// does not say how long with Retry-After.
+const defaultRetryAfter = 30 * time.Second
+
+// retryAfter parses the Retry-After header of a response received at now,
+// either in seconds or as an HTTP date.
+func retryAfter(header http.Header, now time.Time) time.Time {
+	value := header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return now.Add(time.Duration(seconds) * time.Second)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t
+	}
+	return now.Add(defaultRetryAfter)
+}
+
+// limit refuses the requests until the time the node asked to retry after.
+func (b *HTTPBackend) limit(header http.Header) error {
+	until := retryAfter(header, time.Now())
+	b.mu.Lock()
+	limited := b.limitedUntil.After(time.Now())
+	b.limitedUntil = until
+	b.mu.Unlock()
+	if !limited {
+		b.log.Printf("Endpoint %s rate limited, retrying after %s", b.baseURL, until.Format(time.RFC3339))
+	}
+	return fmt.Errorf("status code 429: %w", ErrRateLimited)
+}
+
+// allow fails with ErrRateLimited until the node allows requests again.
+func (b *HTTPBackend) allow() error {
+	if until := b.RateLimited(); !until.IsZero() {
+		return fmt.Errorf("%w until %s", ErrRateLimited, until.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// RateLimited returns until when the node refuses the requests, or the zero
+// time when it does not.
+func (b *HTTPBackend) RateLimited() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limitedUntil.After(time.Now()) {
+		return b.limitedUntil
+	}
+	return time.Time{}
+}
+
+// RateLimited returns until when the node refuses the requests, or the zero
+// time when it does not. A node backed by several endpoints is rate limited
+// until the first of them allows requests again.
+func (c *Client) RateLimited() time.Time {
+	return rateLimited(c.backend)
+}
+
+func rateLimited(b Backend) time.Time {
+	var members []QuorumMember
+	switch b := b.(type) {
+	case *HTTPBackend:
+		return b.RateLimited()
+	case *GraphQLBackend:
+		return b.RateLimited()
+	case *BreakerBackend:
+		return rateLimited(b.backend)
+	case *LimitedBackend:
+		return rateLimited(b.backend)
+	case *RetryBackend:
+		return rateLimited(b.backend)
+	case *QuorumBackend:
+		members = b.members
+	case *FailoverBackend:
+		members = b.members
+	}
+	var until time.Time
+	for i, member := range members {
+		t := rateLimited(member.Backend)
+		if t.IsZero() {
+			return time.Time{}
+		}
+		if i == 0 || t.Before(until) {
+			until = t
+		}
+	}
+	return until
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// StatusError is returned for the responses with an unexpected status code.
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status code not 200: %s", e.Status)
+}
+
+// RetryBackend retries the requests to an endpoint which failed with a
+// network error or a 5xx status, backing off exponentially. Client errors
+// such as a 4xx status or an unknown block are returned at once.
+type RetryBackend struct {
+	backend  Backend
+	attempts int           // retries after the first attempt.
+	backoff  time.Duration // before the first retry, doubled for every next one.
+}
+
+func NewRetryBackend(b Backend, attempts int, backoff time.Duration) *RetryBackend {
+	return &RetryBackend{backend: b, attempts: attempts, backoff: backoff}
+}
+
+func (b *RetryBackend) GetBlock(ctx context.Context, revision string) (JSONBlockSummary, error) {
+	return retry(ctx, b, func() (JSONBlockSummary, error) { return b.backend.GetBlock(ctx, revision) })
+}
+
+func (b *RetryBackend) Inspect(ctx context.Context, clauses []Clause) ([]CallResult, error) {
+	return retry(ctx, b, func() ([]CallResult, error) { return b.backend.Inspect(ctx, clauses) })
+}
+
+func (b *RetryBackend) Peers(ctx context.Context) ([]PeerStats, error) {
+	return retry(ctx, b, func() ([]PeerStats, error) { return b.backend.Peers(ctx) })
+}
+
+func (b *RetryBackend) BFT(ctx context.Context, path string) (BFTState, error) {
+	return retry(ctx, b, func() (BFTState, error) { return b.backend.BFT(ctx, path) })
+}
+
+// retry calls request until it succeeds, fails with an error not worth
+// retrying, or the retries of b are exhausted.
+func retry[T any](ctx context.Context, b *RetryBackend, request func() (T, error)) (T, error) {
+	backoff := b.backoff
+	for attempt := 0; ; attempt++ {
+		v, err := request()
+		if err == nil || attempt == b.attempts || ctx.Err() != nil || !retryable(err) {
+			return v, err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return v, err
+		}
+		backoff *= 2
+	}
+}
+
+// retryable reports whether err is a transient failure of the endpoint: a
+// network error or a 5xx status.
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code >= 500
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
@@ -0,0 +1,60 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// countingBackend counts the blocks requested from its backend.
+type countingBackend struct {
+	client.Backend
+	requests atomic.Int32
+}
+
+func (b *countingBackend) GetBlock(ctx context.Context, revision string) (client.JSONBlockSummary, error) {
+	b.requests.Add(1)
+	return b.Backend.GetBlock(ctx, revision)
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		revision string
+		down     bool // the node is not listening.
+		fails    bool
+		requests int32
+	}{
+		{name: "success", status: http.StatusOK, revision: "5", requests: 1},
+		{name: "server error", status: http.StatusInternalServerError, revision: "5", fails: true, requests: 3},
+		{name: "unavailable", status: http.StatusServiceUnavailable, revision: "5", fails: true, requests: 3},
+		{name: "network error", status: http.StatusOK, revision: "5", down: true, fails: true, requests: 3},
+		{name: "client error", status: http.StatusBadRequest, revision: "5", fails: true, requests: 1},
+		{name: "not found status", status: http.StatusNotFound, revision: "5", fails: true, requests: 1},
+		{name: "unknown block", status: http.StatusOK, revision: "b-5", fails: true, requests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newTestNode(t, 10, "a")
+			node.status.Store(int32(tt.status))
+			if tt.down {
+				node.server.Close()
+			}
+			backend := &countingBackend{Backend: node.backend()}
+			c := client.New("node", client.NewRetryBackend(backend, 2, time.Millisecond))
+
+			_, err := c.GetBlock(context.Background(), tt.revision)
+			if (err != nil) != tt.fails {
+				t.Errorf("got error %v, want failure %t", err, tt.fails)
+			}
+			if got := backend.requests.Load(); got != tt.requests {
+				t.Errorf("got %d requests, want %d", got, tt.requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+// UnixBaseURL is the base URL to request a node behind a unix socket with,
+// over a transport dialing the socket with DialUnix. Its host only fills the
+// Host header.
+const UnixBaseURL = "http://unix/"
+
+// UnixSocket returns the path of the socket a unix:///path/to/thor.sock node
+// URL points to, reporting whether the URL is a unix socket URL.
+func UnixSocket(rawURL string) (string, bool) {
+	socket, ok := strings.CutPrefix(rawURL, "unix://")
+	return socket, ok && socket != ""
+}
+
+// DialUnix returns a dial function for http.Transport connecting to socket
+// whatever the address requested.
+func DialUnix(socket string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", socket)
+	}
+}
@@ -0,0 +1,89 @@
+package mocknode
+
+import (
+	"fmt"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// Chain is the state of the blocks served by a mock node. Scenarios advance
+// it once per step.
+type Chain struct {
+	BlockInterval      uint64 // seconds between the timestamps of two consecutive blocks.
+	CheckpointInterval uint32 // blocks between two bft checkpoints.
+	Proposers          int    // signers taking turns to produce the blocks.
+	Peers              int    // nodes the mock node is connected to.
+	GenesisTimestamp   uint64 // of block 0, the following ones produced every BlockInterval.
+
+	Best      uint32
+	Justified uint32
+	Finalized uint32
+
+	// forks are the heights the chain was re-written from by Revert, oldest first.
+	forks []uint32
+}
+
+// Produce appends a block and advances the justified and finalized blocks: a
+// checkpoint is justified once the round after it completed and finalized
+// once the next checkpoint is justified.
+func (c *Chain) Produce() {
+	c.Best++
+	interval := c.CheckpointInterval
+	if c.Best+1 < 2*interval {
+		return
+	}
+	justified := (c.Best + 1 - interval) / interval * interval
+	if justified > c.Justified {
+		c.Justified = justified
+		c.Finalized = justified - interval
+	}
+}
+
+// Revert replaces the finalized block and every block after it with a fork
+// and moves the justified and finalized blocks back by a checkpoint, as after
+// a finality violation.
+func (c *Chain) Revert() {
+	c.forks = append(c.forks, c.Finalized)
+	if c.Finalized >= c.CheckpointInterval {
+		c.Finalized -= c.CheckpointInterval
+	}
+	if c.Justified >= c.CheckpointInterval {
+		c.Justified -= c.CheckpointInterval
+	}
+}
+
+// fork returns the number of the fork block n belongs to, 0 for the original chain.
+func (c *Chain) fork(n uint32) int {
+	for i := len(c.forks) - 1; i >= 0; i-- {
+		if c.forks[i] <= n {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func (c *Chain) blockID(n uint32) string {
+	return fmt.Sprintf("0x%08x%056x", n, c.fork(n))
+}
+
+// Block returns block n of the chain, which must not be after the best block.
+func (c *Chain) Block(n uint32) client.JSONBlockSummary {
+	parentID := "0x" + fmt.Sprintf("%064x", 0)
+	if n > 0 {
+		parentID = c.blockID(n - 1)
+	}
+	return client.JSONBlockSummary{
+		Number:      n,
+		ID:          c.blockID(n),
+		ParentID:    parentID,
+		Timestamp:   c.GenesisTimestamp + uint64(n)*c.BlockInterval,
+		Signer:      proposer(int(n) % c.Proposers),
+		COM:         true,
+		IsFinalized: n <= c.Finalized,
+	}
+}
+
+// proposer returns the address of the i-th proposer.
+func proposer(i int) string {
+	return fmt.Sprintf("0x%040x", i+1)
+}
@@ -0,0 +1,10 @@
+package mocknode
+
+import "time"
+
+// Fault is a misbehaviour of a mock node injected with Node.Inject.
+type Fault struct {
+	Delay  time.Duration // added before every answer.
+	Status int           // answered instead of the result when not 0, e.g. 503.
+	Stale  bool          // answer with the chain as it was when the fault was injected.
+}
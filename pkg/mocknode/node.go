@@ -0,0 +1,350 @@
+// Package mocknode serves fake VeChain Thor nodes over HTTP, replaying
+// scriptable scenarios, to test the monitor without a real network.
+package mocknode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// Options configure the chain of a mock node. Zero fields take the defaults.
+type Options struct {
+	Best               uint32 // best block before the first step.
+	BlockInterval      uint64
+	CheckpointInterval uint32
+	Proposers          int
+	Peers              int
+}
+
+// Node is a mock node listening on a local address. Its chain only advances
+// on Step, or periodically while Run is running.
+type Node struct {
+	server   *httptest.Server
+	scenario Scenario
+
+	mu    sync.Mutex
+	step  int
+	chain Chain
+	fault Fault
+	stale *Chain // the chain answered while a stale fault is injected.
+}
+
+// New starts a mock node replaying scenario. The node must be closed after use.
+func New(scenario Scenario, opts Options) *Node {
+	n := &Node{
+		scenario: scenario,
+		chain: Chain{
+			BlockInterval:      10,
+			CheckpointInterval: 180,
+			Proposers:          4,
+			Peers:              3,
+		},
+	}
+	if opts.BlockInterval != 0 {
+		n.chain.BlockInterval = opts.BlockInterval
+	}
+	if opts.CheckpointInterval != 0 {
+		n.chain.CheckpointInterval = opts.CheckpointInterval
+	}
+	if opts.Proposers != 0 {
+		n.chain.Proposers = opts.Proposers
+	}
+	if opts.Peers != 0 {
+		n.chain.Peers = opts.Peers
+	}
+	// The best block before the first step is as recent as on a synced node.
+	n.chain.GenesisTimestamp = uint64(time.Now().Unix()) - uint64(opts.Best)*n.chain.BlockInterval
+	for n.chain.Best < opts.Best {
+		n.chain.Produce()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /blocks/{revision}", n.serveBlock)
+	mux.HandleFunc("POST /accounts/*", n.serveCall)
+	mux.HandleFunc("GET /node/network/peers", n.servePeers)
+	mux.HandleFunc("GET /debug/bft", n.serveBFT)
+	n.server = httptest.NewServer(mux)
+	return n
+}
+
+// URL returns the base URL of the node, to be used as a node URL in the config.
+func (n *Node) URL() string {
+	return n.server.URL
+}
+
+// Close stops the node.
+func (n *Node) Close() {
+	n.server.Close()
+}
+
+// Step advances the chain by a step of the scenario.
+func (n *Node) Step() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.scenario(n.step, &n.chain)
+	n.step++
+}
+
+// Run steps the scenario every interval until ctx is done.
+func (n *Node) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n.Step()
+		}
+	}
+}
+
+// Chain returns a copy of the current state of the chain.
+func (n *Node) Chain() Chain {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.chain
+}
+
+// Inject makes the node answer with fault until another one is injected.
+// Injecting the zero Fault restores the normal answers.
+func (n *Node) Inject(fault Fault) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.fault = fault
+	n.stale = nil
+	if fault.Stale {
+		stale := n.chain
+		stale.forks = slices.Clone(n.chain.forks)
+		n.stale = &stale
+	}
+}
+
+// Revert reverts the finalized block of the chain, see Chain.Revert.
+func (n *Node) Revert() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.chain.Revert()
+}
+
+// misbehave applies the injected fault to the request, reporting whether the
+// request was answered by the fault.
+func (n *Node) misbehave(w http.ResponseWriter, r *http.Request) bool {
+	n.mu.Lock()
+	fault := n.fault
+	n.mu.Unlock()
+
+	if fault.Delay > 0 {
+		select {
+		case <-time.After(fault.Delay):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+	if fault.Status != 0 {
+		http.Error(w, http.StatusText(fault.Status), fault.Status)
+		return true
+	}
+	return false
+}
+
+// serveBlock answers /blocks/{revision} like a Thor node: a block after the
+// best one, or an ID off the chain, is null. Named revisions carry an ETag so
+// conditional requests are answered with 304 Not Modified while the block
+// does not change.
+func (n *Node) serveBlock(w http.ResponseWriter, r *http.Request) {
+	if n.misbehave(w, r) {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	chain := &n.chain
+	if n.stale != nil {
+		chain = n.stale
+	}
+
+	revision := r.PathValue("revision")
+	var number uint32
+	switch revision {
+	case "best":
+		number = chain.Best
+	case "justified":
+		number = chain.Justified
+	case "finalized":
+		number = chain.Finalized
+	default:
+		if strings.HasPrefix(revision, "0x") {
+			// the IDs are prefixed with the number of the block.
+			parsed, err := strconv.ParseUint(revision[2:min(len(revision), 10)], 16, 32)
+			if err != nil || uint32(parsed) > chain.Best || chain.blockID(uint32(parsed)) != revision {
+				writeJSON(w, nil)
+				return
+			}
+			writeJSON(w, chain.Block(uint32(parsed)))
+			return
+		}
+		parsed, err := strconv.ParseUint(revision, 10, 32)
+		if err != nil {
+			http.Error(w, "revision: invalid format", http.StatusBadRequest)
+			return
+		}
+		if uint32(parsed) > chain.Best {
+			writeJSON(w, nil)
+			return
+		}
+		writeJSON(w, chain.Block(uint32(parsed)))
+		return
+	}
+
+	block := chain.Block(number)
+	etag := strconv.Quote(block.ID)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	writeJSON(w, block)
+}
+
+const (
+	selectorFirst = "0x3df4ddf4" // first()
+	selectorNext  = "0xab73e316" // next(address)
+	selectorGet   = "0xc2bc2efc" // get(address)
+
+	selectorParamsGet = "0x8eaa6ac0" // get(bytes32)
+)
+
+// keyMaxBlockProposers is the params key of the max block proposers.
+var keyMaxBlockProposers = fmt.Sprintf("%064x", "max-block-proposers")
+
+// serveCall answers the calls to the authority contract listing the
+// proposers, every one of them active, and to the params contract for the
+// max block proposers, the number of proposers. Any other call reverts.
+func (n *Node) serveCall(w http.ResponseWriter, r *http.Request) {
+	if n.misbehave(w, r) {
+		return
+	}
+	var req struct {
+		Clauses []client.Clause `json:"clauses"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n.mu.Lock()
+	proposers := n.chain.Proposers
+	n.mu.Unlock()
+
+	results := make([]client.CallResult, 0, len(req.Clauses))
+	for _, clause := range req.Clauses {
+		data := strings.ToLower(clause.Data)
+		switch {
+		case data == selectorParamsGet+keyMaxBlockProposers:
+			results = append(results, client.CallResult{Data: "0x" + word(strconv.FormatInt(int64(proposers), 16))})
+		case data == selectorFirst:
+			results = append(results, client.CallResult{Data: "0x" + word(proposer(0))})
+		case strings.HasPrefix(data, selectorNext):
+			next := "0x" + strings.Repeat("0", 40)
+			if i := proposerIndex(data[len(selectorNext):]); i >= 0 && i+1 < proposers {
+				next = proposer(i + 1)
+			}
+			results = append(results, client.CallResult{Data: "0x" + word(next)})
+		case strings.HasPrefix(data, selectorGet):
+			// (bool listed, address endorsor, bytes32 identity, bool active)
+			active := "0x0"
+			if i := proposerIndex(data[len(selectorGet):]); i >= 0 && i < proposers {
+				active = "0x1"
+			}
+			results = append(results, client.CallResult{Data: "0x" + word(active) + word("0x0") + word("0x0") + word(active)})
+		default:
+			results = append(results, client.CallResult{Reverted: true, VMError: "execution reverted"})
+		}
+	}
+	writeJSON(w, results)
+}
+
+// word left-pads the hex value to a 32 bytes ABI word.
+func word(hex string) string {
+	hex = strings.TrimPrefix(hex, "0x")
+	return strings.Repeat("0", 64-len(hex)) + hex
+}
+
+// proposerIndex returns the index of the proposer whose address is encoded in
+// the ABI word, or -1.
+func proposerIndex(encoded string) int {
+	i, err := strconv.ParseUint(strings.TrimLeft(encoded, "0"), 16, 32)
+	if err != nil || i == 0 {
+		return -1
+	}
+	return int(i) - 1
+}
+
+// servePeers answers /node/network/peers with peers at the best block.
+func (n *Node) servePeers(w http.ResponseWriter, r *http.Request) {
+	if n.misbehave(w, r) {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	chain := &n.chain
+	if n.stale != nil {
+		chain = n.stale
+	}
+
+	peers := make([]client.PeerStats, 0, chain.Peers)
+	for i := range chain.Peers {
+		peers = append(peers, client.PeerStats{
+			Name:        "thor/mock",
+			BestBlockID: chain.blockID(chain.Best),
+			TotalScore:  uint64(chain.Best),
+			PeerID:      fmt.Sprintf("%0128x", i+1),
+			NetAddr:     fmt.Sprintf("10.0.0.%d:11235", i+1),
+			Duration:    uint64(chain.Best) * chain.BlockInterval,
+		})
+	}
+	writeJSON(w, peers)
+}
+
+// serveBFT answers /debug/bft with the consensus state at the best block,
+// every proposer voting.
+func (n *Node) serveBFT(w http.ResponseWriter, r *http.Request) {
+	if n.misbehave(w, r) {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	chain := &n.chain
+	if n.stale != nil {
+		chain = n.stale
+	}
+
+	round := chain.Best / chain.CheckpointInterval
+	state := client.BFTState{
+		Round:   round,
+		Votes:   chain.Proposers,
+		Quorum:  chain.Proposers*2/3 + 1,
+		Quality: chain.Justified / chain.CheckpointInterval,
+	}
+	if round > 0 {
+		state.Checkpoint = (round - 1) * chain.CheckpointInterval
+	}
+	writeJSON(w, state)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
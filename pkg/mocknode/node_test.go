@@ -0,0 +1,105 @@
+package mocknode_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+	"github.com/paologalligit/justified/pkg/client"
+	"github.com/paologalligit/justified/pkg/mocknode"
+)
+
+// checkpointInterval is small for the scenarios to cross several checkpoints.
+const checkpointInterval = 10
+
+func TestScenarios(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario mocknode.Scenario
+		steps    int
+		want     map[string]checks.Severity // checks failing at some step, the others never may.
+	}{
+		{
+			name:     "normal",
+			scenario: mocknode.Normal(),
+			steps:    40,
+			want:     map[string]checks.Severity{},
+		},
+		{
+			name:     "stall",
+			scenario: mocknode.Stall(5),
+			steps:    20,
+			want:     map[string]checks.Severity{"chain-stalled": checks.SeverityWarn},
+		},
+		{
+			name:     "reversion",
+			scenario: mocknode.Reversion(20),
+			steps:    25,
+			want: map[string]checks.Severity{
+				"finality-reversion":   checks.SeverityFatal,
+				"justified-monotonic":  checks.SeverityFatal,
+				"finalized-monotonic":  checks.SeverityFatal,
+				"justified-lag":        checks.SeverityFatal,
+				"finalized-lag":        checks.SeverityFatal,
+				"best-reorg":           checks.SeverityWarn,
+				"justified-reorg":      checks.SeverityFatal,
+				"finality-lag-anomaly": checks.SeverityWarn,
+			},
+		},
+		{
+			name:     "finality stall",
+			scenario: mocknode.FinalityStall(5),
+			steps:    40,
+			want: map[string]checks.Severity{
+				"justified-lag": checks.SeverityFatal,
+				"finalized-lag": checks.SeverityFatal,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := mocknode.New(tt.scenario, mocknode.Options{Best: 100, BlockInterval: 1, CheckpointInterval: checkpointInterval})
+			defer node.Close()
+
+			cfg := checks.DefaultConfig()
+			cfg.BlockInterval = 1
+			cfg.Thresholds.CheckpointInterval = checkpointInterval
+			cfg.MaxBlockProposers = 4
+			cfg.SyncIntervals = 0
+			c := client.New("mock", client.NewHTTPBackend(&http.Client{}, node.URL(), time.Second, client.Auth{}, 0))
+			poller := checks.NewPoller(c, cfg)
+			nodeChecks := checks.New(cfg)
+
+			// polled a block interval apart, just after the blocks were produced.
+			chain := node.Chain()
+			start := time.Unix(int64(chain.GenesisTimestamp+uint64(chain.Best)*chain.BlockInterval), 0).Add(500 * time.Millisecond)
+			failed := make(map[string]checks.Severity)
+			for step := 0; step < tt.steps; step++ {
+				r := poller.Poll(context.Background(), start.Add(time.Duration(step)*time.Second))
+				if err := r.Err(); err != nil {
+					t.Fatalf("step %d: poll failed: %v", step, err)
+				}
+				for _, outcome := range checks.Perform(nodeChecks, r) {
+					if _, ok := tt.want[outcome.Name]; !ok {
+						t.Errorf("step %d: unexpected failure of %s: %v", step, outcome.Name, outcome.Err)
+					}
+					failed[outcome.Name] = outcome.Severity
+				}
+				node.Step()
+			}
+
+			for name, severity := range tt.want {
+				got, ok := failed[name]
+				switch {
+				case !ok:
+					t.Errorf("%s never failed", name)
+				case got != severity:
+					t.Errorf("%s failed with severity %s, want %s", name, got, severity)
+				}
+			}
+		})
+	}
+}
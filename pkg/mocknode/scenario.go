@@ -0,0 +1,44 @@
+package mocknode
+
+// Scenario advances the chain of a mock node at each step, step counting from 0.
+type Scenario func(step int, c *Chain)
+
+// Normal produces a block every step.
+func Normal() Scenario {
+	return func(step int, c *Chain) {
+		c.Produce()
+	}
+}
+
+// Stall produces a block every step until step at, after which the node keeps
+// answering with the same best, justified and finalized blocks.
+func Stall(at int) Scenario {
+	return func(step int, c *Chain) {
+		if step < at {
+			c.Produce()
+		}
+	}
+}
+
+// FinalityStall produces a block every step but stops advancing the justified
+// and finalized blocks from step at.
+func FinalityStall(at int) Scenario {
+	return func(step int, c *Chain) {
+		justified, finalized := c.Justified, c.Finalized
+		c.Produce()
+		if step >= at {
+			c.Justified, c.Finalized = justified, finalized
+		}
+	}
+}
+
+// Reversion produces a block every step and reverts the finalized block at step at.
+func Reversion(at int) Scenario {
+	return func(step int, c *Chain) {
+		if step == at {
+			c.Revert()
+			return
+		}
+		c.Produce()
+	}
+}
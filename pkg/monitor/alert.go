@@ -0,0 +1,158 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// defaultAlertCooldown is how often a lasting failure is alerted by default.
+const defaultAlertCooldown = 15 * time.Minute
+
+// AlertsConfig configures how failed checks are turned into alerts.
+type AlertsConfig struct {
+	// Cooldown is how long a check keeps failing on a node before it is
+	// alerted again, defaults to 15m. The failures in between are grouped
+	// into the next alert.
+	Cooldown Duration `json:"cooldown"`
+	// Maintenance are the windows during which the failures are not alerted.
+	// Failures lasting past a window are alerted when it ends.
+	Maintenance []MaintenanceWindow `json:"maintenance"`
+	// Discord posts the alerts to the webhook of a Discord channel.
+	Discord *DiscordConfig `json:"discord"`
+	// Opsgenie creates and closes the alerts in Opsgenie.
+	Opsgenie *OpsgenieConfig `json:"opsgenie"`
+}
+
+// Alert is a check failing on a node, or no longer failing once Resolved.
+type Alert struct {
+	Chain    string `json:",omitempty"`
+	Node     string
+	Check    string
+	Severity checks.Severity
+	Error    string    // of the latest failure.
+	Since    time.Time // first failure of the check, in the poll cycle started then.
+	Failures int       // failed poll cycles since the previous alert of the check.
+	Resolved bool
+
+	// The block heights of the node in the poll cycle alerted.
+	Best      uint32
+	Justified uint32
+	Finalized uint32
+}
+
+func (a Alert) String() string {
+	if a.Resolved {
+		return fmt.Sprintf("Resolved: check %s on %s passes again after failing since %s", a.Check, a.Node, a.Since.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("Check %s failed on %s %d times since %s: %s", a.Check, a.Node, a.Failures, a.Since.Format(time.RFC3339), a.Error)
+}
+
+// Alerter notifies the operators of an alert.
+type Alerter interface {
+	Alert(a Alert) error
+}
+
+type alertKey struct {
+	node, check string
+}
+
+// activeAlert is a check failing on a node.
+type activeAlert struct {
+	alert  Alert
+	sent   time.Time // when the check was alerted last, zero if never.
+	failed int       // since sent.
+}
+
+// alertSink alerts the failed checks, once when a check starts failing on a
+// node, then at most once per cooldown while it keeps failing, and once more
+// when it passes again. Nothing is alerted while the node is in maintenance
+// but the resolution of the checks alerted before.
+type alertSink struct {
+	cooldown    time.Duration
+	maintenance *maintenance
+	alerters    map[string]Alerter
+	needs       map[string]checks.Field // of every check, to tell a passed check from a skipped one.
+	active      map[alertKey]*activeAlert
+}
+
+// newAlertSink returns the sink alerting the failures of the checks of
+// checksCfg, the plugins and the fleet checks included.
+func newAlertSink(cfg AlertsConfig, checksCfg checks.Config, alerters map[string]Alerter, maintenance *maintenance) *alertSink {
+	needs := make(map[string]checks.Field)
+	for _, check := range checks.New(checksCfg) {
+		needs[check.Name] = check.Needs
+	}
+	for _, check := range checks.NewFleetChecks(checksCfg) {
+		needs[check.Name] = checks.FleetNeeds
+	}
+	return &alertSink{
+		cooldown:    firstNonZero(cfg.Cooldown.Duration, defaultAlertCooldown),
+		maintenance: maintenance,
+		alerters:    alerters,
+		needs:       needs,
+		active:      make(map[alertKey]*activeAlert),
+	}
+}
+
+func (s *alertSink) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	var errs []error
+	inMaintenance := s.maintenance.active(r.Node, r.Time)
+	failed := make(map[alertKey]bool, len(outcomes))
+	for _, outcome := range outcomes {
+		key := alertKey{node: outcome.Node, check: outcome.Name}
+		if failed[key] {
+			continue
+		}
+		failed[key] = true
+
+		a, ok := s.active[key]
+		if !ok {
+			a = &activeAlert{alert: Alert{Chain: r.Chain, Node: outcome.Node, Check: outcome.Name, Since: r.Time}}
+			s.active[key] = a
+		}
+		a.alert.Severity = outcome.Severity
+		a.alert.Error = outcome.Err.Error()
+		a.alert.Best, a.alert.Justified, a.alert.Finalized = r.Best, r.Justified, r.Finalized
+		a.failed++
+		if !inMaintenance && (a.sent.IsZero() || r.Time.Sub(a.sent) >= s.cooldown) {
+			a.alert.Failures = a.failed
+			errs = append(errs, s.send(a.alert))
+			a.sent, a.failed = r.Time, 0
+		}
+	}
+
+	// A check skipped for want of its fields is not known to pass.
+	for key, a := range s.active {
+		if key.node != r.Node || failed[key] || !r.Fetched(s.needs[key.check]) {
+			continue
+		}
+		delete(s.active, key)
+		if a.sent.IsZero() {
+			// failed in maintenance only.
+			continue
+		}
+		resolved := a.alert
+		resolved.Failures = a.failed
+		resolved.Resolved = true
+		resolved.Best, resolved.Justified, resolved.Finalized = r.Best, r.Justified, r.Finalized
+		errs = append(errs, s.send(resolved))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *alertSink) send(a Alert) error {
+	var errs []error
+	for name, alerter := range s.alerters {
+		if err := alerter.Alert(a); err != nil {
+			errs = append(errs, fmt.Errorf("error sending alert to %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *alertSink) Close() error {
+	return nil
+}
@@ -0,0 +1,52 @@
+package monitor
+
+import "time"
+
+// ErrorBudget is how many fatal check failures are tolerated before the monitor terminates.
+type ErrorBudget struct {
+	// Consecutive is the number of consecutive poll cycles of a node with a
+	// fatal failure that exhausts the budget.
+	Consecutive int `json:"consecutive"`
+	// Failures is the number of cycles with a fatal failure within Window
+	// that exhausts the budget. Zero disables the windowed budget.
+	Failures int      `json:"failures"`
+	Window   Duration `json:"window"`
+}
+
+// errorBudget tracks the fatal failures of every node against the budget.
+type errorBudget struct {
+	budget      ErrorBudget
+	consecutive map[string]int
+	failures    map[string][]time.Time
+}
+
+func newErrorBudget(budget ErrorBudget) *errorBudget {
+	return &errorBudget{
+		budget:      budget,
+		consecutive: make(map[string]int),
+		failures:    make(map[string][]time.Time),
+	}
+}
+
+// record accounts a poll cycle of node and reports whether the budget is exhausted.
+func (b *errorBudget) record(node string, at time.Time, failed bool) bool {
+	if !failed {
+		b.consecutive[node] = 0
+		return false
+	}
+
+	b.consecutive[node]++
+	if b.budget.Consecutive > 0 && b.consecutive[node] >= b.budget.Consecutive {
+		return true
+	}
+
+	if b.budget.Failures <= 0 {
+		return false
+	}
+	failures := append(b.failures[node], at)
+	for len(failures) > 0 && at.Sub(failures[0]) > b.budget.Window.Duration {
+		failures = failures[1:]
+	}
+	b.failures[node] = failures
+	return len(failures) >= b.budget.Failures
+}
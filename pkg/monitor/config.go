@@ -0,0 +1,423 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// NodeURL is the node monitored by default.
+const NodeURL = "http://localhost:8689/"
+
+// Config holds the monitor settings loaded from the JSON file given with -config.
+type Config struct {
+	// Network selects the preset of a known network filling the settings
+	// left unset, overridden by the -network flag.
+	Network string `json:"network"`
+
+	// NodeURL is the node monitored when Nodes is empty, defaulting to the
+	// one of the network.
+	NodeURL string       `json:"nodeURL"`
+	Nodes   []NodeConfig `json:"nodes"`
+	// DNSDiscovery monitors the nodes a DNS name resolves to on top of Nodes,
+	// which may then be empty.
+	DNSDiscovery *DNSDiscovery `json:"dnsDiscovery"`
+	// KubernetesDiscovery monitors the pods of a label selector on top of
+	// Nodes, exclusive with DNSDiscovery.
+	KubernetesDiscovery *KubernetesDiscovery `json:"kubernetesDiscovery"`
+
+	// Chains are monitored side by side, each one with the settings of the
+	// top level overridden by its own, while the top level sets no nodes.
+	// Process-wide settings such as metricsAddr, sinks and tracing are only
+	// read from the top level.
+	Chains []ChainConfig `json:"chains"`
+	// Chain is the name of the chain of a config resolved from Chains, empty
+	// when a single chain is monitored.
+	Chain string `json:"-"`
+
+	// GenesisID is the genesis block ID of the monitored network. Nodes on
+	// another chain are refused. When empty, every node must be on the chain
+	// of the first one.
+	GenesisID string `json:"genesisID"`
+
+	// Config holds the settings of the checks, set at the top level of the file.
+	checks.Config
+
+	// RequestTimeout bounds every request to a node. CycleTimeout bounds all
+	// the requests of a poll cycle and defaults to the block interval, so a
+	// cycle never overlaps with the next one.
+	RequestTimeout Duration `json:"requestTimeout"`
+	CycleTimeout   Duration `json:"cycleTimeout"`
+
+	// AlignPolls schedules every poll cycle just after the next block is
+	// expected, a block interval after the best block was produced, instead
+	// of every block interval. New blocks are then seen as soon as they are
+	// produced, even when the block times drift.
+	AlignPolls bool `json:"alignPolls"`
+
+	// PollJitter delays the poll cycles of every node by a random offset up
+	// to it, drawn once per node when the monitor starts, so that the many
+	// instances of a fleet started together do not poll the nodes in lockstep.
+	PollJitter Duration `json:"pollJitter"`
+
+	// CircuitBreaker stops polling an endpoint which keeps failing for a cooldown.
+	CircuitBreaker CircuitBreaker `json:"circuitBreaker"`
+
+	// Retry retries the requests failing with a network error or a 5xx
+	// status, within the cycle timeout.
+	Retry RetryConfig `json:"retry"`
+
+	// Transport tunes the HTTP connections to the nodes.
+	Transport TransportConfig `json:"transport"`
+
+	// MaxResponseSize is how many bytes the decompressed body of a response
+	// of a node may hold, defaulting to 8 MiB, against huge or malicious answers.
+	MaxResponseSize int64 `json:"maxResponseSize"`
+
+	// RateLimit bounds the rate of the requests to all the nodes together,
+	// so that a short poll interval or a large fleet cannot overload them.
+	RateLimit RateLimit `json:"rateLimit"`
+
+	// MetricsAddr is the address serving Prometheus metrics at /metrics, the
+	// /healthz and /readyz probes and the /maintenance API, disabled when empty.
+	MetricsAddr string `json:"metricsAddr"`
+	// MaintenanceToken is the bearer token the POST and DELETE requests of
+	// the /maintenance API must carry. Without it, only the requests from
+	// localhost are allowed to start and end maintenance windows.
+	MaintenanceToken string `json:"maintenanceToken"`
+
+	// HealthStaleAfter is how long a node may go without a poll result before
+	// /healthz fails. It defaults to three block intervals plus the cycle timeout.
+	HealthStaleAfter Duration `json:"healthStaleAfter"`
+
+	// Sinks are the outputs the results are written to.
+	Sinks SinksConfig `json:"sinks"`
+
+	// Queue bounds the poll results waiting to be checked.
+	Queue QueueConfig `json:"queue"`
+
+	// Supervisor restarts the polling of the nodes which crashed.
+	Supervisor SupervisorConfig `json:"supervisor"`
+
+	// Pushgateway receives the metrics of the audit command.
+	Pushgateway PushgatewayConfig `json:"pushgateway"`
+
+	// StateDump is where the internal state is written on SIGUSR1.
+	StateDump StateDumpConfig `json:"stateDump"`
+
+	// FinalityFile is where the highest finalized block of every node is
+	// persisted, to verify on startup that no node rolled back its finality
+	// while the monitor was down. Disabled when empty.
+	FinalityFile string `json:"finalityFile"`
+
+	// Snapshot is where the state of the pollers and checks is snapshotted,
+	// to resume it after a restart.
+	Snapshot SnapshotConfig `json:"snapshot"`
+
+	// Reports are generated from the history of the poll cycles at the end
+	// of every period, disabled when unset.
+	Reports *ReportsConfig `json:"reports"`
+
+	// Tracing exports the poll cycles as traces, disabled when unset.
+	Tracing *TracingConfig `json:"tracing"`
+
+	// ErrorBudget is how many fatal check failures are tolerated before terminating.
+	ErrorBudget ErrorBudget `json:"errorBudget"`
+	// ExitOnNoResponse terminates as soon as the no-response check of a node
+	// fails, bypassing the error budget.
+	ExitOnNoResponse bool `json:"exitOnNoResponse"`
+}
+
+// Duration is a time.Duration written as a string such as "1m30s" in the config.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = duration
+	return nil
+}
+
+// CircuitBreaker is how many consecutive failed requests open the circuit of
+// an endpoint, and for how long requests are then refused before it is probed again.
+// Every URL of a node is an endpoint with its own circuit, opened by the
+// failures of any of its requests.
+type CircuitBreaker struct {
+	Failures int      `json:"failures"` // zero disables the circuit breaker.
+	Cooldown Duration `json:"cooldown"`
+}
+
+// RetryPolicy retries a failed request up to Attempts times. Requests which
+// failed with a 4xx status are never retried.
+type RetryPolicy struct {
+	Attempts int      `json:"attempts"` // zero disables the retries.
+	Backoff  Duration `json:"backoff"`  // before the first retry, doubled for every next one, defaults to 200ms.
+}
+
+// RetryConfig is the retry policy of every endpoint, overridden for some.
+type RetryConfig struct {
+	RetryPolicy
+	Endpoints map[string]RetryPolicy `json:"endpoints"` // by URL.
+}
+
+// defaultRetryBackoff is the wait before the first retry by default.
+const defaultRetryBackoff = 200 * time.Millisecond
+
+// policy returns the retry policy of the endpoint at url.
+func (c RetryConfig) policy(url string) RetryPolicy {
+	if policy, ok := c.Endpoints[url]; ok {
+		return policy
+	}
+	return c.RetryPolicy
+}
+
+// RateLimit is a token bucket shared by the requests to every node.
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond"` // zero disables the limit.
+	Burst             int     `json:"burst"`             // requests sent at once after a pause, defaults to 1.
+}
+
+// TransportConfig tunes the HTTP connections to the nodes, shared by the
+// requests to the same endpoint. Zero fields keep the defaults of Go.
+type TransportConfig struct {
+	MaxIdleConnsPerHost int      `json:"maxIdleConnsPerHost"` // kept open between poll cycles, defaults to 2.
+	MaxConnsPerHost     int      `json:"maxConnsPerHost"`     // unlimited by default.
+	IdleConnTimeout     Duration `json:"idleConnTimeout"`     // after which an idle connection is closed, defaults to 90s.
+	DialTimeout         Duration `json:"dialTimeout"`         // defaults to 30s.
+	KeepAlive           Duration `json:"keepAlive"`           // between TCP keep-alive probes, defaults to 30s.
+	DisableKeepAlives   bool     `json:"disableKeepAlives"`   // opens a connection per request.
+	DisableHTTP2        bool     `json:"disableHTTP2"`        // HTTP/2 is negotiated over TLS by default.
+}
+
+// PushgatewayConfig pushes the metrics of short-lived runs such as audit to a
+// Prometheus Pushgateway, since nothing scrapes them.
+type PushgatewayConfig struct {
+	URL string `json:"url"` // disabled when empty.
+	Job string `json:"job"` // defaults to justified.
+}
+
+// NodeConfig identifies one monitored node. A node with Quorum set is backed
+// by several nodes and every answer is the one given by the majority of them.
+// A node with Failover set is backed by several nodes too, every answer given
+// by the first of them able to.
+type NodeConfig struct {
+	Name     string   `json:"name"` // defaults to the URL.
+	URL      string   `json:"url"`  // http(s)://host[:port][/path] or unix:///path/to/thor.sock.
+	Quorum   []string `json:"quorum"`
+	Failover []string `json:"failover"` // in order of priority.
+	NodeConnection
+}
+
+// NodeConnection configures the connections to a node, or to every node of
+// a quorum.
+type NodeConnection struct {
+	// Auth authenticates every request.
+	Auth client.Auth `json:"auth"`
+	// TLS configures the TLS connections, e.g. for mutual TLS.
+	TLS *client.TLSConfig `json:"tls"`
+	// Proxy is the http://, https:// or socks5:// proxy to reach the node
+	// through, defaulting to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables.
+	Proxy string `json:"proxy"`
+	// Dialer binds the connections to a local address or interface, and
+	// restricts them to IPv4 or IPv6.
+	Dialer *client.DialerConfig `json:"dialer"`
+	// API is how the node is queried: rest (the default) or graphql, the URL
+	// being the endpoint of a GraphQL gateway fronting the node.
+	API string `json:"api"`
+}
+
+// ChainConfig is a chain of a multi-chain config, with the name labeling its
+// metrics and alerts. Its settings are resolved into Config by LoadConfig.
+type ChainConfig struct {
+	Name   string `json:"name"`
+	Config Config `json:"config"`
+
+	raw json.RawMessage // the settings overriding the top level ones.
+}
+
+func (c *ChainConfig) UnmarshalJSON(data []byte) error {
+	var named struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &named); err != nil {
+		return err
+	}
+	c.Name, c.raw = named.Name, data
+	return nil
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Config:         checks.DefaultConfig(),
+		ErrorBudget:    ErrorBudget{Consecutive: 3},
+		CircuitBreaker: CircuitBreaker{Failures: 5, Cooldown: Duration{30 * time.Second}},
+	}
+}
+
+// LoadConfig reads the config file at path. An empty path yields the defaults.
+// The preset of network, if not empty, takes precedence over the network of
+// the top level of the file.
+func LoadConfig(path, network string) (Config, error) {
+	var data []byte
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return DefaultConfig(), fmt.Errorf("error reading config file: %w", err)
+		}
+	}
+	cfg, err := parseConfig(data, nil, network)
+	if err != nil || len(cfg.Chains) == 0 {
+		return cfg, err
+	}
+
+	chainNames := make(map[string]bool)
+	nodeNames := make(map[string]string)
+	for i := range cfg.Chains {
+		chain := &cfg.Chains[i]
+		if chain.Name == "" {
+			return cfg, fmt.Errorf("chain %d has no name", i)
+		}
+		if chainNames[chain.Name] {
+			return cfg, fmt.Errorf("duplicate chain name %q", chain.Name)
+		}
+		chainNames[chain.Name] = true
+
+		if chain.Config, err = parseConfig(data, chain.raw, network); err != nil {
+			return cfg, fmt.Errorf("error loading chain %s: %w", chain.Name, err)
+		}
+		chain.Config.Chains = nil
+		chain.Config.Chain = chain.Name
+		// Node names identify the nodes in every output, whatever their chain.
+		for _, node := range chain.Config.Nodes {
+			if other, ok := nodeNames[node.Name]; ok {
+				return cfg, fmt.Errorf("node %q is in chains %s and %s", node.Name, other, chain.Name)
+			}
+			nodeNames[node.Name] = chain.Name
+		}
+	}
+	return cfg, nil
+}
+
+// parseConfig returns the defaults overridden by the file data, then by the
+// network and the settings of a chain if not nil, with the network preset
+// applied and the nodes validated. The top level of a multi-chain config is
+// returned as is.
+func parseConfig(data, chain []byte, network string) (Config, error) {
+	cfg := DefaultConfig()
+	if data != nil {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("unable to unmarshall config - %w", err)
+		}
+	}
+	for i, w := range cfg.Sinks.Alerts.Maintenance {
+		if w.Schedule.spec == "" || w.Duration.Duration <= 0 {
+			return cfg, fmt.Errorf("maintenance window %d needs a schedule and a duration", i)
+		}
+	}
+	cfg.Network = firstNonZero(network, cfg.Network)
+	if chain != nil {
+		if err := json.Unmarshal(chain, &cfg); err != nil {
+			return cfg, fmt.Errorf("unable to unmarshall chain config - %w", err)
+		}
+	} else if len(cfg.Chains) > 0 {
+		if len(cfg.Nodes) > 0 || cfg.NodeURL != "" || cfg.discoverer() != nil {
+			return cfg, fmt.Errorf("nodes are set per chain when chains are set")
+		}
+		return cfg, nil
+	}
+
+	if cfg.Network != "" {
+		preset, ok := Networks[cfg.Network]
+		if !ok {
+			return cfg, fmt.Errorf("unknown network %q, expected one of %s", cfg.Network, strings.Join(NetworkNames(), ", "))
+		}
+		preset.apply(&cfg)
+	}
+
+	switch {
+	case cfg.DNSDiscovery != nil && cfg.KubernetesDiscovery != nil:
+		return cfg, fmt.Errorf("dnsDiscovery and kubernetesDiscovery are exclusive")
+	case cfg.DNSDiscovery != nil:
+		if err := cfg.DNSDiscovery.validate(); err != nil {
+			return cfg, fmt.Errorf("invalid dnsDiscovery: %w", err)
+		}
+	case cfg.KubernetesDiscovery != nil:
+		if err := cfg.KubernetesDiscovery.validate(); err != nil {
+			return cfg, fmt.Errorf("invalid kubernetesDiscovery: %w", err)
+		}
+	case len(cfg.Nodes) == 0:
+		cfg.Nodes = []NodeConfig{{URL: firstNonZero(cfg.NodeURL, NodeURL)}}
+	}
+	names := make(map[string]bool)
+	for i := range cfg.Nodes {
+		node := &cfg.Nodes[i]
+		set := 0
+		for _, ok := range []bool{node.URL != "", len(node.Quorum) > 0, len(node.Failover) > 0} {
+			if ok {
+				set++
+			}
+		}
+		switch {
+		case set > 1:
+			return cfg, fmt.Errorf("node %d has more than one of url, quorum and failover", i)
+		case len(node.Quorum) == 1:
+			return cfg, fmt.Errorf("node %d quorum needs at least 2 urls", i)
+		case len(node.Failover) == 1:
+			return cfg, fmt.Errorf("node %d failover needs at least 2 urls", i)
+		case set == 0:
+			return cfg, fmt.Errorf("node %d has no url", i)
+		}
+		if node.Name == "" {
+			switch {
+			case node.URL != "":
+				node.Name = node.URL
+			case len(node.Quorum) > 0:
+				node.Name = "quorum(" + strings.Join(node.Quorum, ",") + ")"
+			default:
+				node.Name = "failover(" + strings.Join(node.Failover, ",") + ")"
+			}
+		}
+		if names[cfg.Nodes[i].Name] {
+			return cfg, fmt.Errorf("duplicate node name %q", cfg.Nodes[i].Name)
+		}
+		names[cfg.Nodes[i].Name] = true
+	}
+
+	return cfg, nil
+}
+
+// chainConfigs returns the config of every chain of cfg: cfg itself unless it
+// defines several chains.
+func (cfg Config) chainConfigs() []Config {
+	if len(cfg.Chains) == 0 {
+		return []Config{cfg}
+	}
+	configs := make([]Config, 0, len(cfg.Chains))
+	for _, chain := range cfg.Chains {
+		configs = append(configs, chain.Config)
+	}
+	return configs
+}
+
+func firstNonZero[T comparable](values ...T) T {
+	var zero T
+	for _, v := range values {
+		if v != zero {
+			return v
+		}
+	}
+	return zero
+}
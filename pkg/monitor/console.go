@@ -0,0 +1,105 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// ANSI escape sequences of the colorized terminal output.
+const (
+	ansiReset     = "\033[0m"
+	ansiRed       = "\033[31m"
+	ansiGreen     = "\033[32m"
+	ansiYellow    = "\033[33m"
+	ansiClearLine = "\r\033[K"
+)
+
+// resultsOut is where the JSON lines output is printed.
+var resultsOut = os.Stdout
+
+// ReserveStdout keeps stdout for the results and redirects every other print
+// to stderr, so that the JSON lines output can be piped as is.
+func ReserveStdout() {
+	resultsOut = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+// colorTerminal reports whether stdout is an interactive terminal accepting
+// colors, honouring NO_COLOR and TERM=dumb.
+func colorTerminal() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// statusLine is the compact status of every node, rendered on the last line of
+// the terminal and redrawn in place after each poll cycle.
+type statusLine struct {
+	nodes map[string]string // colored status by node.
+	shown bool
+}
+
+func (l *statusLine) update(r checks.BlockResult, outcomes []checks.Outcome) {
+	color := ansiGreen
+	for _, outcome := range outcomes {
+		if outcome.Severity == checks.SeverityFatal {
+			color = ansiRed
+			break
+		}
+		color = ansiYellow
+	}
+	status := fmt.Sprintf("%s best %d justified %d finalized %d", r.Node, r.Best, r.Justified, r.Finalized)
+	if e := r.Epoch; e != nil {
+		status += fmt.Sprintf(" epoch %d %d/%d justified in ~%s", e.Epoch, e.Position, e.Length, e.JustifiedETA)
+	}
+	l.nodes[r.Node] = color + status + ansiReset
+}
+
+// epochStatus describes when the checkpoint of the epoch e is expected to be
+// justified and finalized.
+func epochStatus(e *checks.EpochProgress) string {
+	return fmt.Sprintf("checkpoint %d justified in %d blocks (~%s), finalized in %d blocks (~%s)",
+		e.Checkpoint, e.JustifiedIn, e.JustifiedETA, e.FinalizedIn, e.FinalizedETA)
+}
+
+func (l *statusLine) render() {
+	var b strings.Builder
+	for _, node := range sortedKeys(l.nodes) {
+		if b.Len() > 0 {
+			b.WriteString(" | ")
+		}
+		b.WriteString(l.nodes[node])
+	}
+	fmt.Print(ansiClearLine + b.String())
+	l.shown = true
+}
+
+// clear removes the status line so that a regular line can be printed.
+func (l *statusLine) clear() {
+	if l.shown {
+		fmt.Print(ansiClearLine)
+		l.shown = false
+	}
+}
+
+// OutputFormats are the formats the results can be printed to stdout in.
+var OutputFormats = []string{"text", "tap", "jsonl"}
+
+// newConsoleSink returns the sink printing results to stdout in format, with
+// the verbosity applying to the text format.
+func newConsoleSink(format string, verbosity int, cfg Config) (Sink, error) {
+	switch format {
+	case "", "text":
+		return newStdoutSink(verbosity), nil
+	case "tap":
+		return newTAPSink(cfg), nil
+	case "jsonl":
+		return newJSONLSink(cfg), nil
+	}
+	return nil, fmt.Errorf("unknown output format %q, expected one of %s", format, strings.Join(OutputFormats, ", "))
+}
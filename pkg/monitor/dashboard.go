@@ -0,0 +1,207 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dashboardColumns is how many panels a row of the dashboard holds.
+const dashboardColumns = 2
+
+// describer is a registerer keeping the descriptions of the metrics
+// registered, without collecting them.
+type describer struct {
+	descs []*prometheus.Desc
+}
+
+func (d *describer) Register(c prometheus.Collector) error {
+	ch := make(chan *prometheus.Desc)
+	go func() {
+		c.Describe(ch)
+		close(ch)
+	}()
+	for desc := range ch {
+		d.descs = append(d.descs, desc)
+	}
+	return nil
+}
+
+func (d *describer) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		d.Register(c)
+	}
+}
+
+func (d *describer) Unregister(prometheus.Collector) bool {
+	return false
+}
+
+// metricDesc is a metric registered by the monitor.
+type metricDesc struct {
+	name   string
+	help   string
+	labels []string // variable labels, the chain one included when several chains are monitored.
+}
+
+// descPattern parses a prometheus.Desc, which exposes its fields only through String.
+var descPattern = regexp.MustCompile(`fqName: ("(?:[^"\\]|\\.)*"), help: ("(?:[^"\\]|\\.)*"), constLabels: \{([^}]*)\}, variableLabels: \{([^}]*)\}`)
+
+// registeredMetrics returns the metrics the monitor registers for the chains
+// of cfg, in registration order.
+func registeredMetrics(cfg Config) ([]metricDesc, error) {
+	d := &describer{}
+	for _, chain := range cfg.chainConfigs() {
+		var reg prometheus.Registerer = d
+		if chain.Chain != "" {
+			reg = prometheus.WrapRegistererWith(prometheus.Labels{"chain": chain.Chain}, d)
+		}
+		newMetrics(reg)
+	}
+	queue, err := newResultQueue(QueueConfig{})
+	if err != nil {
+		return nil, err
+	}
+	queue.register(d)
+	newSupervisor(SupervisorConfig{}).register(d)
+
+	var metrics []metricDesc
+	seen := make(map[string]bool)
+	for _, desc := range d.descs {
+		match := descPattern.FindStringSubmatch(desc.String())
+		if match == nil {
+			return nil, fmt.Errorf("error parsing metric description %s", desc)
+		}
+		name, err := strconv.Unquote(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing metric description %s: %w", desc, err)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		help, err := strconv.Unquote(match[2])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing metric description %s: %w", desc, err)
+		}
+		var labels []string
+		if strings.HasPrefix(match[3], "chain=") {
+			labels = append(labels, "chain")
+		}
+		if match[4] != "" {
+			labels = append(labels, strings.Split(match[4], ",")...)
+		}
+		metrics = append(metrics, metricDesc{name: name, help: help, labels: labels})
+	}
+	return metrics, nil
+}
+
+// Dashboard returns a Grafana dashboard with a panel for every metric the
+// monitor registers for the chains of cfg, filtered by node and chain
+// through the variables of the dashboard.
+func Dashboard(cfg Config, title string) ([]byte, error) {
+	metrics, err := registeredMetrics(cfg)
+	if err != nil {
+		return nil, err
+	}
+	multiChain := len(cfg.Chains) > 0
+
+	datasource := map[string]any{"type": "prometheus", "uid": "${datasource}"}
+	variables := []any{map[string]any{"name": "datasource", "label": "Data source", "type": "datasource", "query": "prometheus"}}
+	if multiChain {
+		variables = append(variables, queryVariable(datasource, "chain", "justified_block_height"))
+	}
+	variables = append(variables, queryVariable(datasource, "node", "justified_block_height"))
+
+	panels := make([]any, 0, len(metrics))
+	for i, metric := range metrics {
+		// The process-wide metrics have neither a node nor a chain label.
+		var filters []string
+		for _, label := range metric.labels {
+			if label == "chain" || label == "node" {
+				filters = append(filters, fmt.Sprintf(`%s=~"$%s"`, label, label))
+			}
+		}
+		expr := metric.name
+		if len(filters) > 0 {
+			expr += "{" + strings.Join(filters, ",") + "}"
+		}
+		if strings.HasSuffix(metric.name, "_total") {
+			expr = fmt.Sprintf("increase(%s[$__rate_interval])", expr)
+		}
+		legend := make([]string, len(metric.labels))
+		for j, label := range metric.labels {
+			legend[j] = "{{" + label + "}}"
+		}
+		panels = append(panels, map[string]any{
+			"id":          i + 1,
+			"type":        "timeseries",
+			"title":       panelTitle(metric.name),
+			"description": metric.help,
+			"datasource":  datasource,
+			"gridPos": map[string]int{
+				"x": (i % dashboardColumns) * 24 / dashboardColumns,
+				"y": (i / dashboardColumns) * 8,
+				"w": 24 / dashboardColumns,
+				"h": 8,
+			},
+			"fieldConfig": map[string]any{"defaults": map[string]string{"unit": panelUnit(metric.name)}, "overrides": []any{}},
+			"targets": []any{map[string]any{
+				"refId":        "A",
+				"datasource":   datasource,
+				"expr":         expr,
+				"legendFormat": strings.Join(legend, " "),
+			}},
+		})
+	}
+
+	return json.MarshalIndent(map[string]any{
+		"title":         title,
+		"uid":           "justified",
+		"tags":          []string{"justified", "vechain"},
+		"schemaVersion": 39,
+		"refresh":       "30s",
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"templating":    map[string]any{"list": variables},
+		"panels":        panels,
+	}, "", "  ")
+}
+
+// queryVariable is a dashboard variable selecting some values of label of metric.
+func queryVariable(datasource map[string]any, label, metric string) map[string]any {
+	query := fmt.Sprintf("label_values(%s, %s)", metric, label)
+	return map[string]any{
+		"name":       label,
+		"label":      strings.ToUpper(label[:1]) + label[1:],
+		"type":       "query",
+		"datasource": datasource,
+		"query":      map[string]string{"query": query, "refId": label},
+		"definition": query,
+		"refresh":    2, // on time range change, to pick up the discovered nodes.
+		"multi":      true,
+		"includeAll": true,
+		"current":    map[string]any{"text": "All", "value": "$__all"},
+	}
+}
+
+// panelTitle turns a metric name into a title, e.g. justified_block_height
+// into Block height.
+func panelTitle(name string) string {
+	title := strings.ReplaceAll(strings.TrimPrefix(strings.TrimSuffix(name, "_total"), "justified_"), "_", " ")
+	return strings.ToUpper(title[:1]) + title[1:]
+}
+
+// panelUnit returns the Grafana unit of a metric after its name suffix.
+func panelUnit(name string) string {
+	switch {
+	case strings.HasSuffix(name, "_seconds"):
+		return "s"
+	case strings.HasSuffix(name, "_ratio"):
+		return "percentunit"
+	}
+	return "short"
+}
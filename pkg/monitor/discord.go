@@ -0,0 +1,113 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// defaultDiscordTimeout bounds a request to a Discord webhook by default.
+const defaultDiscordTimeout = 10 * time.Second
+
+// The colors of the Discord embeds.
+const (
+	discordColorFatal    = 0xe74c3c
+	discordColorWarn     = 0xf1c40f
+	discordColorResolved = 0x2ecc71
+)
+
+// DiscordConfig posts the alerts as embeds to a Discord channel webhook.
+type DiscordConfig struct {
+	Webhook  string   `json:"webhook"`  // URL of the channel webhook.
+	Username string   `json:"username"` // overriding the name of the webhook.
+	Timeout  Duration `json:"timeout"`  // of every request, defaults to 10s.
+}
+
+// discordMessage is the body of a Discord webhook request.
+type discordMessage struct {
+	Username string         `json:"username,omitempty"`
+	Embeds   []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Color       int            `json:"color"`
+	Timestamp   time.Time      `json:"timestamp"`
+	Fields      []discordField `json:"fields"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordAlerter struct {
+	webhook  string
+	username string
+	client   *http.Client
+}
+
+func newDiscordAlerter(cfg DiscordConfig) (*discordAlerter, error) {
+	if cfg.Webhook == "" {
+		return nil, errors.New("discord webhook not set")
+	}
+	return &discordAlerter{
+		webhook:  cfg.Webhook,
+		username: cfg.Username,
+		client:   &http.Client{Timeout: firstNonZero(cfg.Timeout.Duration, defaultDiscordTimeout)},
+	}, nil
+}
+
+func (d *discordAlerter) Alert(a Alert) error {
+	data, err := json.Marshal(discordMessage{Username: d.username, Embeds: []discordEmbed{newDiscordEmbed(a)}})
+	if err != nil {
+		return err
+	}
+	res, err := d.client.Post(d.webhook, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("status code not 2xx: %s", res.Status)
+	}
+	return nil
+}
+
+// newDiscordEmbed describes a with the invariant violated, in the
+// description, and the block heights of the node.
+func newDiscordEmbed(a Alert) discordEmbed {
+	embed := discordEmbed{
+		Title:       fmt.Sprintf("%s failed on %s", a.Check, a.Node),
+		Description: a.Error,
+		Color:       discordColorWarn,
+		Timestamp:   a.Since,
+	}
+	switch {
+	case a.Resolved:
+		embed.Title = fmt.Sprintf("Resolved: %s passes again on %s", a.Check, a.Node)
+		embed.Description = fmt.Sprintf("Failing since %s: %s", a.Since.Format(time.RFC3339), a.Error)
+		embed.Color = discordColorResolved
+	case a.Severity == checks.SeverityFatal:
+		embed.Color = discordColorFatal
+	}
+	if a.Chain != "" {
+		embed.Fields = append(embed.Fields, discordField{Name: "Chain", Value: a.Chain, Inline: true})
+	}
+	embed.Fields = append(embed.Fields,
+		discordField{Name: "Severity", Value: a.Severity.String(), Inline: true},
+		discordField{Name: "Failures", Value: strconv.Itoa(a.Failures), Inline: true},
+		discordField{Name: "Best", Value: strconv.FormatUint(uint64(a.Best), 10), Inline: true},
+		discordField{Name: "Justified", Value: strconv.FormatUint(uint64(a.Justified), 10), Inline: true},
+		discordField{Name: "Finalized", Value: strconv.FormatUint(uint64(a.Finalized), 10), Inline: true},
+	)
+	return embed
+}
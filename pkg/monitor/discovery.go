@@ -0,0 +1,191 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultDiscoveryInterval is how often the nodes are discovered again by default.
+const defaultDiscoveryInterval = 30 * time.Second
+
+// discoveryTimeout bounds a discovery of the nodes.
+const discoveryTimeout = 10 * time.Second
+
+// discoverer discovers the nodes of a chain.
+type discoverer interface {
+	discover(ctx context.Context) ([]NodeConfig, error)
+	interval() time.Duration
+	// String describes the nodes discovered in the logs.
+	String() string
+}
+
+// discoverer returns the discoverer of the nodes of cfg, nil if none.
+func (cfg Config) discoverer() discoverer {
+	switch {
+	case cfg.DNSDiscovery != nil:
+		return cfg.DNSDiscovery
+	case cfg.KubernetesDiscovery != nil:
+		return cfg.KubernetesDiscovery
+	}
+	return nil
+}
+
+// discoveryURL returns the URL of a discovered node at addr.
+func discoveryURL(scheme, addr string) string {
+	return firstNonZero(scheme, "http") + "://" + addr + "/"
+}
+
+func validateDiscoveryScheme(scheme string) error {
+	if scheme != "" && scheme != "http" && scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", scheme)
+	}
+	return nil
+}
+
+// DNSDiscovery discovers the nodes from the records of a DNS name: its SRV
+// records, or its A and AAAA records with Port. The name is resolved again
+// every interval, adding and removing nodes as the records change.
+type DNSDiscovery struct {
+	Name     string   `json:"name"`
+	Port     int      `json:"port"`     // of the nodes resolved from A and AAAA records, SRV records are resolved when 0.
+	Scheme   string   `json:"scheme"`   // http or https, defaults to http.
+	Interval Duration `json:"interval"` // defaults to 30s.
+	// NodeConnection configures the connections to every discovered node.
+	NodeConnection
+}
+
+func (d *DNSDiscovery) validate() error {
+	if d.Name == "" {
+		return errors.New("name is required")
+	}
+	if err := validateDiscoveryScheme(d.Scheme); err != nil {
+		return err
+	}
+	if d.Port < 0 || d.Port > 65535 {
+		return fmt.Errorf("invalid port %d", d.Port)
+	}
+	return nil
+}
+
+// discover resolves the name of d to the nodes it points to, sorted by name,
+// each named by its address. A name which does not exist points to no node.
+func (d *DNSDiscovery) discover(ctx context.Context) ([]NodeConfig, error) {
+	var resolver net.Resolver
+	var addrs []string
+	var dnsErr *net.DNSError
+	if d.Port == 0 {
+		_, records, err := resolver.LookupSRV(ctx, "", "", d.Name)
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error looking up SRV records of %s: %w", d.Name, err)
+		}
+		for _, srv := range records {
+			addrs = append(addrs, net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port))))
+		}
+	} else {
+		hosts, err := resolver.LookupHost(ctx, d.Name)
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error looking up %s: %w", d.Name, err)
+		}
+		for _, host := range hosts {
+			addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(d.Port)))
+		}
+	}
+	slices.Sort(addrs)
+	addrs = slices.Compact(addrs)
+
+	nodes := make([]NodeConfig, 0, len(addrs))
+	for _, addr := range addrs {
+		nodes = append(nodes, NodeConfig{Name: addr, URL: discoveryURL(d.Scheme, addr), NodeConnection: d.NodeConnection})
+	}
+	return nodes, nil
+}
+
+func (d *DNSDiscovery) interval() time.Duration {
+	return firstNonZero(d.Interval.Duration, defaultDiscoveryInterval)
+}
+
+func (d *DNSDiscovery) String() string {
+	return "nodes of " + d.Name
+}
+
+// discovery is a running discovery of the nodes of a chain.
+type discovery struct {
+	chain string
+	stop  context.CancelFunc
+}
+
+// discoveredNodes are the nodes found by a discovery.
+type discoveredNodes struct {
+	from  *discovery
+	nodes []NodeConfig
+}
+
+// startDiscovery discovers the nodes of c every interval until stopDiscovery,
+// if its config discovers some. The first discovery happens right away.
+func (m *Monitor) startDiscovery(name string, c *chain) {
+	d := c.cfg.discoverer()
+	if d == nil {
+		return
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	c.discovery = &discovery{chain: name, stop: stop}
+	go func(from *discovery) {
+		ticker := time.NewTicker(d.interval())
+		defer ticker.Stop()
+		for {
+			discoverCtx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+			nodes, err := d.discover(discoverCtx)
+			cancel()
+			if err != nil {
+				// The nodes discovered before keep being monitored.
+				m.log.Printf("Error discovering the %s: %v", d, err)
+			} else {
+				select {
+				case m.discovered <- discoveredNodes{from: from, nodes: nodes}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}(c.discovery)
+}
+
+// stopDiscovery stops the discovery of the nodes of c and forgets the nodes
+// it discovered, which keep running until the nodes of c are synced.
+func (m *Monitor) stopDiscovery(c *chain) {
+	if c.discovery == nil {
+		return
+	}
+	c.discovery.stop()
+	c.discovery = nil
+	c.discovered = nil
+}
+
+// applyDiscovered monitors the nodes just discovered for a chain, and stops
+// monitoring the ones which disappeared.
+func (m *Monitor) applyDiscovered(d discoveredNodes) {
+	c, ok := m.chains[d.from.chain]
+	if !ok || c.discovery != d.from {
+		// the discovery was stopped while discovering.
+		return
+	}
+	c.discovered = d.nodes
+	m.syncNodes(d.from.chain, c, false)
+}
@@ -0,0 +1,79 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// defaultExecTimeout bounds a run of the exec hook by default.
+const defaultExecTimeout = 30 * time.Second
+
+// ExecSinkConfig runs a command on every poll cycle failing checks, to plug
+// in remediation scripts. The command is passed the JSON record of the cycle
+// on stdin, along with the JUSTIFIED_CHAIN, JUSTIFIED_NODE, JUSTIFIED_CHECKS
+// (comma separated failed checks) and JUSTIFIED_SEVERITY (of the most severe
+// failed check) environment variables.
+type ExecSinkConfig struct {
+	Command []string `json:"command"` // program and arguments, run without a shell.
+	Timeout Duration `json:"timeout"` // after which the command is killed, defaults to 30s.
+}
+
+type execSink struct {
+	command []string
+	timeout time.Duration
+}
+
+func newExecSink(cfg ExecSinkConfig) (*execSink, error) {
+	if len(cfg.Command) == 0 {
+		return nil, errors.New("exec sink command is required")
+	}
+	return &execSink{command: cfg.Command, timeout: firstNonZero(cfg.Timeout.Duration, defaultExecTimeout)}, nil
+}
+
+func (s *execSink) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	if len(outcomes) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(newRecord(r, outcomes))
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(outcomes))
+	severity := checks.SeverityWarn
+	for _, outcome := range outcomes {
+		names = append(names, outcome.Name)
+		severity = max(severity, outcome.Severity)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, s.command[0], s.command[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"JUSTIFIED_CHAIN="+r.Chain,
+		"JUSTIFIED_NODE="+r.Node,
+		"JUSTIFIED_CHECKS="+strings.Join(names, ","),
+		"JUSTIFIED_SEVERITY="+severity.String(),
+	)
+	out, err := cmd.CombinedOutput()
+	if len(out) > 0 {
+		fmt.Printf("Exec hook output for %s: %s\n", r.Node, bytes.TrimSpace(out))
+	}
+	if err != nil {
+		return fmt.Errorf("error running exec hook: %w", err)
+	}
+	return nil
+}
+
+func (s *execSink) Close() error {
+	return nil
+}
@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// Exit codes of the monitor, so supervisors and CI can tell failures apart.
+const (
+	ExitConfigError     = 1 // the configuration is invalid.
+	ExitCheckViolation  = 2 // a check exhausted the error budget.
+	ExitNodeUnreachable = 3 // a node could not be polled within the error budget.
+	ExitInternalError   = 4 // the monitor itself failed.
+)
+
+// FatalRecord is written to stderr as a JSON line before the monitor exits.
+type FatalRecord struct {
+	Time     time.Time `json:"time"`
+	Level    string    `json:"level"`
+	Reason   string    `json:"reason"`
+	ExitCode int       `json:"exitCode"`
+	Node     string    `json:"node,omitempty"`
+	Check    string    `json:"check,omitempty"`
+	Error    string    `json:"error"`
+	Stack    string    `json:"stack,omitempty"`
+}
+
+// Terminate logs rec, flushes the outputs and exits with code.
+func Terminate(code int, rec FatalRecord) {
+	rec.Time = time.Now()
+	rec.Level = "fatal"
+	rec.ExitCode = code
+	if rec.Reason == "" {
+		rec.Reason = exitReason(code)
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%+v", rec))
+	}
+	fmt.Fprintln(os.Stderr, string(data))
+
+	os.Stdout.Sync()
+	os.Stderr.Sync()
+	os.Exit(code)
+}
+
+func exitReason(code int) string {
+	switch code {
+	case ExitConfigError:
+		return "config error"
+	case ExitCheckViolation:
+		return "check violation"
+	case ExitNodeUnreachable:
+		return "node unreachable"
+	default:
+		return "internal error"
+	}
+}
+
+// ExitOnPanic terminates with exitInternalError when the calling goroutine
+// panics. It must be deferred.
+func ExitOnPanic() {
+	if r := recover(); r != nil {
+		Terminate(ExitInternalError, FatalRecord{Error: fmt.Sprint(r), Stack: string(debug.Stack())})
+	}
+}
+
+// BudgetExhausted terminates after the error budget of outcome's node was
+// exhausted, with exitNodeUnreachable when the node could not be polled.
+func BudgetExhausted(outcome checks.Outcome) {
+	code := ExitCheckViolation
+	if outcome.Name == checks.FetchErrors || outcome.Name == checks.NoResponse {
+		code = ExitNodeUnreachable
+	}
+	Terminate(code, FatalRecord{Node: outcome.Node, Check: outcome.Name, Error: outcome.Err.Error()})
+}
@@ -0,0 +1,79 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"sync"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// finalityStore persists the highest finalized block of every node, so that a
+// finality rollback happening while the monitor is down is caught once it
+// restarts. It is fed the results like any other sink.
+type finalityStore struct {
+	path string
+
+	mu    sync.Mutex
+	marks map[string]checks.FinalizedMark // by node.
+}
+
+// loadFinalityStore reads the finalized blocks persisted at path, if any.
+func loadFinalityStore(path string) (*finalityStore, error) {
+	s := &finalityStore{path: path, marks: make(map[string]checks.FinalizedMark)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading finality file: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.marks); err != nil {
+		return nil, fmt.Errorf("error decoding finality file: %w", err)
+	}
+	return s, nil
+}
+
+// mark returns the finalized block persisted for node.
+func (s *finalityStore) mark(node string) (checks.FinalizedMark, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mark, ok := s.marks[node]
+	return mark, ok
+}
+
+func (s *finalityStore) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	if !r.Fetched(checks.FieldFinalized) || r.FinalizedID == "" {
+		return nil
+	}
+	s.mu.Lock()
+	if mark, ok := s.marks[r.Node]; ok && mark.Number >= r.Finalized {
+		s.mu.Unlock()
+		return nil
+	}
+	s.marks[r.Node] = checks.FinalizedMark{Number: r.Finalized, ID: r.FinalizedID}
+	marks := maps.Clone(s.marks)
+	s.mu.Unlock()
+
+	data, err := json.MarshalIndent(marks, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Renaming keeps the previous file whole if the monitor dies meanwhile.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("error writing finality file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("error writing finality file: %w", err)
+	}
+	return nil
+}
+
+func (s *finalityStore) Close() error {
+	return nil
+}
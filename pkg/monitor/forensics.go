@@ -0,0 +1,209 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// The defaults of the forensic bundles.
+const (
+	defaultForensicsNeighbors = 2
+	defaultForensicsTimeout   = 30 * time.Second
+)
+
+// ForensicsConfig archives a bundle on disk when a fatal check starts failing
+// on a node, for post-mortem analysis: the expanded best, justified and
+// finalized blocks and their neighbors, as answered by every endpoint of the
+// node, along with the record of the poll cycle.
+type ForensicsConfig struct {
+	Dir       string   `json:"dir"`       // holding a directory per bundle, named after the poll cycle and the node.
+	Neighbors int      `json:"neighbors"` // blocks captured on each side of the best, justified and finalized ones, defaults to 2.
+	Timeout   Duration `json:"timeout"`   // of the capture of a bundle, defaults to 30s.
+}
+
+// nodeClients are the clients of the running nodes, shared with the sinks.
+type nodeClients struct {
+	mu      sync.Mutex
+	clients map[string]*client.Client
+}
+
+func newNodeClients() *nodeClients {
+	return &nodeClients{clients: make(map[string]*client.Client)}
+}
+
+func (n *nodeClients) set(name string, c *client.Client) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.clients[name] = c
+}
+
+func (n *nodeClients) get(name string) *client.Client {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.clients[name]
+}
+
+func (n *nodeClients) remove(name string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.clients, name)
+}
+
+// forensicBundle is the violation.json file of a bundle.
+type forensicBundle struct {
+	Time       time.Time       `json:"time"`
+	Chain      string          `json:"chain,omitempty"`
+	Node       string          `json:"node"`
+	Violations []OutcomeRecord `json:"violations"` // the fatal checks which started failing.
+	Record     Record          `json:"record"`
+}
+
+// forensicResponse is an entry of the index.json file of a bundle.
+type forensicResponse struct {
+	Endpoint string `json:"endpoint"`
+	Path     string `json:"path"`
+	File     string `json:"file,omitempty"` // of the raw response, relative to the bundle.
+	Error    string `json:"error,omitempty"`
+}
+
+// unsafeFileChars are replaced in the names of the bundle files.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// forensicSink captures a bundle once per fatal check starting to fail on a node.
+type forensicSink struct {
+	dir       string
+	neighbors int
+	timeout   time.Duration
+	clients   *nodeClients
+	failing   map[alertKey]bool // fatal checks failing in the last poll cycle of their node.
+}
+
+func newForensicSink(cfg ForensicsConfig, clients *nodeClients) (*forensicSink, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("forensics dir not set")
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating forensics dir: %w", err)
+	}
+	return &forensicSink{
+		dir:       cfg.Dir,
+		neighbors: firstNonZero(cfg.Neighbors, defaultForensicsNeighbors),
+		timeout:   firstNonZero(cfg.Timeout.Duration, defaultForensicsTimeout),
+		clients:   clients,
+		failing:   make(map[alertKey]bool),
+	}, nil
+}
+
+func (s *forensicSink) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	previous := make(map[alertKey]bool)
+	for key := range s.failing {
+		if key.node == r.Node {
+			previous[key] = true
+			delete(s.failing, key)
+		}
+	}
+	var violations []OutcomeRecord
+	for _, outcome := range outcomes {
+		key := alertKey{node: outcome.Node, check: outcome.Name}
+		// the node does not answer the requests of a bundle either.
+		if outcome.Severity != checks.SeverityFatal || outcome.Name == checks.FetchErrors || outcome.Name == checks.NoResponse || s.failing[key] {
+			continue
+		}
+		s.failing[key] = true
+		if !previous[key] {
+			violations = append(violations, OutcomeRecord{Node: outcome.Node, Name: outcome.Name, Severity: outcome.Severity, Error: outcome.Err.Error()})
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	c := s.clients.get(r.Node)
+	if c == nil {
+		return nil
+	}
+
+	bundle := filepath.Join(s.dir, r.Time.UTC().Format("20060102T150405.000Z")+"-"+unsafeFileChars.ReplaceAllString(r.Node, "_"))
+	if err := os.MkdirAll(filepath.Join(bundle, "responses"), 0o755); err != nil {
+		return fmt.Errorf("error creating forensic bundle: %w", err)
+	}
+	if err := writeBundleFile(filepath.Join(bundle, "violation.json"), forensicBundle{
+		Time:       r.Time,
+		Chain:      r.Chain,
+		Node:       r.Node,
+		Violations: violations,
+		Record:     newRecord(r, outcomes),
+	}); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var index []forensicResponse
+	for _, revision := range s.revisions(r) {
+		path := "blocks/" + revision + "?expanded=true"
+		for i, res := range c.Dump(ctx, path) {
+			entry := forensicResponse{Endpoint: res.Endpoint, Path: path}
+			if res.Err != nil {
+				entry.Error = res.Err.Error()
+			}
+			if res.Dump != nil {
+				entry.File = filepath.Join("responses", fmt.Sprintf("%d-%s.http", i, revision))
+				if err := os.WriteFile(filepath.Join(bundle, entry.File), res.Dump, 0o644); err != nil {
+					return fmt.Errorf("error writing forensic bundle: %w", err)
+				}
+			}
+			index = append(index, entry)
+		}
+	}
+	if err := writeBundleFile(filepath.Join(bundle, "index.json"), index); err != nil {
+		return err
+	}
+	fmt.Printf("Forensic bundle of %s written to %s\n", r.Node, bundle)
+	return nil
+}
+
+// revisions returns the named revisions and the numbers of the blocks around
+// the best, justified and finalized ones of r, lowest first.
+func (s *forensicSink) revisions(r checks.BlockResult) []string {
+	var numbers []uint32
+	for _, n := range []uint32{r.Best, r.Justified, r.Finalized} {
+		for i := -s.neighbors; i <= s.neighbors; i++ {
+			if int64(n)+int64(i) >= 0 {
+				numbers = append(numbers, uint32(int64(n)+int64(i)))
+			}
+		}
+	}
+	slices.Sort(numbers)
+	revisions := []string{"best", "justified", "finalized"}
+	for _, n := range slices.Compact(numbers) {
+		revisions = append(revisions, strconv.FormatUint(uint64(n), 10))
+	}
+	return revisions
+}
+
+func (s *forensicSink) Close() error {
+	return nil
+}
+
+func writeBundleFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("error writing forensic bundle: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// health tracks the poll loops of the monitor to answer liveness and
+// readiness probes. It is fed the results like any other sink.
+type health struct {
+	started time.Time
+
+	mu         sync.Mutex
+	staleAfter time.Duration // a node without result for longer is considered wedged.
+	nodes      map[string]*nodeHealth
+}
+
+type nodeHealth struct {
+	LastResult *time.Time `json:"lastResult,omitempty"` // nil before the first result.
+	Reachable  bool       `json:"reachable"`
+	Error      string     `json:"error,omitempty"`
+	Stats      *nodeStats `json:"stats,omitempty"` // nil before the first result.
+}
+
+// nodeStats are the rolling statistics of a node over its recent poll cycles.
+type nodeStats struct {
+	JustifiedGap    statsSummary `json:"justifiedGap"`    // blocks between the best and the justified block.
+	FinalizedGap    statsSummary `json:"finalizedGap"`    // blocks between the best and the finalized block.
+	FinalityLatency statsSummary `json:"finalityLatency"` // seconds from production to finalization of the checkpoints.
+	Promotion       statsSummary `json:"promotion"`       // seconds from justification to finalization of the checkpoints.
+}
+
+type statsSummary struct {
+	Count  int     `json:"count"`
+	Avg    float64 `json:"avg"`
+	Median float64 `json:"median"`
+	P95    float64 `json:"p95"`
+}
+
+func newStatsSummary(s checks.Stats) statsSummary {
+	return statsSummary{Count: s.Count, Avg: s.Avg, Median: s.Median, P95: s.P95}
+}
+
+func newHealth(nodes []string, staleAfter time.Duration) *health {
+	h := &health{staleAfter: staleAfter, started: time.Now(), nodes: make(map[string]*nodeHealth, len(nodes))}
+	for _, node := range nodes {
+		h.nodes[node] = &nodeHealth{}
+	}
+	return h
+}
+
+// add starts tracking node, if not tracked already.
+func (h *health) add(node string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.nodes[node]; !ok {
+		h.nodes[node] = &nodeHealth{}
+	}
+}
+
+func (h *health) setStaleAfter(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.staleAfter = d
+}
+
+func (h *health) remove(node string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.nodes, node)
+}
+
+func (h *health) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	n := &nodeHealth{LastResult: &now, Reachable: r.BestErr == nil, Stats: &nodeStats{
+		JustifiedGap:    newStatsSummary(r.Gaps.Justified),
+		FinalizedGap:    newStatsSummary(r.Gaps.Finalized),
+		FinalityLatency: newStatsSummary(r.Latency.Seconds),
+		Promotion:       newStatsSummary(r.Latency.Promotion),
+	}}
+	if r.BestErr != nil {
+		n.Error = r.BestErr.Error()
+	}
+	if _, ok := h.nodes[r.Node]; ok {
+		h.nodes[r.Node] = n
+	}
+	return nil
+}
+
+func (h *health) Close() error {
+	return nil
+}
+
+// healthStatus is the body of the probe responses.
+type healthStatus struct {
+	Status string                `json:"status"`
+	Nodes  map[string]nodeHealth `json:"nodes"`
+	Failed []string              `json:"failed,omitempty"`
+}
+
+// status reports the nodes whose poll loop is wedged and, when ready is set,
+// the nodes which were not reachable in their latest poll cycle.
+func (h *health) status(ready bool) healthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := healthStatus{Status: "ok", Nodes: make(map[string]nodeHealth, len(h.nodes))}
+	now := time.Now()
+	for name, n := range h.nodes {
+		s.Nodes[name] = *n
+		last := h.started
+		if n.LastResult != nil {
+			last = *n.LastResult
+		}
+		switch {
+		case now.Sub(last) > h.staleAfter:
+			s.Failed = append(s.Failed, name+": no poll result for "+now.Sub(last).Round(time.Second).String())
+		case ready && !n.Reachable:
+			s.Failed = append(s.Failed, name+": unreachable")
+		}
+	}
+	sort.Strings(s.Failed)
+	if len(s.Failed) > 0 {
+		s.Status = "unavailable"
+	}
+	return s
+}
+
+// handler answers 200 when the probe succeeds and 503 otherwise.
+func (h *health) handler(ready bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s := h.status(ready)
+		w.Header().Set("Content-Type", "application/json")
+		if len(s.Failed) > 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(s)
+	})
+}
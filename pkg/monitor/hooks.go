@@ -0,0 +1,173 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// defaultHookTimeout bounds a webhook request or a run of a hook command by default.
+const defaultHookTimeout = 10 * time.Second
+
+// HooksConfig fires hooks when a chain justifies or finalizes a new
+// checkpoint, to automate what should happen at finality such as
+// settlements or snapshots. Every checkpoint fires the hooks once per chain,
+// when the first of its nodes reports it.
+type HooksConfig struct {
+	// Webhooks are the URLs the JSON CheckpointEvent is posted to.
+	Webhooks []string `json:"webhooks"`
+	// Exec is a command passed the JSON CheckpointEvent on stdin, along
+	// with the JUSTIFIED_CHAIN, JUSTIFIED_NODE, JUSTIFIED_EVENT (justified or
+	// finalized), JUSTIFIED_CHECKPOINT and JUSTIFIED_BLOCK_ID variables.
+	Exec    []string `json:"exec"`
+	Timeout Duration `json:"timeout"` // of every hook, defaults to 10s.
+}
+
+// Kinds of a CheckpointEvent.
+const (
+	EventJustified = "justified"
+	EventFinalized = "finalized"
+)
+
+// CheckpointEvent is a checkpoint newly justified or finalized on a chain.
+type CheckpointEvent struct {
+	Chain  string    `json:"chain,omitempty"`
+	Node   string    `json:"node"` // which reported it first.
+	Kind   string    `json:"kind"`
+	Number uint32    `json:"number"`
+	ID     string    `json:"id"`
+	Time   time.Time `json:"time"` // of the poll cycle it was reported in.
+}
+
+// CheckpointHook is fired on every new checkpoint.
+type CheckpointHook interface {
+	Checkpoint(e CheckpointEvent) error
+}
+
+// HookFunc is a Go function fired as a CheckpointHook.
+type HookFunc func(e CheckpointEvent) error
+
+func (f HookFunc) Checkpoint(e CheckpointEvent) error {
+	return f(e)
+}
+
+type webhook struct {
+	url    string
+	client *http.Client
+}
+
+func (h webhook) Checkpoint(e CheckpointEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	res, err := h.client.Post(h.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("status code not 2xx: %s", res.Status)
+	}
+	return nil
+}
+
+type execHook struct {
+	command []string
+	timeout time.Duration
+}
+
+func (h execHook) Checkpoint(e CheckpointEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, h.command[0], h.command[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"JUSTIFIED_CHAIN="+e.Chain,
+		"JUSTIFIED_NODE="+e.Node,
+		"JUSTIFIED_EVENT="+e.Kind,
+		"JUSTIFIED_CHECKPOINT="+strconv.FormatUint(uint64(e.Number), 10),
+		"JUSTIFIED_BLOCK_ID="+e.ID,
+	)
+	out, err := cmd.CombinedOutput()
+	if len(out) > 0 {
+		fmt.Printf("Checkpoint hook output for %s %d: %s\n", e.Kind, e.Number, bytes.TrimSpace(out))
+	}
+	return err
+}
+
+type checkpointKey struct {
+	chain, kind string
+}
+
+// hookSink fires the hooks on the checkpoints newer than those the chains
+// had when the monitor started.
+type hookSink struct {
+	hooks map[string]CheckpointHook
+	last  map[checkpointKey]uint32
+}
+
+// newHookSink returns the sink firing hooks along with the hooks of cfg.
+func newHookSink(cfg HooksConfig, hooks map[string]CheckpointHook) *hookSink {
+	s := &hookSink{hooks: make(map[string]CheckpointHook), last: make(map[checkpointKey]uint32)}
+	for name, hook := range hooks {
+		s.hooks[name] = hook
+	}
+	timeout := firstNonZero(cfg.Timeout.Duration, defaultHookTimeout)
+	for _, url := range cfg.Webhooks {
+		s.hooks[url] = webhook{url: url, client: &http.Client{Timeout: timeout}}
+	}
+	if len(cfg.Exec) > 0 {
+		s.hooks["exec"] = execHook{command: cfg.Exec, timeout: timeout}
+	}
+	return s
+}
+
+func (s *hookSink) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	var errs []error
+	if r.Fetched(checks.FieldJustified) {
+		errs = append(errs, s.update(r, EventJustified, r.Justified, r.JustifiedID))
+	}
+	if r.Fetched(checks.FieldFinalized) {
+		errs = append(errs, s.update(r, EventFinalized, r.Finalized, r.FinalizedID))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *hookSink) update(r checks.BlockResult, kind string, number uint32, id string) error {
+	key := checkpointKey{chain: r.Chain, kind: kind}
+	last, ok := s.last[key]
+	if ok && number <= last {
+		return nil
+	}
+	s.last[key] = number
+	if !ok {
+		return nil
+	}
+
+	e := CheckpointEvent{Chain: r.Chain, Node: r.Node, Kind: kind, Number: number, ID: id, Time: r.Time}
+	var errs []error
+	for name, hook := range s.hooks {
+		if err := hook.Checkpoint(e); err != nil {
+			errs = append(errs, fmt.Errorf("error firing %s hook: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *hookSink) Close() error {
+	return nil
+}
@@ -0,0 +1,134 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// InfluxSinkConfig writes heights, lags and failed checks in the line protocol
+// to an InfluxDB compatible write endpoint.
+type InfluxSinkConfig struct {
+	// URL is the write endpoint, e.g. http://localhost:8086/api/v2/write?org=o&bucket=b
+	// or http://localhost:8086/write?db=finality. Points have nanosecond precision.
+	URL   string `json:"url"`
+	Token string `json:"token"` // sent as "Authorization: Token <token>" when set.
+	Chain string `json:"chain"` // value of the chain tag, omitted when empty.
+	// Tags are added to every point.
+	Tags map[string]string `json:"tags"`
+}
+
+// influxTimeout bounds the write of one record.
+const influxTimeout = 10 * time.Second
+
+type influxSink struct {
+	client *http.Client
+	url    string
+	token  string
+	tags   string // sorted and escaped ",key=value" pairs.
+}
+
+func newInfluxSink(cfg InfluxSinkConfig) (*influxSink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("influx sink needs a url")
+	}
+
+	tags := make(map[string]string, len(cfg.Tags)+1)
+	for k, v := range cfg.Tags {
+		tags[k] = v
+	}
+	if cfg.Chain != "" {
+		tags["chain"] = cfg.Chain
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString("," + escapeTag(k) + "=" + escapeTag(tags[k]))
+	}
+
+	return &influxSink{client: &http.Client{Timeout: influxTimeout}, url: cfg.URL, token: cfg.Token, tags: sb.String()}, nil
+}
+
+func (s *influxSink) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	var buf bytes.Buffer
+	ts := strconv.FormatInt(r.Time.UnixNano(), 10)
+	tags := ",node=" + escapeTag(r.Node) + s.tags
+	if r.Chain != "" {
+		tags = ",chain=" + escapeTag(r.Chain) + tags
+	}
+
+	var fields []string
+	if r.Fetched(checks.FieldBest) {
+		fields = append(fields, fmt.Sprintf("best=%di", r.Best))
+	}
+	if r.Fetched(checks.FieldJustified) {
+		fields = append(fields, fmt.Sprintf("justified=%di", r.Justified))
+	}
+	if r.Fetched(checks.FieldFinalized) {
+		fields = append(fields, fmt.Sprintf("finalized=%di", r.Finalized))
+	}
+	if r.Fetched(checks.FieldBest | checks.FieldJustified) {
+		fields = append(fields, fmt.Sprintf("justified_lag=%di", int64(r.Best)-int64(r.Justified)))
+	}
+	if r.Fetched(checks.FieldBest | checks.FieldFinalized) {
+		fields = append(fields, fmt.Sprintf("finalized_lag=%di", int64(r.Best)-int64(r.Finalized)))
+	}
+	fields = append(fields, fmt.Sprintf("errors=%di", len(r.Errs())), fmt.Sprintf("timed_out=%t", r.TimedOut))
+	fmt.Fprintf(&buf, "finality%s %s %s\n", tags, strings.Join(fields, ","), ts)
+
+	for _, outcome := range outcomes {
+		fmt.Fprintf(&buf, "finality_check%s,check=%s,severity=%s failed=1i,error=%s %s\n",
+			tags, escapeTag(outcome.Name), outcome.Severity, quoteField(outcome.Err.Error()), ts)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), influxTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Token "+s.token)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("status code not 2xx: %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (s *influxSink) Close() error {
+	return nil
+}
+
+var (
+	tagEscaper   = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+	fieldEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, "\n", `\n`)
+)
+
+func escapeTag(s string) string {
+	return tagEscaper.Replace(s)
+}
+
+func quoteField(s string) string {
+	return `"` + fieldEscaper.Replace(s) + `"`
+}
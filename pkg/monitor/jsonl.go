@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// jsonlSink prints every poll cycle to stdout as a JSON line carrying the
+// heights, lags, errors and the result of every check, for jq and log shippers.
+// The other output goes to stderr, see ReserveStdout.
+type jsonlSink struct {
+	checks []checks.Check
+	fleet  []checks.FleetCheck
+	enc    *json.Encoder
+}
+
+// cycleLine is the JSON line of a poll cycle. Lags are omitted when the blocks
+// they are computed from were not fetched.
+type cycleLine struct {
+	Time         string      `json:"time"`
+	Node         string      `json:"node"`
+	Chain        string      `json:"chain,omitempty"`
+	Best         uint32      `json:"best"`
+	Justified    uint32      `json:"justified"`
+	Finalized    uint32      `json:"finalized"`
+	JustifiedLag *int64      `json:"justifiedLag,omitempty"`
+	FinalizedLag *int64      `json:"finalizedLag,omitempty"`
+	TimedOut     bool        `json:"timedOut"`
+	Errors       []string    `json:"errors,omitempty"`
+	Checks       []checkLine `json:"checks"`
+}
+
+type checkLine struct {
+	Name     string          `json:"name"`
+	Severity checks.Severity `json:"severity"`
+	Status   string          `json:"status"` // pass, fail or skip.
+	Error    string          `json:"error,omitempty"`
+}
+
+func newJSONLSink(cfg Config) *jsonlSink {
+	return &jsonlSink{checks: checks.New(cfg.Config), fleet: checks.NewFleetChecks(cfg.Config), enc: json.NewEncoder(resultsOut)}
+}
+
+func (s *jsonlSink) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	line := cycleLine{
+		Time:      r.Time.Format(time.RFC3339Nano),
+		Node:      r.Node,
+		Chain:     r.Chain,
+		Best:      r.Best,
+		Justified: r.Justified,
+		Finalized: r.Finalized,
+		TimedOut:  r.TimedOut,
+		Checks:    []checkLine{},
+	}
+	if r.Fetched(checks.FieldBest | checks.FieldJustified) {
+		lag := int64(r.Best) - int64(r.Justified)
+		line.JustifiedLag = &lag
+	}
+	if r.Fetched(checks.FieldBest | checks.FieldFinalized) {
+		lag := int64(r.Best) - int64(r.Finalized)
+		line.FinalizedLag = &lag
+	}
+	for _, err := range r.Errs() {
+		line.Errors = append(line.Errors, err.Error())
+	}
+
+	failed := make(map[string]checks.Outcome, len(outcomes))
+	for _, outcome := range outcomes {
+		failed[outcome.Name] = outcome
+	}
+	add := func(name string, severity checks.Severity, ran bool) {
+		check := checkLine{Name: name, Severity: severity, Status: "pass"}
+		if outcome, ok := failed[name]; ok {
+			check.Status, check.Error = "fail", outcome.Err.Error()
+		} else if !ran {
+			check.Status = "skip"
+		}
+		line.Checks = append(line.Checks, check)
+	}
+	for _, check := range s.checks {
+		add(check.Name, check.Severity, r.Fetched(check.Needs))
+	}
+	for _, check := range s.fleet {
+		add(check.Name, check.Severity, r.Fetched(checks.FieldJustified|checks.FieldFinalized))
+	}
+	return s.enc.Encode(line)
+}
+
+func (s *jsonlSink) Close() error {
+	// Syncing fails when the output is a pipe, which needs no flush.
+	resultsOut.Sync()
+	return nil
+}
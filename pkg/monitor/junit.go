@@ -0,0 +1,125 @@
+package monitor
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// junitReport collects the outcome of every check per node to write them as
+// a JUnit XML report: a test suite per node with a test case per check.
+type junitReport struct {
+	started time.Time
+	nodes   map[string]map[string]*junitCase // by node and check.
+}
+
+type junitCase struct {
+	runs     int
+	severity checks.Severity
+	failures []string
+}
+
+func newJUnitReport() *junitReport {
+	return &junitReport{started: time.Now(), nodes: make(map[string]map[string]*junitCase)}
+}
+
+func (j *junitReport) record(r checks.BlockResult, nodeChecks []checks.Check, fleetChecks []checks.FleetCheck, outcomes []checks.Outcome) {
+	cases, ok := j.nodes[r.Node]
+	if !ok {
+		cases = make(map[string]*junitCase)
+		j.nodes[r.Node] = cases
+	}
+	get := func(name string, severity checks.Severity) *junitCase {
+		c, ok := cases[name]
+		if !ok {
+			c = &junitCase{severity: severity}
+			cases[name] = c
+		}
+		return c
+	}
+
+	for _, check := range nodeChecks {
+		if r.Fetched(check.Needs) {
+			get(check.Name, check.Severity).runs++
+		}
+	}
+	for _, check := range fleetChecks {
+		if r.Fetched(checks.FieldJustified | checks.FieldFinalized) {
+			get(check.Name, check.Severity).runs++
+		}
+	}
+	for _, outcome := range outcomes {
+		c := get(outcome.Name, outcome.Severity)
+		c.failures = append(c.failures, fmt.Sprintf("%s: %v", r.Time.Format(time.RFC3339), outcome.Err))
+	}
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// write writes the report to path.
+func (j *junitReport) write(path string) error {
+	suites := junitTestSuites{Name: "justified", Time: time.Since(j.started).Seconds()}
+	for _, node := range sortedKeys(j.nodes) {
+		suite := junitTestSuite{Name: node, Timestamp: j.started.Format(time.RFC3339)}
+		for _, name := range sortedKeys(j.nodes[node]) {
+			c := j.nodes[node][name]
+			tc := junitTestCase{Name: name, ClassName: node}
+			switch {
+			case len(c.failures) > 0:
+				tc.Failure = &junitFailure{
+					Message: fmt.Sprintf("failed %d of %d poll cycles", len(c.failures), max(c.runs, len(c.failures))),
+					Type:    c.severity.String(),
+					Text:    strings.Join(c.failures, "\n"),
+				}
+				suite.Failures++
+			case c.runs == 0:
+				tc.Skipped = &struct{}{}
+				suite.Skipped++
+			}
+			suite.Tests++
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), append(data, '\n')...), 0o644)
+}
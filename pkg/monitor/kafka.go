@@ -0,0 +1,137 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// KafkaSinkConfig publishes every record to a Kafka topic, keyed by node.
+type KafkaSinkConfig struct {
+	Brokers []string `json:"brokers"`
+	Topic   string   `json:"topic"`
+	Format  string   `json:"format"` // json (default), avro or protobuf, see record.proto.
+}
+
+// recordSchema is the Avro schema of the records published by the Kafka sink.
+const recordSchema = `{
+	"type": "record",
+	"name": "Record",
+	"namespace": "justified",
+	"fields": [
+		{"name": "node", "type": "string"},
+		{"name": "time", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "best", "type": "long"},
+		{"name": "bestID", "type": "string"},
+		{"name": "justified", "type": "long"},
+		{"name": "justifiedID", "type": "string"},
+		{"name": "finalized", "type": "long"},
+		{"name": "finalizedID", "type": "string"},
+		{"name": "timedOut", "type": "boolean"},
+		{"name": "errors", "type": {"type": "array", "items": "string"}},
+		{"name": "outcomes", "type": {"type": "array", "items": {
+			"type": "record",
+			"name": "Outcome",
+			"fields": [
+				{"name": "node", "type": "string"},
+				{"name": "name", "type": "string"},
+				{"name": "severity", "type": "string"},
+				{"name": "error", "type": "string"}
+			]
+		}}}
+	]
+}`
+
+// kafkaWriteTimeout bounds the publication of one record.
+const kafkaWriteTimeout = 10 * time.Second
+
+type kafkaSink struct {
+	writer   *kafka.Writer
+	codec    *goavro.Codec // nil for JSON and protobuf payloads.
+	protobuf bool
+}
+
+func newKafkaSink(cfg KafkaSinkConfig) (*kafkaSink, error) {
+	if len(cfg.Brokers) == 0 || cfg.Topic == "" {
+		return nil, fmt.Errorf("kafka sink needs brokers and a topic")
+	}
+
+	s := &kafkaSink{writer: &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 50 * time.Millisecond,
+	}}
+	switch cfg.Format {
+	case "", "json":
+	case "avro":
+		codec, err := goavro.NewCodec(recordSchema)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing avro schema: %w", err)
+		}
+		s.codec = codec
+	case "protobuf":
+		s.protobuf = true
+	default:
+		return nil, fmt.Errorf("unknown kafka format %q", cfg.Format)
+	}
+	return s, nil
+}
+
+func (s *kafkaSink) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	value, err := s.encode(newRecord(r, outcomes))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), kafkaWriteTimeout)
+	defer cancel()
+	return s.writer.WriteMessages(ctx, kafka.Message{Key: []byte(r.Node), Value: value})
+}
+
+func (s *kafkaSink) encode(rec Record) ([]byte, error) {
+	if s.protobuf {
+		return marshalRecordProto(rec), nil
+	}
+	if s.codec == nil {
+		return json.Marshal(rec)
+	}
+
+	errs := make([]any, 0, len(rec.Errors))
+	for _, err := range rec.Errors {
+		errs = append(errs, err)
+	}
+	outcomes := make([]any, 0, len(rec.Outcomes))
+	for _, outcome := range rec.Outcomes {
+		outcomes = append(outcomes, map[string]any{
+			"node":     outcome.Node,
+			"name":     outcome.Name,
+			"severity": outcome.Severity.String(),
+			"error":    outcome.Error,
+		})
+	}
+	r := rec.Result
+	return s.codec.BinaryFromNative(nil, map[string]any{
+		"node":        r.Node,
+		"time":        r.Time,
+		"best":        int64(r.Best),
+		"bestID":      r.BestID,
+		"justified":   int64(r.Justified),
+		"justifiedID": r.JustifiedID,
+		"finalized":   int64(r.Finalized),
+		"finalizedID": r.FinalizedID,
+		"timedOut":    r.TimedOut,
+		"errors":      errs,
+		"outcomes":    outcomes,
+	})
+}
+
+func (s *kafkaSink) Close() error {
+	return s.writer.Close()
+}
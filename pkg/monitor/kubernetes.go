@@ -0,0 +1,131 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serviceAccountDir holds the credentials of the service account of a pod.
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount/"
+
+// KubernetesDiscovery discovers the nodes from the running pods matching a
+// label selector, through the Kubernetes API with the service account of the
+// pod the monitor runs in. The pods are listed again every interval, adding
+// and removing nodes as pods come and go.
+type KubernetesDiscovery struct {
+	Namespace string   `json:"namespace"` // defaults to the namespace of the monitor.
+	Selector  string   `json:"selector"`  // label selector, such as app=thor.
+	Port      int      `json:"port"`      // of the API of the nodes in the pods.
+	Scheme    string   `json:"scheme"`    // http or https, defaults to http.
+	Interval  Duration `json:"interval"`  // defaults to 30s.
+	// NodeConnection configures the connections to every discovered node.
+	NodeConnection
+}
+
+func (d *KubernetesDiscovery) validate() error {
+	if d.Selector == "" {
+		return errors.New("selector is required")
+	}
+	if d.Port <= 0 || d.Port > 65535 {
+		return fmt.Errorf("invalid port %d", d.Port)
+	}
+	return validateDiscoveryScheme(d.Scheme)
+}
+
+// discover lists the running pods matching the selector, naming every node
+// after its pod. Terminating pods and pods without an IP are left out.
+func (d *KubernetesDiscovery) discover(ctx context.Context) ([]NodeConfig, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("not running in a Kubernetes cluster")
+	}
+	token, err := os.ReadFile(serviceAccountDir + "token")
+	if err != nil {
+		return nil, fmt.Errorf("error reading service account token: %w", err)
+	}
+	namespace := d.Namespace
+	if namespace == "" {
+		data, err := os.ReadFile(serviceAccountDir + "namespace")
+		if err != nil {
+			return nil, fmt.Errorf("error reading service account namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+
+	u := "https://" + net.JoinHostPort(host, port) + "/api/v1/namespaces/" + url.PathEscape(namespace) +
+		"/pods?labelSelector=" + url.QueryEscape(d.Selector)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating pods request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+	resp, err := kubernetesClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error listing pods: status %s", resp.Status)
+	}
+
+	var pods struct {
+		Items []struct {
+			Metadata struct {
+				Name              string     `json:"name"`
+				DeletionTimestamp *time.Time `json:"deletionTimestamp"`
+			} `json:"metadata"`
+			Status struct {
+				Phase string `json:"phase"`
+				PodIP string `json:"podIP"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&pods); err != nil {
+		return nil, fmt.Errorf("error decoding pods: %w", err)
+	}
+
+	var nodes []NodeConfig
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != "Running" || pod.Status.PodIP == "" || pod.Metadata.DeletionTimestamp != nil {
+			continue
+		}
+		addr := net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(d.Port))
+		nodes = append(nodes, NodeConfig{Name: pod.Metadata.Name, URL: discoveryURL(d.Scheme, addr), NodeConnection: d.NodeConnection})
+	}
+	slices.SortFunc(nodes, func(a, b NodeConfig) int { return strings.Compare(a.Name, b.Name) })
+	return nodes, nil
+}
+
+// kubernetesClient requests the API server, verified with the CA of the
+// service account, or with the system pool when the pod has none.
+var kubernetesClient = sync.OnceValue(func() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if ca, err := os.ReadFile(serviceAccountDir + "ca.crt"); err == nil {
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(ca)
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return &http.Client{Transport: transport, Timeout: discoveryTimeout}
+})
+
+func (d *KubernetesDiscovery) interval() time.Duration {
+	return firstNonZero(d.Interval.Duration, defaultDiscoveryInterval)
+}
+
+func (d *KubernetesDiscovery) String() string {
+	return "pods " + d.Selector
+}
@@ -0,0 +1,226 @@
+package monitor
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaintenanceWindow is a recurring period during which the check failures of
+// some nodes are recorded but not alerted, such as planned restarts.
+type MaintenanceWindow struct {
+	Schedule Schedule `json:"schedule"` // when the window starts, in local time.
+	Duration Duration `json:"duration"`
+	Nodes    []string `json:"nodes"` // in maintenance, every node when empty.
+}
+
+// covers reports whether the window covers node at t.
+func (w MaintenanceWindow) covers(node string, t time.Time) bool {
+	if len(w.Nodes) > 0 && !slices.Contains(w.Nodes, node) {
+		return false
+	}
+	// The window covers t when it started at most Duration before t.
+	start := t.Truncate(time.Minute)
+	for ; t.Sub(start) < w.Duration.Duration; start = start.Add(-time.Minute) {
+		if w.Schedule.matches(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// Schedule is a cron expression of five fields: minute, hour, day of the
+// month, month and day of the week. A field is *, or a comma separated list
+// of values and ranges such as 1-5, each optionally followed by a /step.
+type Schedule struct {
+	spec   string
+	fields [5]uint64 // bit set of the values matching each field.
+	// A day matches either day field when the other one is restricted too.
+	anyDay bool
+}
+
+// scheduleFields are the bounds of the fields of a schedule.
+var scheduleFields = [5]struct{ min, max int }{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+func (s Schedule) String() string {
+	return s.spec
+}
+
+func (s Schedule) MarshalText() ([]byte, error) {
+	return []byte(s.spec), nil
+}
+
+func (s *Schedule) UnmarshalText(text []byte) error {
+	spec := string(text)
+	fields := strings.Fields(spec)
+	if len(fields) != len(scheduleFields) {
+		return fmt.Errorf("invalid schedule %q: expected 5 fields", spec)
+	}
+	parsed := Schedule{spec: spec}
+	for i, field := range fields {
+		bits, err := parseScheduleField(field, scheduleFields[i].min, scheduleFields[i].max)
+		if err != nil {
+			return fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		parsed.fields[i] = bits
+	}
+	// Sunday is both 0 and 7.
+	if parsed.fields[4]&(1<<7) != 0 {
+		parsed.fields[4] |= 1
+	}
+	parsed.anyDay = fields[2] != "*" && fields[4] != "*"
+	*s = parsed
+	return nil
+}
+
+func parseScheduleField(field string, lo, hi int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		spec, stepSpec, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepSpec); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepSpec)
+			}
+		}
+		first, last := lo, hi
+		if spec != "*" {
+			from, to, isRange := strings.Cut(spec, "-")
+			var err error
+			if first, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", from)
+			}
+			last = first
+			if isRange {
+				if last, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid value %q", to)
+				}
+			} else if hasStep {
+				last = hi
+			}
+		}
+		if first < lo || last > hi || first > last {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, lo, hi)
+		}
+		for v := first; v <= last; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (s Schedule) matches(t time.Time) bool {
+	has := func(field, v int) bool { return s.fields[field]&(1<<v) != 0 }
+	dom, dow := has(2, t.Day()), has(4, int(t.Weekday()))
+	day := dom && dow
+	if s.anyDay {
+		day = dom || dow
+	}
+	return has(0, t.Minute()) && has(1, t.Hour()) && has(3, int(t.Month())) && day
+}
+
+// maintenance tells whether a node is in maintenance, from the configured
+// windows or the ones started through the maintenance API.
+type maintenance struct {
+	mu      sync.Mutex
+	windows []MaintenanceWindow
+	until   map[string]time.Time // end of the windows started through the API by node, "" for every node.
+}
+
+func newMaintenance() *maintenance {
+	return &maintenance{until: make(map[string]time.Time)}
+}
+
+func (m *maintenance) setWindows(windows []MaintenanceWindow) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.windows = windows
+}
+
+// active reports whether node is in maintenance at t.
+func (m *maintenance) active(node string, t time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t.Before(m.until[""]) || t.Before(m.until[node]) {
+		return true
+	}
+	for _, w := range m.windows {
+		if w.covers(node, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// handler serves the maintenance API: GET lists the end of the windows
+// started through it by node, * for every node, POST starts one for
+// ?duration=, of ?node= or every node, and DELETE ends the one of ?node= or
+// of every node. POST and DELETE require token as a bearer token, or come
+// from localhost when token is empty: they silence the alerts.
+func (m *maintenance) handler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && !authorized(req, token) {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		node := req.URL.Query().Get("node")
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			d, err := time.ParseDuration(req.URL.Query().Get("duration"))
+			if err != nil || d <= 0 {
+				http.Error(w, "invalid duration", http.StatusBadRequest)
+				return
+			}
+			m.until[node] = time.Now().Add(d)
+			fmt.Printf("Maintenance of %s until %s, alerts suppressed\n", maintenanceTarget(node), m.until[node].Format(time.RFC3339))
+		case http.MethodDelete:
+			if _, ok := m.until[node]; ok {
+				delete(m.until, node)
+				fmt.Printf("Maintenance of %s ended\n", maintenanceTarget(node))
+			}
+		default:
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		now := time.Now()
+		windows := make(map[string]time.Time, len(m.until))
+		for node, until := range m.until {
+			if now.Before(until) {
+				windows[firstNonZero(node, "*")] = until
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(windows)
+	})
+}
+
+func maintenanceTarget(node string) string {
+	if node == "" {
+		return "every node"
+	}
+	return node
+}
+
+// authorized reports whether req carries token as a bearer token, or comes
+// from a loopback address when token is empty.
+func authorized(req *http.Request, token string) bool {
+	if token == "" {
+		host, _, err := net.SplitHostPort(req.RemoteAddr)
+		ip := net.ParseIP(host)
+		return err == nil && ip != nil && ip.IsLoopback()
+	}
+	bearer, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1
+}
@@ -0,0 +1,125 @@
+package monitor
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScheduleUnmarshalText(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+	}
+	for _, spec := range tests {
+		var s Schedule
+		if err := s.UnmarshalText([]byte(spec)); err == nil {
+			t.Errorf("schedule %q parsed", spec)
+		}
+	}
+}
+
+func TestScheduleMatches(t *testing.T) {
+	// March 3, 2024 is a Sunday.
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.Local)
+	}
+	tests := []struct {
+		spec    string
+		t       time.Time
+		matches bool
+	}{
+		{spec: "* * * * *", t: at(3, 3, 12, 34), matches: true},
+		{spec: "30 2 * * *", t: at(3, 3, 2, 30), matches: true},
+		{spec: "30 2 * * *", t: at(3, 3, 2, 31), matches: false},
+		{spec: "*/15 * * * *", t: at(3, 3, 7, 45), matches: true},
+		{spec: "*/15 * * * *", t: at(3, 3, 7, 50), matches: false},
+		{spec: "10/20 * * * *", t: at(3, 3, 7, 50), matches: true},
+		{spec: "0 9-17/4 * * *", t: at(3, 3, 13, 0), matches: true},
+		{spec: "0 9-17/4 * * *", t: at(3, 3, 15, 0), matches: false},
+		{spec: "0,30 * * * *", t: at(3, 3, 1, 30), matches: true},
+		{spec: "0 0 * 3 *", t: at(4, 3, 0, 0), matches: false},
+		// Sunday is both 0 and 7.
+		{spec: "0 4 * * 0", t: at(3, 3, 4, 0), matches: true},
+		{spec: "0 4 * * 7", t: at(3, 3, 4, 0), matches: true},
+		{spec: "0 4 * * 1-5", t: at(3, 3, 4, 0), matches: false},
+		// either day field matches when both are restricted.
+		{spec: "0 4 1 * 1", t: at(3, 3, 4, 0), matches: false},
+		{spec: "0 4 3 * 1", t: at(3, 3, 4, 0), matches: true},
+		{spec: "0 4 1 * 0", t: at(3, 3, 4, 0), matches: true},
+		{spec: "0 4 3 * *", t: at(3, 3, 4, 0), matches: true},
+	}
+	for _, tt := range tests {
+		var s Schedule
+		if err := s.UnmarshalText([]byte(tt.spec)); err != nil {
+			t.Fatal(err)
+		}
+		if got := s.matches(tt.t); got != tt.matches {
+			t.Errorf("schedule %q matches %s: %t, want %t", tt.spec, tt.t.Format(time.DateTime), got, tt.matches)
+		}
+	}
+}
+
+func TestMaintenanceWindowCovers(t *testing.T) {
+	var schedule Schedule
+	if err := schedule.UnmarshalText([]byte("0 3 * * *")); err != nil {
+		t.Fatal(err)
+	}
+	w := MaintenanceWindow{Schedule: schedule, Duration: Duration{30 * time.Minute}, Nodes: []string{"a"}}
+	start := time.Date(2024, 3, 3, 3, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		node   string
+		t      time.Time
+		covers bool
+	}{
+		{node: "a", t: start, covers: true},
+		{node: "a", t: start.Add(29*time.Minute + 59*time.Second), covers: true},
+		{node: "a", t: start.Add(30 * time.Minute), covers: false},
+		{node: "a", t: start.Add(-time.Second), covers: false},
+		{node: "b", t: start, covers: false},
+	}
+	for _, tt := range tests {
+		if got := w.covers(tt.node, tt.t); got != tt.covers {
+			t.Errorf("window covers %s at %s: %t, want %t", tt.node, tt.t.Format(time.TimeOnly), got, tt.covers)
+		}
+	}
+}
+
+func TestAuthorized(t *testing.T) {
+	tests := []struct {
+		name   string
+		remote string
+		header string
+		token  string
+		want   bool
+	}{
+		{name: "localhost", remote: "127.0.0.1:1234", want: true},
+		{name: "localhost ipv6", remote: "[::1]:1234", want: true},
+		{name: "remote", remote: "192.0.2.1:1234", want: false},
+		{name: "token", remote: "192.0.2.1:1234", header: "Bearer secret", token: "secret", want: true},
+		{name: "wrong token", remote: "192.0.2.1:1234", header: "Bearer public", token: "secret", want: false},
+		{name: "no token", remote: "127.0.0.1:1234", token: "secret", want: false},
+		{name: "not a bearer token", remote: "192.0.2.1:1234", header: "secret", token: "secret", want: false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/maintenance", nil)
+		req.RemoteAddr = tt.remote
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		if got := authorized(req, tt.token); got != tt.want {
+			t.Errorf("%s: authorized %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// metrics exposes the block results and check outcomes in the Prometheus format.
+type metrics struct {
+	height          *prometheus.GaugeVec
+	checkFailures   *prometheus.CounterVec
+	finalityLatency *prometheus.GaugeVec
+	finalityBlocks  *prometheus.GaugeVec
+	promotion       *prometheus.GaugeVec
+	activeProposers *prometheus.GaugeVec
+	cycleTimeouts   *prometheus.CounterVec
+	missedSlots     *prometheus.GaugeVec
+	epochMissed     *prometheus.GaugeVec
+	degraded        *prometheus.GaugeVec
+	serving         *prometheus.GaugeVec
+	lag             *prometheus.GaugeVec
+	peers           *prometheus.GaugeVec
+	syncing         *prometheus.GaugeVec
+	epochProgress   *prometheus.GaugeVec
+	checkpointIn    *prometheus.GaugeVec
+	checkpointETA   *prometheus.GaugeVec
+	finalityGaps    *prometheus.GaugeVec
+	bftRound        *prometheus.GaugeVec
+	spread          *prometheus.GaugeVec
+	propagation     *prometheus.GaugeVec
+
+	// degradedEndpoints are the endpoints reported as degraded by node, to
+	// clear their gauge once they recover.
+	degradedEndpoints map[string][]string
+	// servingEndpoints are the endpoints serving the failover nodes, to clear
+	// their gauge once another one serves.
+	servingEndpoints map[string]string
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	m := &metrics{
+		height: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_block_height",
+			Help: "Height of the best, justified and finalized blocks.",
+		}, []string{"node", "block"}),
+		checkFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "justified_check_failures_total",
+			Help: "Number of failed checks.",
+		}, []string{"node", "check", "severity"}),
+		finalityLatency: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_finality_latency_seconds",
+			Help: "Wall-clock time from production to finalization of recent checkpoints.",
+		}, []string{"node", "stat"}),
+		finalityBlocks: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_finality_latency_blocks",
+			Help: "Blocks produced between a recent checkpoint and its finalization.",
+		}, []string{"node", "stat"}),
+		promotion: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_checkpoint_promotion_seconds",
+			Help: "Time recent checkpoints took from justified to finalized.",
+		}, []string{"node", "stat"}),
+		activeProposers: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_active_proposers",
+			Help: "Active proposers registered in the authority contract.",
+		}, []string{"node"}),
+		cycleTimeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "justified_cycle_timeouts_total",
+			Help: "Number of poll cycles cancelled at their deadline.",
+		}, []string{"node"}),
+		missedSlots: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_missed_slots",
+			Help: "Slots without a block since the monitor started.",
+		}, []string{"node"}),
+		epochMissed: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_epoch_missed_slots",
+			Help: "Slots without a block in the current and the last completed epoch.",
+		}, []string{"node", "epoch"}),
+		degraded: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_endpoint_degraded",
+			Help: "Whether the circuit breaker of an endpoint is open.",
+		}, []string{"node", "endpoint"}),
+		serving: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_endpoint_serving",
+			Help: "Whether an endpoint of a failover node answered its last request.",
+		}, []string{"node", "endpoint"}),
+		lag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_node_lag_blocks",
+			Help: "Blocks the best, justified and finalized blocks are behind the most advanced other node.",
+		}, []string{"node", "block"}),
+		peers: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_peers",
+			Help: "Peers the node is connected to.",
+		}, []string{"node"}),
+		syncing: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_node_syncing",
+			Help: "Whether the node is catching up with the chain, its finality range checks suspended.",
+		}, []string{"node"}),
+		epochProgress: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_epoch_progress_ratio",
+			Help: "Fraction of the checkpoint epoch being voted produced so far.",
+		}, []string{"node"}),
+		checkpointIn: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_checkpoint_remaining_blocks",
+			Help: "Blocks still to be produced before the checkpoint being voted is justified and finalized.",
+		}, []string{"node", "block"}),
+		checkpointETA: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_checkpoint_eta_seconds",
+			Help: "Estimated time at the block interval before the checkpoint being voted is justified and finalized.",
+		}, []string{"node", "block"}),
+		finalityGaps: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_finality_gap_blocks",
+			Help: "Blocks between the best block and the justified and finalized ones over the recent poll cycles.",
+		}, []string{"node", "block", "stat"}),
+		bftRound: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_bft_round",
+			Help: "Round, view and quality of the bft state reported by the debug endpoint of the node.",
+		}, []string{"node", "counter"}),
+		spread: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_propagation_spread_seconds",
+			Help: "Time between the first and the last node reporting recent best block heights.",
+		}, []string{"stat"}),
+		propagation: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "justified_propagation_delay_seconds",
+			Help: "Time between the first node and this one reporting recent best block heights.",
+		}, []string{"node", "stat"}),
+		degradedEndpoints: make(map[string][]string),
+		servingEndpoints:  make(map[string]string),
+	}
+	reg.MustRegister(m.height, m.checkFailures, m.finalityLatency, m.finalityBlocks, m.promotion, m.activeProposers, m.cycleTimeouts, m.missedSlots, m.epochMissed, m.degraded, m.serving, m.lag, m.peers, m.syncing,
+		m.epochProgress, m.checkpointIn, m.checkpointETA, m.finalityGaps, m.bftRound, m.spread, m.propagation)
+	return m
+}
+
+// Write updates the metrics with a block result and the checks it failed.
+func (m *metrics) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	for _, outcome := range outcomes {
+		m.checkFailures.WithLabelValues(outcome.Node, outcome.Name, outcome.Severity.String()).Inc()
+	}
+	if r.TimedOut {
+		m.cycleTimeouts.WithLabelValues(r.Node).Inc()
+	}
+	for _, endpoint := range m.degradedEndpoints[r.Node] {
+		m.degraded.WithLabelValues(r.Node, endpoint).Set(0)
+	}
+	for _, endpoint := range r.Degraded {
+		m.degraded.WithLabelValues(r.Node, endpoint).Set(1)
+	}
+	m.degradedEndpoints[r.Node] = r.Degraded
+	if previous, ok := m.servingEndpoints[r.Node]; ok && previous != r.Serving {
+		m.serving.WithLabelValues(r.Node, previous).Set(0)
+	}
+	if r.Serving != "" {
+		m.serving.WithLabelValues(r.Node, r.Serving).Set(1)
+		m.servingEndpoints[r.Node] = r.Serving
+	}
+
+	if r.Fetched(checks.FieldBest) {
+		m.height.WithLabelValues(r.Node, "best").Set(float64(r.Best))
+		syncing := 0.0
+		if r.Syncing {
+			syncing = 1
+		}
+		m.syncing.WithLabelValues(r.Node).Set(syncing)
+		if e := r.Epoch; e != nil {
+			m.epochProgress.WithLabelValues(r.Node).Set(e.Fraction())
+			m.checkpointIn.WithLabelValues(r.Node, "justified").Set(float64(e.JustifiedIn))
+			m.checkpointIn.WithLabelValues(r.Node, "finalized").Set(float64(e.FinalizedIn))
+			m.checkpointETA.WithLabelValues(r.Node, "justified").Set(e.JustifiedETA.Seconds())
+			m.checkpointETA.WithLabelValues(r.Node, "finalized").Set(e.FinalizedETA.Seconds())
+		}
+
+		m.missedSlots.WithLabelValues(r.Node).Set(float64(r.Slots.Missed))
+		m.epochMissed.WithLabelValues(r.Node, "current").Set(float64(r.Slots.Current.Missed))
+		if r.Slots.Previous != nil {
+			m.epochMissed.WithLabelValues(r.Node, "previous").Set(float64(r.Slots.Previous.Missed))
+		}
+	}
+	if r.Fetched(checks.FieldJustified) {
+		m.height.WithLabelValues(r.Node, "justified").Set(float64(r.Justified))
+	}
+	if r.Fetched(checks.FieldFinalized) {
+		m.height.WithLabelValues(r.Node, "finalized").Set(float64(r.Finalized))
+	}
+
+	if r.Lag != nil {
+		m.lag.WithLabelValues(r.Node, "best").Set(float64(r.Lag.Best))
+		m.lag.WithLabelValues(r.Node, "justified").Set(float64(r.Lag.Justified))
+		m.lag.WithLabelValues(r.Node, "finalized").Set(float64(r.Lag.Finalized))
+	}
+
+	if r.Authority != nil {
+		m.activeProposers.WithLabelValues(r.Node).Set(float64(r.Authority.Active))
+	}
+	if r.Peers != nil {
+		m.peers.WithLabelValues(r.Node).Set(float64(*r.Peers))
+	}
+	if b := r.BFT; b != nil {
+		m.bftRound.WithLabelValues(r.Node, "round").Set(float64(b.Round))
+		m.bftRound.WithLabelValues(r.Node, "view").Set(float64(b.View))
+		m.bftRound.WithLabelValues(r.Node, "quality").Set(float64(b.Quality))
+	}
+
+	setStats(m.finalityLatency, r.Latency.Seconds, r.Node)
+	setStats(m.finalityBlocks, r.Latency.Blocks, r.Node)
+	setStats(m.promotion, r.Latency.Promotion, r.Node)
+	setStats(m.finalityGaps, r.Gaps.Justified, r.Node, "justified")
+	setStats(m.finalityGaps, r.Gaps.Finalized, r.Node, "finalized")
+	setStats(m.spread, r.Propagation.Spread)
+	setStats(m.propagation, r.Propagation.Delay, r.Node)
+	return nil
+}
+
+func (m *metrics) Close() error {
+	return nil
+}
+
+// remove deletes the series of node, no longer monitored.
+func (m *metrics) remove(node string) {
+	labels := prometheus.Labels{"node": node}
+	for _, vec := range []*prometheus.MetricVec{m.height.MetricVec, m.checkFailures.MetricVec, m.finalityLatency.MetricVec, m.finalityBlocks.MetricVec,
+		m.promotion.MetricVec, m.activeProposers.MetricVec, m.cycleTimeouts.MetricVec, m.missedSlots.MetricVec, m.epochMissed.MetricVec, m.degraded.MetricVec,
+		m.serving.MetricVec, m.lag.MetricVec, m.peers.MetricVec, m.syncing.MetricVec, m.epochProgress.MetricVec, m.checkpointIn.MetricVec, m.checkpointETA.MetricVec,
+		m.finalityGaps.MetricVec, m.bftRound.MetricVec, m.propagation.MetricVec} {
+		vec.DeletePartialMatch(labels)
+	}
+	delete(m.degradedEndpoints, node)
+	delete(m.servingEndpoints, node)
+}
+
+// setStats sets the gauges of the statistics labeled with labels, followed
+// by the name of the statistic.
+func setStats(g *prometheus.GaugeVec, s checks.Stats, labels ...string) {
+	if s.Count == 0 {
+		return
+	}
+	for stat, v := range map[string]float64{"min": s.Min, "avg": s.Avg, "median": s.Median, "p95": s.P95} {
+		g.WithLabelValues(append(labels, stat)...).Set(v)
+	}
+}
+
+// chainMetrics writes the results of every chain to metrics of its own,
+// labeled with the name of the chain when several chains are monitored.
+type chainMetrics struct {
+	reg prometheus.Registerer
+
+	mu     sync.Mutex // the dispatchers of a reload may write concurrently.
+	chains map[string]*metrics
+}
+
+func newChainMetrics(reg prometheus.Registerer) *chainMetrics {
+	return &chainMetrics{reg: reg, chains: make(map[string]*metrics)}
+}
+
+func (c *chainMetrics) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.chains[r.Chain]
+	if !ok {
+		reg := c.reg
+		if r.Chain != "" {
+			reg = prometheus.WrapRegistererWith(prometheus.Labels{"chain": r.Chain}, reg)
+		}
+		m = newMetrics(reg)
+		c.chains[r.Chain] = m
+	}
+	return m.Write(r, outcomes)
+}
+
+// remove deletes the series of node of chain, no longer monitored.
+func (c *chainMetrics) remove(chain, node string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if m, ok := c.chains[chain]; ok {
+		m.remove(node)
+	}
+}
+
+func (c *chainMetrics) Close() error {
+	return nil
+}
+
+// serveMetrics exposes the metrics of reg at addr/metrics, along with the
+// liveness and readiness probes at /healthz and /readyz and the maintenance
+// API at /maintenance, guarded by maintenanceToken.
+func serveMetrics(addr string, reg *prometheus.Registry, h *health, maintenance *maintenance, maintenanceToken string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	mux.Handle("/healthz", h.handler(false))
+	mux.Handle("/readyz", h.handler(true))
+	mux.Handle("/maintenance", maintenance.handler(maintenanceToken))
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		fmt.Println("Error serving metrics: ", err)
+	}
+}
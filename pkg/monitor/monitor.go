@@ -0,0 +1,749 @@
+// Package monitor runs the checks against the configured nodes and reports
+// their results to the configured sinks.
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/paologalligit/justified/pkg/checks"
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// configWatchInterval is how often the config file is checked for changes.
+const configWatchInterval = 5 * time.Second
+
+// Monitor runs a producer per node of every chain and checks their results.
+// It reloads the config file on SIGHUP or when the file changes.
+type Monitor struct {
+	configPath string
+	network    string // preset overriding the network of the config file.
+	cfg        Config // the top level, holding the process-wide settings.
+	chains     map[string]*chain
+
+	queue      *resultQueue // of the results of every producer.
+	supervisor *supervisor
+	discovered chan discoveredNodes
+	nodes      map[string]*runningNode
+	clients    *nodeClients // of nodes, for the forensics.
+	checks     map[string][]checks.Check
+	budget     *errorBudget
+	health     *health
+	metrics    *chainMetrics
+	console    Sink // prints the results to stdout, kept across reloads.
+	sinks      *dispatcher
+	systemd    *systemdNotifier
+	state      *stateRecorder
+	summary    *summary
+	soak       *soakTest    // nil unless the run is bounded in time.
+	junit      *junitReport // nil unless a JUnit report is requested.
+
+	extraSinks map[string]Sink // the sinks of the options, kept across reloads.
+	alerters   map[string]Alerter
+	hooks      map[string]CheckpointHook
+	// maintenance outlives the alert sinks, so that the windows started
+	// through the API survive a reload.
+	maintenance *maintenance
+	finality    *finalityStore // nil unless a finality file is configured.
+	snapshots   *snapshotter   // nil unless a snapshot file is configured.
+	done        chan struct{}  // closed by Stop.
+	stopOnce    sync.Once
+
+	reports *reportScheduler // nil unless reports are scheduled.
+
+	log       client.Logger  // prints the messages of the run.
+	producers sync.WaitGroup // polling the nodes, waited for before Run returns.
+
+	maxCycles int  // poll cycles of every node after which the run stops, unbounded when 0.
+	dryRun    bool // keep running when a node exhausts the error budget.
+}
+
+// chain is a monitored chain, isolated from the others: its nodes are polled
+// and checked with its own settings and only compared with each other.
+type chain struct {
+	cfg      Config
+	detected checks.ChainParams
+	fleet    *checks.Fleet
+
+	discovery  *discovery   // nil unless the nodes are discovered.
+	discovered []NodeConfig // the nodes found by the last discovery.
+}
+
+// nodes returns the configured nodes of the chain followed by the discovered
+// ones not configured.
+func (c *chain) nodes() []NodeConfig {
+	nodes := slices.Clone(c.cfg.Nodes)
+	for _, node := range c.discovered {
+		configured := slices.ContainsFunc(c.cfg.Nodes, func(n NodeConfig) bool { return n.Name == node.Name })
+		if !configured {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// runningNode is a node whose producer is running.
+type runningNode struct {
+	cfg   NodeConfig
+	chain string
+	stop  context.CancelFunc
+}
+
+// Options are the command line settings of a run.
+type Options struct {
+	Network   string // preset the config is reloaded with, see LoadConfig.
+	Output    string // format the results are printed to stdout in.
+	Verbosity int    // of the text output, from verbosityQuiet to verbosityDebug.
+	MaxCycles int    // poll cycles of every node after which the run stops, unbounded when 0.
+	DryRun    bool   // keep running when a node exhausts the error budget.
+
+	// Duration bounds the run to a soak test judged by Verdict, unbounded when 0.
+	Duration time.Duration
+	// JUnit records the check results for WriteJUnit.
+	JUnit bool
+	// Sinks are written the results on top of the configured sinks, by name.
+	// They are closed by the caller.
+	Sinks map[string]Sink
+	// Alerters are sent the alerts of the failed checks, by name.
+	Alerters map[string]Alerter
+	// Hooks are fired when a checkpoint is newly justified or finalized, by name.
+	Hooks map[string]CheckpointHook
+	// Logger prints the messages of the run, to stdout when nil.
+	Logger client.Logger
+}
+
+// New returns a monitor of clients configured by cfg, loaded from configPath.
+func New(configPath string, cfg Config, clients []*client.Client, detected checks.ChainParams, opts Options) (*Monitor, error) {
+	return NewChains(configPath, cfg, []Chain{{Config: cfg, Clients: clients, Detected: detected}}, opts)
+}
+
+// NewChains returns a monitor of chains, with the process-wide settings of
+// cfg, loaded from configPath.
+func NewChains(configPath string, cfg Config, chains []Chain, opts Options) (*Monitor, error) {
+	m := &Monitor{
+		configPath:  configPath,
+		network:     opts.Network,
+		cfg:         cfg,
+		chains:      make(map[string]*chain, len(chains)),
+		discovered:  make(chan discoveredNodes),
+		nodes:       make(map[string]*runningNode),
+		clients:     newNodeClients(),
+		checks:      make(map[string][]checks.Check),
+		budget:      newErrorBudget(cfg.ErrorBudget),
+		health:      newHealth(nil, 0),
+		state:       newStateRecorder(cfg.StateDump.History),
+		summary:     newSummary(),
+		extraSinks:  opts.Sinks,
+		alerters:    opts.Alerters,
+		hooks:       opts.Hooks,
+		maintenance: newMaintenance(),
+		done:        make(chan struct{}),
+		maxCycles:   opts.MaxCycles,
+		dryRun:      opts.DryRun,
+		log:         opts.Logger,
+	}
+	if m.log == nil {
+		m.log = logLine
+	}
+	if opts.Duration > 0 {
+		m.soak = newSoakTest(opts.Duration)
+	}
+	if opts.JUnit {
+		m.junit = newJUnitReport()
+	}
+
+	for _, c := range chains {
+		m.chains[c.Config.Chain] = &chain{cfg: c.Config, detected: c.Detected, fleet: checks.NewFleet(c.Config.Config)}
+	}
+	m.health.setStaleAfter(m.staleAfter())
+
+	queue, err := newResultQueue(cfg.Queue)
+	if err != nil {
+		return nil, err
+	}
+	m.queue = queue
+	m.supervisor = newSupervisor(cfg.Supervisor)
+	m.supervisor.log = m.log
+	reg := prometheus.NewRegistry()
+	m.metrics = newChainMetrics(reg)
+	m.queue.register(reg)
+	m.supervisor.register(reg)
+	if cfg.MetricsAddr != "" {
+		go serveMetrics(cfg.MetricsAddr, reg, m.health, m.maintenance, cfg.MaintenanceToken)
+	}
+
+	if cfg.FinalityFile != "" {
+		finality, err := loadFinalityStore(cfg.FinalityFile)
+		if err != nil {
+			return nil, err
+		}
+		m.finality = finality
+	}
+	if cfg.Snapshot.File != "" {
+		snapshots, err := loadSnapshotter(cfg.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+		m.snapshots = snapshots
+	}
+	if cfg.Reports != nil {
+		reports, err := newReportScheduler(cfg)
+		if err != nil {
+			return nil, err
+		}
+		reports.log = m.log
+		m.reports = reports
+	}
+
+	console, err := newConsoleSink(opts.Output, opts.Verbosity, cfg)
+	if err != nil {
+		return nil, err
+	}
+	m.console = console
+
+	sinks, err := m.newSinks(cfg)
+	if err != nil {
+		return nil, err
+	}
+	m.sinks = sinks
+
+	if m.systemd, err = newSystemdNotifier(); err != nil {
+		m.log.Printf("Error connecting to systemd notify socket: %v", err)
+	}
+
+	for _, c := range chains {
+		for i, node := range c.Config.Nodes {
+			m.start(c.Config.Chain, node, c.Clients[i])
+		}
+		m.startDiscovery(c.Config.Chain, m.chains[c.Config.Chain])
+	}
+	return m, nil
+}
+
+// staleAfter is how long a poll loop may go without a result before it is
+// considered wedged: a few missed cycles of the slowest chain.
+func (m *Monitor) staleAfter() time.Duration {
+	var stale time.Duration
+	for _, c := range m.chains {
+		interval := time.Duration(c.cfg.BlockInterval) * time.Second
+		stale = max(stale, firstNonZero(c.cfg.HealthStaleAfter.Duration, 3*interval+firstNonZero(c.cfg.CycleTimeout.Duration, interval)))
+	}
+	return stale
+}
+
+func (m *Monitor) newSinks(cfg Config) (*dispatcher, error) {
+	m.maintenance.setWindows(cfg.Sinks.Alerts.Maintenance)
+	sinks, err := newSinks(cfg.Sinks, m.metrics)
+	if err != nil {
+		return nil, err
+	}
+	sinks.log = m.log
+	sinks.add("stdout", unclosed{m.console})
+	sinks.add("health", m.health)
+	if m.finality != nil {
+		sinks.add("finality", m.finality)
+	}
+	for name, sink := range m.extraSinks {
+		sinks.add(name, unclosed{sink})
+	}
+	alerters := make(map[string]Alerter, len(m.alerters)+2)
+	for name, alerter := range m.alerters {
+		alerters[name] = alerter
+	}
+	if cfg.Sinks.Alerts.Discord != nil {
+		discord, err := newDiscordAlerter(*cfg.Sinks.Alerts.Discord)
+		if err != nil {
+			sinks.Close()
+			return nil, err
+		}
+		alerters["discord"] = discord
+	}
+	if cfg.Sinks.Alerts.Opsgenie != nil {
+		opsgenie, err := newOpsgenieAlerter(*cfg.Sinks.Alerts.Opsgenie)
+		if err != nil {
+			sinks.Close()
+			return nil, err
+		}
+		alerters["opsgenie"] = opsgenie
+	}
+	if len(alerters) > 0 {
+		sinks.add("alerts", newAlertSink(cfg.Sinks.Alerts, cfg.Config, alerters, m.maintenance))
+	}
+	if hooks := newHookSink(cfg.Sinks.Hooks, m.hooks); len(hooks.hooks) > 0 {
+		sinks.add("hooks", hooks)
+	}
+	if cfg.Sinks.Forensics != nil {
+		forensics, err := newForensicSink(*cfg.Sinks.Forensics, m.clients)
+		if err != nil {
+			sinks.Close()
+			return nil, err
+		}
+		sinks.add("forensics", forensics)
+	}
+	return sinks, nil
+}
+
+// start runs the producer of node of chain with a fresh set of checks.
+func (m *Monitor) start(chain string, node NodeConfig, client *client.Client) {
+	cfg := m.chains[chain].cfg
+	ctx, stop := context.WithCancel(context.Background())
+	m.nodes[node.Name] = &runningNode{cfg: node, chain: chain, stop: stop}
+	m.clients.set(node.Name, client)
+	// Checks keep state between polls, so every node gets its own set.
+	m.checks[node.Name] = checks.New(cfg.Config)
+	m.health.add(node.Name)
+	poller := checks.NewPoller(client, cfg.Config)
+	poller.SetLogger(m.log)
+	if m.finality != nil {
+		if mark, ok := m.finality.mark(node.Name); ok {
+			poller.Resume(mark)
+		}
+	}
+	if m.snapshots != nil {
+		if state, ok := m.snapshots.resume(node.Name, poller, m.checks[node.Name]); ok {
+			m.state.nodes[node.Name] = state
+		}
+	}
+	m.producers.Add(1)
+	go func() {
+		defer m.producers.Done()
+		defer ExitOnPanic()
+		m.supervisor.run(ctx, node.Name, func(ctx context.Context) {
+			producer(ctx, m.queue, client, poller, cfg, m.snapshots != nil)
+		})
+	}()
+}
+
+func (m *Monitor) stop(name string) {
+	node := m.nodes[name]
+	node.stop()
+	delete(m.nodes, name)
+	m.clients.remove(name)
+	delete(m.checks, name)
+	delete(m.state.nodes, name)
+	if m.snapshots != nil {
+		delete(m.snapshots.pollers, name)
+	}
+	m.chains[node.chain].fleet.Remove(name)
+	m.health.remove(name)
+	m.metrics.remove(node.chain, name)
+	m.supervisor.remove(name)
+}
+
+// Run checks the results until a node exhausts the error budget, returning
+// the last fatal outcome of that node, or until interrupted or every node
+// completed the maximum poll cycles, or until Stop is called, returning nil.
+// The summary of the run is printed before returning.
+func (m *Monitor) Run() *checks.Outcome {
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+	dump := make(chan os.Signal, 1)
+	signal.Notify(dump, syscall.SIGUSR1)
+	defer signal.Stop(dump)
+	report := make(chan os.Signal, 1)
+	signal.Notify(report, syscall.SIGUSR2)
+	defer signal.Stop(report)
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	watch := time.NewTicker(configWatchInterval)
+	defer watch.Stop()
+	modTime := m.configModTime()
+
+	var snapshot <-chan time.Time
+	if m.snapshots != nil {
+		ticker := time.NewTicker(m.snapshots.interval)
+		defer ticker.Stop()
+		snapshot = ticker.C
+	}
+
+	var reportTimer *time.Timer
+	var reportDue <-chan time.Time
+	if m.reports != nil {
+		reportTimer = time.NewTimer(m.reports.next())
+		defer reportTimer.Stop()
+		reportDue = reportTimer.C
+	}
+
+	var deadline <-chan time.Time
+	if m.soak != nil {
+		deadline = m.soak.deadline
+	}
+
+	if err := m.systemd.notify("READY=1"); err != nil {
+		m.log.Printf("Error notifying systemd: %v", err)
+	}
+
+	for {
+		select {
+		case <-reload:
+			m.log.Printf("Reloading config on SIGHUP")
+			m.systemd.notify("RELOADING=1")
+			m.reload()
+			m.systemd.notify("READY=1")
+			modTime = m.configModTime()
+		case d := <-m.discovered:
+			m.applyDiscovered(d)
+		case <-dump:
+			m.dumpState()
+		case <-report:
+			m.summary.print(os.Stdout)
+		case <-snapshot:
+			m.snapshot()
+		case <-reportDue:
+			// reading the history must not hold up the checks.
+			go m.reports.write()
+			reportTimer.Reset(m.reports.next())
+		case <-deadline:
+			m.log.Printf("Soak test completed after %s", m.soak.duration)
+			m.stopAll()
+			return nil
+		case <-m.done:
+			m.stopAll()
+			return nil
+		case sig := <-interrupt:
+			m.log.Printf("Stopping on %s", sig)
+			m.stopAll()
+			return nil
+		case <-watch.C:
+			if t := m.configModTime(); !t.Equal(modTime) {
+				m.log.Printf("Reloading changed config")
+				m.reload()
+				modTime = t
+			}
+		case blockResult := <-m.queue.ch:
+			if outcome, exhausted := m.check(blockResult); exhausted {
+				m.stopAll()
+				return &outcome
+			}
+			// The watchdog is only pinged while the node answers.
+			if blockResult.BestErr == nil {
+				if err := m.systemd.watchdog(); err != nil {
+					m.log.Printf("Error pinging systemd watchdog: %v", err)
+				}
+			}
+			if m.cyclesDone() {
+				m.log.Printf("Completed %d poll cycles", m.maxCycles)
+				m.stopAll()
+				return nil
+			}
+		}
+	}
+}
+
+// Stop makes Run return. It may be called from any goroutine.
+func (m *Monitor) Stop() {
+	m.stopOnce.Do(func() { close(m.done) })
+}
+
+// cyclesDone reports whether every node completed the maximum poll cycles.
+func (m *Monitor) cyclesDone() bool {
+	if m.maxCycles == 0 {
+		return false
+	}
+	for name := range m.nodes {
+		if node, ok := m.summary.nodes[name]; !ok || node.Cycles < m.maxCycles {
+			return false
+		}
+	}
+	return true
+}
+
+// Verdict judges the soak test given the outcome of run, if the run was one.
+func (m *Monitor) Verdict(exhausted *checks.Outcome) (Verdict, bool) {
+	if m.soak == nil {
+		return Verdict{}, false
+	}
+	return m.soak.verdict(exhausted), true
+}
+
+// WriteJUnit writes the JUnit XML report of the check results to path.
+func (m *Monitor) WriteJUnit(path string) error {
+	if m.junit == nil {
+		return errors.New("check results not recorded")
+	}
+	return m.junit.write(path)
+}
+
+// snapshot writes the snapshot file, if configured.
+func (m *Monitor) snapshot() {
+	if m.snapshots == nil {
+		return
+	}
+	if err := m.snapshots.write(m.state.nodes); err != nil {
+		m.log.Printf("Error writing snapshot: %v", err)
+	}
+}
+
+// stopAll stops polling the nodes, flushes the sinks and prints the summary
+// of the run.
+func (m *Monitor) stopAll() {
+	m.systemd.notify("STOPPING=1")
+	for _, c := range m.chains {
+		m.stopDiscovery(c)
+	}
+	for _, node := range m.nodes {
+		node.stop()
+	}
+	m.producers.Wait()
+	m.snapshot()
+	m.sinks.Close()
+	if err := m.console.Close(); err != nil {
+		m.log.Printf("Error closing stdout sink: %v", err)
+	}
+	m.summary.print(os.Stdout)
+}
+
+// check runs the checks of the node of r and dispatches the outcomes to the
+// sinks, reporting whether the node exhausted the error budget, or stopped
+// responding with exitOnNoResponse set. In a dry run both are only logged.
+func (m *Monitor) check(r checks.BlockResult) (checks.Outcome, bool) {
+	nodeChecks, ok := m.checks[r.Node]
+	if !ok {
+		// the node was removed while polling.
+		return checks.Outcome{}, false
+	}
+	fleet := m.chains[m.nodes[r.Node].chain].fleet
+	outcomes := checks.Perform(nodeChecks, r)
+	outcomes = append(outcomes, fleet.Update(r)...)
+	r.Lag = fleet.Lag(r)
+	r.Propagation = fleet.Propagation(r)
+	m.logAuthorityChanges(r)
+	m.sinks.dispatch(r, outcomes)
+	m.state.record(r, outcomes)
+	if m.snapshots != nil && r.State != nil {
+		m.snapshots.pollers[r.Node] = r.State
+	}
+	m.summary.record(r, nodeChecks, outcomes)
+	if m.soak != nil {
+		m.soak.record(r, outcomes)
+	}
+	if m.junit != nil {
+		m.junit.record(r, nodeChecks, fleet.Checks, outcomes)
+	}
+
+	var fatal []checks.Outcome
+	for _, outcome := range outcomes {
+		if outcome.Severity == checks.SeverityFatal {
+			fatal = append(fatal, outcome)
+		}
+		if outcome.Name == checks.NoResponse && m.chains[m.nodes[r.Node].chain].cfg.ExitOnNoResponse {
+			if m.dryRun {
+				m.log.Printf("%s is not responding, continuing in dry run", r.Node)
+				continue
+			}
+			return outcome, true
+		}
+	}
+	if m.budget.record(r.Node, r.Time, len(fatal) > 0) {
+		if m.dryRun {
+			m.log.Printf("Error budget of %s exhausted, continuing in dry run", r.Node)
+			return checks.Outcome{}, false
+		}
+		return fatal[0], true
+	}
+	return checks.Outcome{}, false
+}
+
+// logAuthorityChanges logs the changes of the active proposer set read in
+// the poll cycle of r.
+func (m *Monitor) logAuthorityChanges(r checks.BlockResult) {
+	if r.Authority == nil {
+		return
+	}
+	for _, addr := range r.Authority.Added {
+		m.log.Printf("Node %s: proposer %s joined the active set", r.Node, addr)
+	}
+	for _, addr := range r.Authority.Removed {
+		m.log.Printf("Node %s: proposer %s left the active set", r.Node, addr)
+	}
+}
+
+func (m *Monitor) configModTime() time.Time {
+	if m.configPath == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(m.configPath)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// reload applies the config file. Nodes whose config is unchanged keep
+// polling, unless a setting shared by all the nodes of their chain changed.
+// An invalid config is reported and ignored.
+func (m *Monitor) reload() {
+	if m.configPath == "" {
+		return
+	}
+	cfg, err := LoadConfig(m.configPath, m.network)
+	var chains []Chain
+	if err == nil {
+		chains, err = m.resolveChains(cfg)
+	}
+	if err != nil {
+		m.log.Printf("Error reloading config, keeping the current one: %v", err)
+		return
+	}
+
+	if cfg.MetricsAddr != m.cfg.MetricsAddr || cfg.MaintenanceToken != m.cfg.MaintenanceToken || cfg.FinalityFile != m.cfg.FinalityFile || cfg.Snapshot != m.cfg.Snapshot || !reflect.DeepEqual(cfg.Tracing, m.cfg.Tracing) || !reflect.DeepEqual(cfg.Reports, m.cfg.Reports) {
+		m.log.Printf("Changes of metricsAddr, maintenanceToken, finalityFile, snapshot, tracing and reports take effect after a restart")
+	}
+
+	// the alerts know the fields every check needs, the plugins included.
+	if !reflect.DeepEqual(cfg.Sinks, m.cfg.Sinks) || !reflect.DeepEqual(cfg.Plugins, m.cfg.Plugins) {
+		sinks, err := m.newSinks(cfg)
+		if err != nil {
+			m.log.Printf("Error reloading sinks, keeping the current ones: %v", err)
+			cfg.Sinks = m.cfg.Sinks
+		} else {
+			m.sinks.Close()
+			m.sinks = sinks
+		}
+	}
+
+	m.cfg = cfg
+	m.budget.budget = cfg.ErrorBudget
+	m.state.history = firstNonZero(cfg.StateDump.History, defaultStateHistory)
+
+	wantedChains := make(map[string]bool, len(chains))
+	for _, c := range chains {
+		name := c.Config.Chain
+		wantedChains[name] = true
+		current, ok := m.chains[name]
+		restartAll := ok && !reflect.DeepEqual(pollSettings(c.Config), pollSettings(current.cfg))
+		switch {
+		case !ok:
+			if name != "" {
+				m.log.Printf("Adding chain %s", name)
+			}
+			current = &chain{fleet: checks.NewFleet(c.Config.Config)}
+			m.chains[name] = current
+		case restartAll:
+			current.fleet = checks.NewFleet(c.Config.Config)
+		}
+		rediscover := !reflect.DeepEqual(c.Config.discoverer(), current.cfg.discoverer())
+		current.cfg, current.detected = c.Config, c.Detected
+		if rediscover {
+			m.stopDiscovery(current)
+			m.startDiscovery(name, current)
+		}
+		m.syncNodes(name, current, restartAll)
+	}
+	for name, node := range m.nodes {
+		if !wantedChains[node.chain] {
+			m.log.Printf("Removing node %s", name)
+			m.stop(name)
+		}
+	}
+	for name, c := range m.chains {
+		if !wantedChains[name] {
+			if name != "" {
+				m.log.Printf("Removing chain %s", name)
+			}
+			m.stopDiscovery(c)
+			delete(m.chains, name)
+		}
+	}
+	m.health.setStaleAfter(m.staleAfter())
+}
+
+// syncNodes runs the nodes of chain c, named name, restarting the ones whose
+// config changed, or all of them when restart is set, and stops the nodes of
+// the chain which are no longer configured nor discovered.
+func (m *Monitor) syncNodes(name string, c *chain, restart bool) {
+	wanted := make(map[string]bool)
+	for i, node := range c.nodes() {
+		running, ok := m.nodes[node.Name]
+		if ok && running.chain != name && i >= len(c.cfg.Nodes) {
+			m.log.Printf("Not monitoring discovered node %s: already in chain %s", node.Name, running.chain)
+			continue
+		}
+		wanted[node.Name] = true
+		if ok && !restart && running.chain == name && reflect.DeepEqual(running.cfg, node) {
+			continue
+		}
+		if ok {
+			m.stop(node.Name)
+		} else {
+			m.log.Printf("Adding node %s", node.Name)
+		}
+		nodeClient, err := newClient(c.cfg, node)
+		if err != nil {
+			m.log.Printf("Not monitoring node %s: %v", node.Name, err)
+			continue
+		}
+		if _, err := verifyChain(context.Background(), strings.ToLower(c.cfg.GenesisID), nodeClient); errors.Is(err, errWrongChain) {
+			m.log.Printf("Not monitoring node %s: %v", node.Name, err)
+			continue
+		} else if err != nil {
+			m.log.Printf("Error verifying the chain of node %s: %v", node.Name, err)
+		}
+		m.start(name, node, nodeClient)
+	}
+	for nodeName, running := range m.nodes {
+		if running.chain == name && !wanted[nodeName] {
+			m.log.Printf("Removing node %s", nodeName)
+			m.stop(nodeName)
+		}
+	}
+}
+
+// resolveChains resolves the chain parameters of every chain of cfg against
+// the ones detected before for the known chains, and from their nodes for
+// the new ones.
+func (m *Monitor) resolveChains(cfg Config) ([]Chain, error) {
+	var chains []Chain
+	for _, chainCfg := range cfg.chainConfigs() {
+		current, ok := m.chains[chainCfg.Chain]
+		if !ok {
+			c, err := setupChain(chainCfg)
+			if err != nil {
+				return nil, err
+			}
+			chains = append(chains, c)
+			continue
+		}
+		if err := applyChainParams(&chainCfg, current.detected); err != nil {
+			if chainCfg.Chain != "" {
+				err = fmt.Errorf("error resolving chain %s: %w", chainCfg.Chain, err)
+			}
+			return nil, err
+		}
+		if chainCfg.GenesisID == "" {
+			chainCfg.GenesisID = current.cfg.GenesisID
+		}
+		chains = append(chains, Chain{Config: chainCfg, Detected: current.detected})
+	}
+	return chains, nil
+}
+
+// pollSettings returns cfg without the settings which can change without
+// restarting the producers and checks of every node.
+func pollSettings(cfg Config) Config {
+	cfg.Nodes = nil
+	cfg.DNSDiscovery = nil
+	cfg.KubernetesDiscovery = nil
+	cfg.Sinks = SinksConfig{}
+	cfg.ErrorBudget = ErrorBudget{}
+	cfg.HealthStaleAfter = Duration{}
+	cfg.MetricsAddr = ""
+	cfg.Tracing = nil
+	cfg.Pushgateway = PushgatewayConfig{}
+	cfg.StateDump = StateDumpConfig{}
+	return cfg
+}
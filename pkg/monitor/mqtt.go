@@ -0,0 +1,112 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/paologalligit/justified/pkg/checks"
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// MQTTSinkConfig publishes every record on <prefix>/<node>/result and the
+// failed checks on <prefix>/<node>/violation.
+type MQTTSinkConfig struct {
+	Broker      string            `json:"broker"` // e.g. tcp://localhost:1883 or ssl://localhost:8883.
+	TopicPrefix string            `json:"topicPrefix"`
+	QoS         byte              `json:"qos"`
+	ClientID    string            `json:"clientID"`
+	Username    string            `json:"username"`
+	Password    string            `json:"password"`
+	TLS         *client.TLSConfig `json:"tls"`
+}
+
+// mqttTimeout bounds the connection to the broker and every publication.
+const mqttTimeout = 10 * time.Second
+
+type mqttSink struct {
+	client mqtt.Client
+	prefix string
+	qos    byte
+}
+
+func newMQTTSink(cfg MQTTSinkConfig) (*mqttSink, error) {
+	if cfg.Broker == "" {
+		return nil, fmt.Errorf("mqtt sink needs a broker")
+	}
+	if cfg.QoS > 2 {
+		return nil, fmt.Errorf("invalid mqtt qos %d", cfg.QoS)
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(firstNonZero(cfg.ClientID, "justified")).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetConnectTimeout(mqttTimeout).
+		SetAutoReconnect(true)
+	if cfg.TLS != nil {
+		tlsConfig, err := cfg.TLS.Load()
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	client := mqtt.NewClient(opts)
+	if err := waitToken(client.Connect()); err != nil {
+		return nil, fmt.Errorf("error connecting to mqtt broker: %w", err)
+	}
+	return &mqttSink{client: client, prefix: firstNonZero(cfg.TopicPrefix, "finality"), qos: cfg.QoS}, nil
+}
+
+func (s *mqttSink) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	rec := newRecord(r, outcomes)
+	topic := s.prefix + "/" + topicLevel(r.Node)
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if err := waitToken(s.client.Publish(topic+"/result", s.qos, false, data)); err != nil {
+		return err
+	}
+
+	for _, outcome := range rec.Outcomes {
+		data, err := json.Marshal(outcome)
+		if err != nil {
+			return err
+		}
+		if err := waitToken(s.client.Publish(topic+"/violation", s.qos, false, data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *mqttSink) Close() error {
+	s.client.Disconnect(uint(mqttTimeout.Milliseconds()))
+	return nil
+}
+
+func waitToken(t mqtt.Token) error {
+	if !t.WaitTimeout(mqttTimeout) {
+		return fmt.Errorf("timed out after %s", mqttTimeout)
+	}
+	return t.Error()
+}
+
+// topicLevel turns name into a single topic level, replacing the separator
+// and wildcard characters.
+func topicLevel(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '+', '#':
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// NATSSinkConfig publishes every record on <prefix>.<node>.result and the
+// failed checks on <prefix>.<node>.violation.
+type NATSSinkConfig struct {
+	URL           string `json:"url"`
+	SubjectPrefix string `json:"subjectPrefix"` // defaults to finality.
+}
+
+type natsSink struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+func newNATSSink(cfg NATSSinkConfig) (*natsSink, error) {
+	conn, err := nats.Connect(firstNonZero(cfg.URL, nats.DefaultURL), nats.Name("justified"))
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to nats: %w", err)
+	}
+	return &natsSink{conn: conn, prefix: firstNonZero(cfg.SubjectPrefix, "finality")}, nil
+}
+
+func (s *natsSink) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	rec := newRecord(r, outcomes)
+	subject := s.prefix + "." + subjectToken(r.Node)
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if err := s.conn.Publish(subject+".result", data); err != nil {
+		return err
+	}
+
+	for _, outcome := range rec.Outcomes {
+		data, err := json.Marshal(outcome)
+		if err != nil {
+			return err
+		}
+		if err := s.conn.Publish(subject+".violation", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *natsSink) Close() error {
+	// Drain flushes the pending messages before closing the connection.
+	return s.conn.Drain()
+}
+
+// subjectToken turns name into a single subject token, replacing the
+// separator and wildcard characters.
+func subjectToken(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\n':
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,60 @@
+package monitor
+
+import (
+	"sort"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// Network is the preset of a known network: the node monitored by default,
+// the expected genesis block and the chain parameters.
+type Network struct {
+	NodeURL   string
+	GenesisID string // empty when any chain is accepted.
+	Params    checks.ChainParams
+}
+
+// Networks are the presets selectable by name.
+var Networks = map[string]Network{
+	"mainnet": {
+		NodeURL:   "https://mainnet.vechain.org/",
+		GenesisID: checks.MainnetGenesisID,
+		Params:    checks.PublicChainParams,
+	},
+	"testnet": {
+		NodeURL:   "https://testnet.vechain.org/",
+		GenesisID: checks.TestnetGenesisID,
+		Params:    checks.PublicChainParams,
+	},
+	// A local devnet has its own genesis block.
+	"devnet": {
+		NodeURL: NodeURL,
+		Params: checks.ChainParams{
+			BlockInterval:      checks.BlockInterval,
+			CheckpointInterval: checks.CheckpointInterval,
+			MaxBlockProposers:  checks.InitialMaxBlockProposers,
+		},
+	},
+}
+
+// NetworkNames returns the names of the presets, sorted.
+func NetworkNames() []string {
+	names := make([]string, 0, len(Networks))
+	for name := range Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// apply fills the settings of cfg left unset with the preset.
+func (n Network) apply(cfg *Config) {
+	cfg.NodeURL = firstNonZero(cfg.NodeURL, n.NodeURL)
+	cfg.GenesisID = firstNonZero(cfg.GenesisID, n.GenesisID)
+	params := cfg.ChainParams()
+	cfg.SetChainParams(checks.ChainParams{
+		BlockInterval:      firstNonZero(params.BlockInterval, n.Params.BlockInterval),
+		CheckpointInterval: firstNonZero(params.CheckpointInterval, n.Params.CheckpointInterval),
+		MaxBlockProposers:  firstNonZero(params.MaxBlockProposers, n.Params.MaxBlockProposers),
+	})
+}
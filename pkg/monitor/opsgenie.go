@@ -0,0 +1,157 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+const (
+	// defaultOpsgenieURL is the API of the US instance of Opsgenie.
+	defaultOpsgenieURL = "https://api.opsgenie.com"
+	// defaultOpsgenieTimeout bounds a request to Opsgenie by default.
+	defaultOpsgenieTimeout = 10 * time.Second
+	// opsgenieMessageSize is how many characters Opsgenie keeps of a message.
+	opsgenieMessageSize = 130
+)
+
+var opsgeniePriorities = []string{"P1", "P2", "P3", "P4", "P5"}
+
+// OpsgenieConfig creates an Opsgenie alert for every check failing on a
+// node, closed once the check passes again. The failures alerted in between
+// are deduplicated into the open alert.
+type OpsgenieConfig struct {
+	APIKey string `json:"apiKey"` // of an API integration.
+	// URL of the API, defaults to the US instance, https://api.eu.opsgenie.com for the EU one.
+	URL string `json:"url"`
+	// The priorities of the fatal and warning failures, P1 and P3 by default.
+	FatalPriority string   `json:"fatalPriority"`
+	WarnPriority  string   `json:"warnPriority"`
+	Tags          []string `json:"tags"`
+	Timeout       Duration `json:"timeout"` // of every request, defaults to 10s.
+}
+
+// opsgenieAlert is the body of a request creating an Opsgenie alert.
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description"`
+	Priority    string            `json:"priority"`
+	Entity      string            `json:"entity"`
+	Source      string            `json:"source"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details"`
+}
+
+// opsgenieClose is the body of a request closing an Opsgenie alert.
+type opsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note"`
+}
+
+type opsgenieAlerter struct {
+	url        string
+	apiKey     string
+	priorities map[checks.Severity]string
+	tags       []string
+	client     *http.Client
+}
+
+func newOpsgenieAlerter(cfg OpsgenieConfig) (*opsgenieAlerter, error) {
+	if cfg.APIKey == "" {
+		return nil, errors.New("opsgenie api key not set")
+	}
+	fatal, warn := firstNonZero(cfg.FatalPriority, "P1"), firstNonZero(cfg.WarnPriority, "P3")
+	for _, priority := range []string{fatal, warn} {
+		if !slices.Contains(opsgeniePriorities, priority) {
+			return nil, fmt.Errorf("unknown opsgenie priority %q", priority)
+		}
+	}
+	return &opsgenieAlerter{
+		url:        strings.TrimSuffix(firstNonZero(cfg.URL, defaultOpsgenieURL), "/"),
+		apiKey:     cfg.APIKey,
+		priorities: map[checks.Severity]string{checks.SeverityFatal: fatal, checks.SeverityWarn: warn},
+		tags:       cfg.Tags,
+		client:     &http.Client{Timeout: firstNonZero(cfg.Timeout.Duration, defaultOpsgenieTimeout)},
+	}, nil
+}
+
+func (o *opsgenieAlerter) Alert(a Alert) error {
+	alias := opsgenieAlias(a)
+	if a.Resolved {
+		return o.post("/v2/alerts/"+url.PathEscape(alias)+"/close?identifierType=alias", opsgenieClose{
+			Source: "justified",
+			Note:   a.String(),
+		})
+	}
+
+	message := fmt.Sprintf("%s failed on %s", a.Check, a.Node)
+	if a.Chain != "" {
+		message += " of " + a.Chain
+	}
+	if len(message) > opsgenieMessageSize {
+		message = message[:opsgenieMessageSize]
+	}
+	priority, ok := o.priorities[a.Severity]
+	if !ok {
+		priority = o.priorities[checks.SeverityWarn]
+	}
+	return o.post("/v2/alerts", opsgenieAlert{
+		Message:     message,
+		Alias:       alias,
+		Description: a.Error,
+		Priority:    priority,
+		Entity:      a.Node,
+		Source:      "justified",
+		Tags:        o.tags,
+		Details: map[string]string{
+			"chain":     a.Chain,
+			"check":     a.Check,
+			"severity":  a.Severity.String(),
+			"since":     a.Since.Format(time.RFC3339),
+			"failures":  fmt.Sprint(a.Failures),
+			"best":      fmt.Sprint(a.Best),
+			"justified": fmt.Sprint(a.Justified),
+			"finalized": fmt.Sprint(a.Finalized),
+		},
+	})
+}
+
+func (o *opsgenieAlerter) post(path string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, o.url+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+o.apiKey)
+	res, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("status code not 2xx: %s", res.Status)
+	}
+	return nil
+}
+
+// opsgenieAlias identifies the alert of a check on a node, so that its
+// failures are deduplicated and its resolution closes it.
+func opsgenieAlias(a Alert) string {
+	if a.Chain == "" {
+		return strings.Join([]string{"justified", a.Node, a.Check}, ":")
+	}
+	return strings.Join([]string{"justified", a.Chain, a.Node, a.Check}, ":")
+}
@@ -0,0 +1,83 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/paologalligit/justified/pkg/checks"
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// producer polls client with poller every block interval and queues the
+// results on queue until ctx is done, along with the state of the poller when
+// snapshot is set.
+func producer(ctx context.Context, queue *resultQueue, client *client.Client, poller *checks.Poller, cfg Config, snapshot bool) {
+	interval := time.Duration(cfg.BlockInterval) * time.Second
+	cycleTimeout := firstNonZero(cfg.CycleTimeout.Duration, interval)
+	var jitter time.Duration
+	if cfg.PollJitter.Duration > 0 {
+		jitter = rand.N(min(cfg.PollJitter.Duration, interval))
+	}
+	timer := time.NewTimer(interval + jitter)
+	defer timer.Stop()
+	run := ctx
+	for {
+		var now time.Time
+		select {
+		case now = <-timer.C:
+		case <-run.Done():
+			return
+		}
+
+		// The cycle must complete before the next tick.
+		ctx, cancel := context.WithTimeout(run, cycleTimeout)
+		ctx, span := tracer.Start(ctx, "poll cycle", trace.WithAttributes(attribute.String("node", client.Name)))
+
+		blockResult := poller.Poll(ctx, now)
+		blockResult.Chain = cfg.Chain
+		blockResult.Trace = span.SpanContext()
+		if snapshot {
+			state := poller.State()
+			blockResult.State = &state
+		}
+		if blockResult.TimedOut {
+			blockResult.CycleErr = fmt.Errorf("poll cycle timed out after %s", cycleTimeout)
+		}
+		span.SetAttributes(attribute.Int64("best", int64(blockResult.Best)), attribute.Int64("justified", int64(blockResult.Justified)), attribute.Int64("finalized", int64(blockResult.Finalized)))
+		endSpan(span, blockResult.Err())
+		cancel()
+
+		next := nextPoll(now, blockResult, interval, cfg.AlignPolls, jitter)
+		if until := client.RateLimited(); until.After(next) {
+			next = until
+		}
+		timer.Reset(time.Until(next))
+		if !queue.send(run, blockResult) {
+			return
+		}
+	}
+}
+
+// alignedPollDelay is how long after the expected time of the next block it
+// is polled, for the block to reach the node.
+const alignedPollDelay = 500 * time.Millisecond
+
+// nextPoll returns when to poll after the poll cycle started at now returned
+// r: a block interval later, or when aligned, just after the next block is
+// expected and jitter. An overdue block is expected in the next slot.
+func nextPoll(now time.Time, r checks.BlockResult, interval time.Duration, align bool, jitter time.Duration) time.Time {
+	if !align || r.BestErr != nil || r.BestTimestamp == 0 {
+		// the first poll was delayed by the jitter already.
+		return now.Add(interval)
+	}
+	next := time.Unix(int64(r.BestTimestamp), 0).Add(interval + alignedPollDelay + jitter)
+	if late := now.Sub(next); late >= 0 {
+		next = next.Add((late/interval + 1) * interval)
+	}
+	return next
+}
@@ -0,0 +1,84 @@
+package monitor
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// marshalRecordProto encodes rec as the Record message of record.proto. The
+// message is small and stable enough to be encoded by hand rather than
+// through generated code.
+func marshalRecordProto(rec Record) []byte {
+	var b []byte
+	b = appendMessage(b, 1, marshalResultProto(rec.Result))
+	for _, err := range rec.Errors {
+		b = appendString(b, 2, err)
+	}
+	for _, outcome := range rec.Outcomes {
+		var o []byte
+		o = appendString(o, 1, outcome.Node)
+		o = appendString(o, 2, outcome.Name)
+		o = appendVarint(o, 3, protoSeverity(outcome.Severity))
+		o = appendString(o, 4, outcome.Error)
+		b = appendMessage(b, 3, o)
+	}
+	return b
+}
+
+func marshalResultProto(r checks.BlockResult) []byte {
+	var b []byte
+	b = appendString(b, 1, r.Node)
+	b = appendString(b, 2, r.Chain)
+	if !r.Time.IsZero() {
+		var t []byte
+		t = appendVarint(t, 1, uint64(r.Time.Unix()))
+		t = appendVarint(t, 2, uint64(r.Time.Nanosecond()))
+		b = appendMessage(b, 3, t)
+	}
+	b = appendVarint(b, 4, uint64(r.Best))
+	b = appendString(b, 5, r.BestID)
+	b = appendVarint(b, 6, r.BestTimestamp)
+	b = appendVarint(b, 7, uint64(r.Justified))
+	b = appendString(b, 8, r.JustifiedID)
+	b = appendVarint(b, 9, uint64(r.Finalized))
+	b = appendString(b, 10, r.FinalizedID)
+	b = appendVarint(b, 11, protowire.EncodeBool(r.TimedOut))
+	b = appendVarint(b, 12, protowire.EncodeBool(r.Syncing))
+	return b
+}
+
+// protoSeverity returns the Severity enum value of severity.
+func protoSeverity(severity checks.Severity) uint64 {
+	switch severity {
+	case checks.SeverityWarn:
+		return 1
+	case checks.SeverityFatal:
+		return 2
+	}
+	return 0
+}
+
+// The append functions leave out the fields set to their zero value, as
+// proto3 does.
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendMessage(b []byte, num protowire.Number, m []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m)
+}
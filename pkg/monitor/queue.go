@@ -0,0 +1,83 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// defaultQueueSize is how many poll results may wait for the monitor by default.
+const defaultQueueSize = 64
+
+// QueueConfig bounds the poll results waiting to be checked, queued by the
+// producers while the monitor is busy, e.g. with a slow check plugin. It is
+// read at startup only.
+type QueueConfig struct {
+	Size int `json:"size"` // defaults to 64.
+	// Overflow is what a producer does when the queue is full: block
+	// (default), delaying its next poll cycles, or drop-oldest, dropping the
+	// oldest result queued to keep polling on time.
+	Overflow string `json:"overflow"`
+}
+
+// resultQueue carries the poll results from the producers to the monitor.
+type resultQueue struct {
+	ch         chan checks.BlockResult
+	dropOldest bool
+	depth      prometheus.GaugeFunc
+	dropped    prometheus.Counter
+}
+
+func newResultQueue(cfg QueueConfig) (*resultQueue, error) {
+	if cfg.Overflow != "" && cfg.Overflow != "block" && cfg.Overflow != "drop-oldest" {
+		return nil, fmt.Errorf("unknown queue overflow policy %q", cfg.Overflow)
+	}
+	q := &resultQueue{
+		ch:         make(chan checks.BlockResult, firstNonZero(cfg.Size, defaultQueueSize)),
+		dropOldest: cfg.Overflow == "drop-oldest",
+		dropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "justified_queue_dropped_total",
+			Help: "Number of poll results dropped from the full queue of the results to check.",
+		}),
+	}
+	q.depth = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "justified_queue_depth",
+		Help: "Poll results waiting to be checked.",
+	}, func() float64 { return float64(len(q.ch)) })
+	return q, nil
+}
+
+// register registers the metrics of the queue with reg.
+func (q *resultQueue) register(reg prometheus.Registerer) {
+	reg.MustRegister(q.depth, q.dropped)
+}
+
+// send queues r unless ctx is done first. When the queue is full, it blocks
+// or drops the oldest result queued.
+func (q *resultQueue) send(ctx context.Context, r checks.BlockResult) bool {
+	if !q.dropOldest {
+		select {
+		case q.ch <- r:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for ctx.Err() == nil {
+		select {
+		case q.ch <- r:
+			return true
+		default:
+		}
+		// The monitor may have emptied the queue meanwhile.
+		select {
+		case <-q.ch:
+			q.dropped.Inc()
+		default:
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/paologalligit/justified/pkg/checks"
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// RedisSinkConfig publishes every record on a channel and keeps the latest
+// state of each node in keys such as finality:<node>:finalized.
+type RedisSinkConfig struct {
+	Addr      string            `json:"addr"`
+	Username  string            `json:"username"`
+	Password  string            `json:"password"`
+	DB        int               `json:"db"`
+	Channel   string            `json:"channel"`   // defaults to finality.
+	KeyPrefix string            `json:"keyPrefix"` // defaults to finality.
+	TLS       *client.TLSConfig `json:"tls"`
+}
+
+// redisTimeout bounds the commands of one record.
+const redisTimeout = 10 * time.Second
+
+type redisSink struct {
+	client  *redis.Client
+	channel string
+	prefix  string
+}
+
+func newRedisSink(cfg RedisSinkConfig) (*redisSink, error) {
+	opts := &redis.Options{
+		Addr:     firstNonZero(cfg.Addr, "localhost:6379"),
+		Username: cfg.Username,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	}
+	if cfg.TLS != nil {
+		tlsConfig, err := cfg.TLS.Load()
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = tlsConfig
+	}
+
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("error connecting to redis: %w", err)
+	}
+	return &redisSink{client: client, channel: firstNonZero(cfg.Channel, "finality"), prefix: firstNonZero(cfg.KeyPrefix, "finality")}, nil
+}
+
+func (s *redisSink) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	data, err := json.Marshal(newRecord(r, outcomes))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	key := func(field string) string { return s.prefix + ":" + r.Node + ":" + field }
+	pipe := s.client.TxPipeline()
+	pipe.Publish(ctx, s.channel, data)
+	pipe.Set(ctx, key("result"), data, 0)
+	pipe.Set(ctx, key("time"), r.Time.Unix(), 0)
+	if r.Fetched(checks.FieldBest) {
+		pipe.Set(ctx, key("best"), r.Best, 0)
+		pipe.Set(ctx, key("bestID"), r.BestID, 0)
+	}
+	if r.Fetched(checks.FieldJustified) {
+		pipe.Set(ctx, key("justified"), r.Justified, 0)
+		pipe.Set(ctx, key("justifiedID"), r.JustifiedID, 0)
+	}
+	if r.Fetched(checks.FieldFinalized) {
+		pipe.Set(ctx, key("finalized"), r.Finalized, 0)
+		pipe.Set(ctx, key("finalizedID"), r.FinalizedID, 0)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s *redisSink) Close() error {
+	return s.client.Close()
+}
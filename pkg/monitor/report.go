@@ -0,0 +1,365 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// ReportPeriods are the periods a report covers, in UTC: a day from midnight,
+// or a week from Monday.
+var ReportPeriods = []string{"daily", "weekly"}
+
+// ReportFormats are the formats a report is rendered in.
+var ReportFormats = []string{"json", "html"}
+
+// reportDelay is how long after the end of a period its report is generated,
+// for the results of its last poll cycles to reach the history.
+const reportDelay = time.Minute
+
+// ReportsConfig generates a report of every elapsed period from the history
+// of the poll cycles, the JSON lines of sinks.file.
+type ReportsConfig struct {
+	Period  string   `json:"period"`  // daily or weekly.
+	Dir     string   `json:"dir"`     // the reports are written to, as <period>-<date>.<format>.
+	Formats []string `json:"formats"` // json and html, defaults to both.
+}
+
+// Report summarizes the finality of the nodes over a period.
+type Report struct {
+	Period    string    `json:"period,omitempty"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Generated time.Time `json:"generated"`
+	Cycles    int       `json:"cycles"`
+	// FinalizationUptime is the share of the poll cycles of all the nodes
+	// without a fatal check failure.
+	FinalizationUptime float64           `json:"finalizationUptime"`
+	Nodes              []*NodeReport     `json:"nodes"`
+	Violations         []*ViolationCount `json:"violations"`
+	Skipped            int               `json:"skipped,omitempty"` // lines of the history which could not be decoded.
+}
+
+// NodeReport summarizes the poll cycles of a node over a period. The lags are
+// averaged over the poll cycles without errors.
+type NodeReport struct {
+	Chain              string  `json:"chain,omitempty"`
+	Node               string  `json:"node"`
+	Cycles             int     `json:"cycles"`
+	ErrorCycles        int     `json:"errorCycles"` // poll cycles with at least one error.
+	ErrorRate          float64 `json:"errorRate"`
+	FinalizationUptime float64 `json:"finalizationUptime"`
+	AvgJustifiedLag    float64 `json:"avgJustifiedLag"`
+	AvgFinalizedLag    float64 `json:"avgFinalizedLag"`
+	MaxFinalizedLag    int64   `json:"maxFinalizedLag"`
+	FirstFinalized     uint32  `json:"firstFinalized"`
+	LastFinalized      uint32  `json:"lastFinalized"`
+	Violations         int     `json:"violations"` // fatal check failures.
+	Warnings           int     `json:"warnings"`
+
+	up, lagged             int
+	justifiedLag, finalLag int64
+}
+
+// ViolationCount is how many times a fatal check failed on a node over a period.
+type ViolationCount struct {
+	Chain     string    `json:"chain,omitempty"`
+	Node      string    `json:"node"`
+	Check     string    `json:"check"`
+	Count     int       `json:"count"`
+	First     time.Time `json:"first"`
+	Last      time.Time `json:"last"`
+	LastError string    `json:"lastError"`
+}
+
+// historyLine is the part of a line of the history the reports read.
+type historyLine struct {
+	Result struct {
+		Node      string
+		Chain     string
+		Time      time.Time
+		Best      uint32
+		Justified uint32
+		Finalized uint32
+	} `json:"result"`
+	Errors   []string        `json:"errors"`
+	Outcomes []OutcomeRecord `json:"outcomes"`
+}
+
+// ReportHistory returns the history the reports of cfg are generated from.
+func ReportHistory(cfg Config) (string, error) {
+	switch {
+	case cfg.Sinks.File == "":
+		return "", errors.New("reports are generated from sinks.file, which is not set")
+	case cfg.Sinks.FileFormat == "protobuf":
+		return "", errors.New("reports are generated from the JSON lines of sinks.file, not protobuf")
+	}
+	return cfg.Sinks.File, nil
+}
+
+// ReportPeriod returns the last period completed at t.
+func ReportPeriod(period string, t time.Time) (from, to time.Time, err error) {
+	to, err = periodStart(period, t)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return to.AddDate(0, 0, -periodDays(period)), to, nil
+}
+
+// periodStart returns the start of the period including t.
+func periodStart(period string, t time.Time) (time.Time, error) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case "daily":
+		return day, nil
+	case "weekly":
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7), nil
+	}
+	return time.Time{}, fmt.Errorf("unknown report period %q, expected one of %s", period, strings.Join(ReportPeriods, ", "))
+}
+
+func periodDays(period string) int {
+	if period == "weekly" {
+		return 7
+	}
+	return 1
+}
+
+// BuildReport summarizes the poll cycles of the history at path which
+// started in [from, to).
+func BuildReport(path string, from, to time.Time) (*Report, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening history: %w", err)
+	}
+	defer file.Close()
+
+	report := &Report{From: from.UTC(), To: to.UTC(), Generated: time.Now().UTC(), Nodes: []*NodeReport{}, Violations: []*ViolationCount{}}
+	nodes := make(map[[2]string]*NodeReport)
+	violations := make(map[[3]string]*ViolationCount)
+	reader := bufio.NewReader(file)
+	for {
+		data, err := reader.ReadBytes('\n')
+		if len(data) > 0 {
+			var line historyLine
+			if json.Unmarshal(data, &line) != nil {
+				report.Skipped++
+			} else if r := line.Result; !r.Time.Before(from) && r.Time.Before(to) {
+				key := [2]string{r.Chain, r.Node}
+				node, ok := nodes[key]
+				if !ok {
+					node = &NodeReport{Chain: r.Chain, Node: r.Node, FirstFinalized: r.Finalized}
+					nodes[key] = node
+				}
+				report.Cycles++
+				node.add(line)
+				for _, outcome := range line.Outcomes {
+					if outcome.Severity != checks.SeverityFatal {
+						continue
+					}
+					key := [3]string{r.Chain, outcome.Node, outcome.Name}
+					v, ok := violations[key]
+					if !ok {
+						v = &ViolationCount{Chain: r.Chain, Node: outcome.Node, Check: outcome.Name, First: r.Time}
+						violations[key] = v
+					}
+					v.Count++
+					v.Last, v.LastError = r.Time, outcome.Error
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading history: %w", err)
+		}
+	}
+
+	up := 0
+	for _, node := range nodes {
+		node.summarize()
+		up += node.up
+		report.Nodes = append(report.Nodes, node)
+	}
+	if report.Cycles > 0 {
+		report.FinalizationUptime = float64(up) / float64(report.Cycles)
+	}
+	slices.SortFunc(report.Nodes, func(a, b *NodeReport) int {
+		return strings.Compare(a.Chain+"\x00"+a.Node, b.Chain+"\x00"+b.Node)
+	})
+	for _, v := range violations {
+		report.Violations = append(report.Violations, v)
+	}
+	slices.SortFunc(report.Violations, func(a, b *ViolationCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Chain+"\x00"+a.Node+"\x00"+a.Check, b.Chain+"\x00"+b.Node+"\x00"+b.Check)
+	})
+	return report, nil
+}
+
+// add accounts a poll cycle of the node.
+func (n *NodeReport) add(line historyLine) {
+	n.Cycles++
+	n.LastFinalized = max(n.LastFinalized, line.Result.Finalized)
+	fatal := false
+	for _, outcome := range line.Outcomes {
+		if outcome.Severity == checks.SeverityFatal {
+			n.Violations++
+			fatal = true
+		} else {
+			n.Warnings++
+		}
+	}
+	if !fatal {
+		n.up++
+	}
+	if len(line.Errors) > 0 {
+		n.ErrorCycles++
+		return
+	}
+	r := line.Result
+	n.lagged++
+	n.justifiedLag += int64(r.Best) - int64(r.Justified)
+	n.finalLag += int64(r.Best) - int64(r.Finalized)
+	n.MaxFinalizedLag = max(n.MaxFinalizedLag, int64(r.Best)-int64(r.Finalized))
+}
+
+func (n *NodeReport) summarize() {
+	n.ErrorRate = float64(n.ErrorCycles) / float64(n.Cycles)
+	n.FinalizationUptime = float64(n.up) / float64(n.Cycles)
+	if n.lagged > 0 {
+		n.AvgJustifiedLag = float64(n.justifiedLag) / float64(n.lagged)
+		n.AvgFinalizedLag = float64(n.finalLag) / float64(n.lagged)
+	}
+}
+
+// Encode writes the report to w in format, one of ReportFormats.
+func (r *Report) Encode(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case "html":
+		return reportTemplate.Execute(w, r)
+	}
+	return fmt.Errorf("unknown report format %q, expected one of %s", format, strings.Join(ReportFormats, ", "))
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(f float64) string { return fmt.Sprintf("%.2f%%", 100*f) },
+	"time":    func(t time.Time) string { return t.Format(time.RFC3339) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Finality report {{time .From}} - {{time .To}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+</style>
+</head>
+<body>
+<h1>Finality report{{with .Period}} ({{.}}){{end}}</h1>
+<p>From {{time .From}} to {{time .To}}, generated at {{time .Generated}}.</p>
+<p>{{.Cycles}} poll cycles, finalization uptime {{percent .FinalizationUptime}}{{with .Skipped}}, {{.}} undecodable history lines skipped{{end}}.</p>
+<h2>Nodes</h2>
+<table>
+<tr><th>Node</th><th>Cycles</th><th>Finalization uptime</th><th>Error rate</th><th>Avg justified lag</th><th>Avg finalized lag</th><th>Max finalized lag</th><th>Finalized</th><th>Violations</th><th>Warnings</th></tr>
+{{range .Nodes}}<tr><td>{{with .Chain}}{{.}}/{{end}}{{.Node}}</td><td>{{.Cycles}}</td><td>{{percent .FinalizationUptime}}</td><td>{{percent .ErrorRate}}</td><td>{{printf "%.1f" .AvgJustifiedLag}}</td><td>{{printf "%.1f" .AvgFinalizedLag}}</td><td>{{.MaxFinalizedLag}}</td><td>{{.FirstFinalized}} - {{.LastFinalized}}</td><td>{{.Violations}}</td><td>{{.Warnings}}</td></tr>
+{{end}}</table>
+<h2>Violations</h2>
+{{if .Violations}}<table>
+<tr><th>Node</th><th>Check</th><th>Count</th><th>First</th><th>Last</th><th>Last error</th></tr>
+{{range .Violations}}<tr><td>{{with .Chain}}{{.}}/{{end}}{{.Node}}</td><td>{{.Check}}</td><td>{{.Count}}</td><td>{{time .First}}</td><td>{{time .Last}}</td><td>{{.LastError}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+</body>
+</html>
+`))
+
+// reportScheduler writes the report of every period once it elapsed.
+type reportScheduler struct {
+	period  string
+	dir     string
+	formats []string
+	history string
+	log     client.Logger
+}
+
+func newReportScheduler(cfg Config) (*reportScheduler, error) {
+	history, err := ReportHistory(cfg)
+	if err != nil {
+		return nil, err
+	}
+	reports := *cfg.Reports
+	if _, err := periodStart(reports.Period, time.Now()); err != nil {
+		return nil, err
+	}
+	formats := reports.Formats
+	if len(formats) == 0 {
+		formats = ReportFormats
+	}
+	for _, format := range formats {
+		if !slices.Contains(ReportFormats, format) {
+			return nil, fmt.Errorf("unknown report format %q, expected one of %s", format, strings.Join(ReportFormats, ", "))
+		}
+	}
+	if err := os.MkdirAll(reports.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating reports dir: %w", err)
+	}
+	return &reportScheduler{period: reports.Period, dir: reports.Dir, formats: formats, history: history}, nil
+}
+
+// next returns when the report of the current period is due.
+func (s *reportScheduler) next() time.Duration {
+	start, _ := periodStart(s.period, time.Now())
+	return time.Until(start.AddDate(0, 0, periodDays(s.period)).Add(reportDelay))
+}
+
+// write writes the report of the last elapsed period.
+func (s *reportScheduler) write() {
+	from, to, _ := ReportPeriod(s.period, time.Now())
+	report, err := BuildReport(s.history, from, to)
+	if err != nil {
+		s.log.Printf("Error building report: %v", err)
+		return
+	}
+	report.Period = s.period
+	for _, format := range s.formats {
+		path := filepath.Join(s.dir, s.period+"-"+from.Format(time.DateOnly)+"."+format)
+		if err := writeReport(path, report, format); err != nil {
+			s.log.Printf("Error writing report: %v", err)
+			continue
+		}
+		s.log.Printf("Report of %s written to %s", from.Format(time.DateOnly), path)
+	}
+}
+
+func writeReport(path string, report *Report, format string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.Encode(file, format); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
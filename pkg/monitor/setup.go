@@ -0,0 +1,346 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/paologalligit/justified/pkg/checks"
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// Setup loads the config at configPath with the preset of network, if any,
+// and resolves the chain parameters against the first node, returning a
+// client per configured and discovered node and the detected chain
+// parameters. It fails when there is no node at all.
+func Setup(configPath, network string) (Config, []*client.Client, checks.ChainParams, error) {
+	cfg, err := LoadConfig(configPath, network)
+	if err != nil {
+		return cfg, nil, checks.ChainParams{}, fmt.Errorf("error loading config: %w", err)
+	}
+	if len(cfg.Chains) > 0 {
+		return cfg, nil, checks.ChainParams{}, fmt.Errorf("config defines %d chains, expected a single one", len(cfg.Chains))
+	}
+	cfg, clients, detected, err := setupConfig(cfg)
+	if err == nil && len(clients) == 0 {
+		err = errors.New("no node configured or discovered")
+	}
+	return cfg, clients, detected, err
+}
+
+// Chain is a monitored chain with a client per node of its config.
+type Chain struct {
+	Config   Config
+	Clients  []*client.Client
+	Detected checks.ChainParams
+}
+
+// SetupChains is Setup for a config which may define several chains,
+// returning the top level config and every chain set up.
+func SetupChains(configPath, network string) (Config, []Chain, error) {
+	cfg, err := LoadConfig(configPath, network)
+	if err != nil {
+		return cfg, nil, fmt.Errorf("error loading config: %w", err)
+	}
+	chains := make([]Chain, 0, len(cfg.Chains))
+	for _, chainCfg := range cfg.chainConfigs() {
+		chain, err := setupChain(chainCfg)
+		if err != nil {
+			return cfg, nil, err
+		}
+		chains = append(chains, chain)
+	}
+	return cfg, chains, nil
+}
+
+// setupChain is SetupConfig returning a Chain, naming the chain in the logs
+// when it is one of several.
+func setupChain(cfg Config) (Chain, error) {
+	if cfg.Chain != "" {
+		fmt.Printf("Setting up chain %s\n", cfg.Chain)
+	}
+	cfg, clients, detected, err := SetupConfig(cfg)
+	if err != nil && cfg.Chain != "" {
+		err = fmt.Errorf("error setting up chain %s: %w", cfg.Chain, err)
+	}
+	return Chain{Config: cfg, Clients: clients, Detected: detected}, err
+}
+
+// SetupConfig is Setup for a config built by the caller. The nodes discovered
+// are verified and detected from too, but only the configured ones get a
+// client: the monitor discovers the nodes again when it starts.
+func SetupConfig(cfg Config) (Config, []*client.Client, checks.ChainParams, error) {
+	cfg, clients, detected, err := setupConfig(cfg)
+	if err != nil {
+		return cfg, nil, detected, err
+	}
+	return cfg, clients[:len(cfg.Nodes)], detected, nil
+}
+
+// setupConfig is SetupConfig returning a client per discovered node too,
+// after the configured ones.
+func setupConfig(cfg Config) (Config, []*client.Client, checks.ChainParams, error) {
+	nodes := cfg.Nodes
+	if d := cfg.discoverer(); d != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+		discovered, err := d.discover(ctx)
+		cancel()
+		if err != nil {
+			fmt.Println("Error discovering nodes: ", err)
+		}
+		nodes = (&chain{cfg: cfg, discovered: discovered}).nodes()
+	}
+	clients, err := newClients(cfg, nodes)
+	if err != nil {
+		return cfg, nil, checks.ChainParams{}, err
+	}
+
+	genesisID, err := verifyChains(context.Background(), cfg.GenesisID, clients)
+	if err != nil {
+		return cfg, nil, checks.ChainParams{}, err
+	}
+	cfg.GenesisID = genesisID
+
+	var detected checks.ChainParams
+	if len(clients) == 0 {
+		fmt.Println("No node to detect chain parameters from, using configured values")
+	} else if detected, err = checks.DetectChainParams(context.Background(), clients[0]); err != nil {
+		fmt.Println("Error detecting chain parameters, using configured values for the undetected ones: ", err)
+	}
+	if err := applyChainParams(&cfg, detected); err != nil {
+		return cfg, nil, detected, err
+	}
+	fmt.Printf("Chain parameters: block interval %ds, checkpoint interval %d, max block proposers %d\n",
+		cfg.BlockInterval, cfg.Thresholds.CheckpointInterval, cfg.MaxBlockProposers)
+
+	return cfg, clients, detected, nil
+}
+
+// errWrongChain is returned for the nodes on another chain than the monitored one.
+var errWrongChain = errors.New("wrong chain")
+
+// verifyChains makes sure every node is on the chain of genesisID, or on the
+// chain of the first node when genesisID is empty, and returns the genesis ID
+// of the chain. Nodes which cannot be reached are reported and not verified.
+func verifyChains(ctx context.Context, genesisID string, clients []*client.Client) (string, error) {
+	genesisID = strings.ToLower(genesisID)
+	for _, c := range clients {
+		id, err := verifyChain(ctx, genesisID, c)
+		if errors.Is(err, errWrongChain) {
+			return genesisID, err
+		}
+		if err != nil {
+			fmt.Printf("Error verifying the chain of node %s: %v\n", c.Name, err)
+			continue
+		}
+		if genesisID == "" {
+			genesisID = id
+			fmt.Printf("Chain genesis %s, chain tag %s\n", id, chainTag(id))
+		}
+	}
+	return genesisID, nil
+}
+
+// verifyChain returns the genesis ID of the chain of c, failing with
+// errWrongChain when it is not genesisID, unless genesisID is empty.
+func verifyChain(ctx context.Context, genesisID string, c *client.Client) (string, error) {
+	genesis, err := c.GetBlockByNumber(ctx, 0)
+	if err != nil {
+		return "", fmt.Errorf("error getting genesis block: %w", err)
+	}
+	id := strings.ToLower(genesis.ID)
+	if genesisID != "" && id != genesisID {
+		return id, fmt.Errorf("%w: node %s is on chain %s with chain tag %s, expected %s with chain tag %s",
+			errWrongChain, c.Name, id, chainTag(id), genesisID, chainTag(genesisID))
+	}
+	return id, nil
+}
+
+// chainTag returns the chain tag of the chain of genesisID: the last byte of the ID.
+func chainTag(genesisID string) string {
+	if len(genesisID) < 2 {
+		return "unknown"
+	}
+	return "0x" + genesisID[len(genesisID)-2:]
+}
+
+// applyChainParams resolves the chain parameters of cfg against the detected
+// ones and validates the checks configuration depending on them.
+func applyChainParams(cfg *Config, detected checks.ChainParams) error {
+	if cfg.MaxBlockProposers == 0 && detected.MaxBlockProposers == 0 {
+		// the bft thresholds of the checks are derived from them.
+		fmt.Printf("Warning: max block proposers neither configured nor detected, using the default of %d\n", checks.InitialMaxBlockProposers)
+	}
+	params, err := checks.ResolveChainParams(cfg.ChainParams(), detected)
+	if err != nil {
+		return fmt.Errorf("error resolving chain parameters: %w", err)
+	}
+	cfg.SetChainParams(params)
+	return checks.ValidateSeverities(cfg.Config)
+}
+
+// newClients returns a client per node, configured by cfg.
+func newClients(cfg Config, nodes []NodeConfig) ([]*client.Client, error) {
+	clients := make([]*client.Client, 0, len(nodes))
+	for _, node := range nodes {
+		c, err := newClient(cfg, node)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, c)
+	}
+	return clients, nil
+}
+
+func newClient(cfg Config, node NodeConfig) (*client.Client, error) {
+	transport := newTransport(cfg.Transport)
+	if node.TLS != nil {
+		tlsConfig, err := node.TLS.Load()
+		if err != nil {
+			return nil, fmt.Errorf("error loading TLS config of node %s: %w", node.Name, err)
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	if node.Proxy != "" {
+		proxy, err := url.Parse(node.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing proxy of node %s: %w", node.Name, err)
+		}
+		if proxy.Scheme != "http" && proxy.Scheme != "https" && proxy.Scheme != "socks5" {
+			return nil, fmt.Errorf("unsupported proxy scheme %q of node %s", proxy.Scheme, node.Name)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if node.Dialer != nil {
+		dial, err := node.Dialer.Load(newDialer(cfg.Transport))
+		if err != nil {
+			return nil, fmt.Errorf("error loading dialer of node %s: %w", node.Name, err)
+		}
+		transport.DialContext = dial
+	}
+	if node.API != "" && node.API != "rest" && node.API != "graphql" {
+		return nil, fmt.Errorf("unsupported api %q of node %s", node.API, node.Name)
+	}
+	endpoint := func(url string) client.Backend {
+		// Requests are bounded by the per-request and per-cycle contexts instead of a client timeout.
+		httpClient := &http.Client{Transport: transport}
+		baseURL := url
+		if socket, ok := client.UnixSocket(url); ok {
+			unix := transport.Clone()
+			unix.DialContext = client.DialUnix(socket)
+			unix.Proxy = nil // the socket is local, whatever the proxy.
+			httpClient.Transport = unix
+			baseURL = client.UnixBaseURL
+		}
+		if cfg.Tracing != nil {
+			httpClient.Transport = otelhttp.NewTransport(httpClient.Transport)
+		}
+		var b client.Backend
+		if node.API == "graphql" {
+			b = client.NewGraphQLBackend(httpClient, baseURL, cfg.RequestTimeout.Duration, node.Auth, cfg.MaxResponseSize)
+		} else {
+			rest := client.NewHTTPBackend(httpClient, baseURL, cfg.RequestTimeout.Duration, node.Auth, cfg.MaxResponseSize)
+			rest.SetLogger(logLine)
+			b = rest
+		}
+		if limiter := requestLimiter(cfg.RateLimit); limiter != nil {
+			b = client.NewLimitedBackend(b, limiter)
+		}
+		// Retried under the circuit breaker, which only sees the last attempt.
+		if retry := cfg.Retry.policy(url); retry.Attempts > 0 {
+			b = client.NewRetryBackend(b, retry.Attempts, firstNonZero(retry.Backoff.Duration, defaultRetryBackoff))
+		}
+		if cfg.CircuitBreaker.Failures > 0 {
+			breaker := client.NewBreakerBackend(url, b, cfg.CircuitBreaker.Failures, cfg.CircuitBreaker.Cooldown.Duration)
+			breaker.SetLogger(logLine)
+			b = breaker
+		}
+		return b
+	}
+
+	members := func(urls []string) []client.QuorumMember {
+		members := make([]client.QuorumMember, 0, len(urls))
+		for _, url := range urls {
+			members = append(members, client.QuorumMember{Name: url, Backend: endpoint(url)})
+		}
+		return members
+	}
+	switch {
+	case len(node.Quorum) > 0:
+		return client.New(node.Name, client.NewQuorumBackend(members(node.Quorum))), nil
+	case len(node.Failover) > 0:
+		failover := client.NewFailoverBackend(node.Name, members(node.Failover))
+		failover.SetLogger(logLine)
+		return client.New(node.Name, failover), nil
+	}
+	return client.New(node.Name, endpoint(node.URL)), nil
+}
+
+// logLine prints the events logged by the clients and the pollers.
+func logLine(format string, args ...any) {
+	fmt.Printf(format+"\n", args...)
+}
+
+// newDialer returns the dialer of the connections to the nodes, with the
+// timeouts of the default transport unless configured.
+func newDialer(cfg TransportConfig) net.Dialer {
+	return net.Dialer{
+		Timeout:   firstNonZero(cfg.DialTimeout.Duration, 30*time.Second),
+		KeepAlive: firstNonZero(cfg.KeepAlive.Duration, 30*time.Second),
+	}
+}
+
+// requestLimiters are the limiters of the rate limits, shared by the clients
+// of every chain and those created on discovery or reload.
+var (
+	limitersMu      sync.Mutex
+	requestLimiters = make(map[RateLimit]*client.Limiter)
+)
+
+// requestLimiter returns the limiter of limit, nil when disabled.
+func requestLimiter(limit RateLimit) *client.Limiter {
+	if limit.RequestsPerSecond <= 0 {
+		return nil
+	}
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	limiter, ok := requestLimiters[limit]
+	if !ok {
+		limiter = client.NewLimiter(limit.RequestsPerSecond, limit.Burst)
+		requestLimiters[limit] = limiter
+	}
+	return limiter
+}
+
+// newTransport returns the default transport tuned by cfg.
+func newTransport(cfg TransportConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+		transport.MaxIdleConns = max(transport.MaxIdleConns, cfg.MaxIdleConnsPerHost)
+	}
+	transport.MaxConnsPerHost = cfg.MaxConnsPerHost
+	if cfg.IdleConnTimeout.Duration > 0 {
+		transport.IdleConnTimeout = cfg.IdleConnTimeout.Duration
+	}
+	if cfg.DialTimeout.Duration > 0 || cfg.KeepAlive.Duration > 0 {
+		dialer := newDialer(cfg)
+		transport.DialContext = dialer.DialContext
+	}
+	transport.DisableKeepAlives = cfg.DisableKeepAlives
+	if cfg.DisableHTTP2 {
+		// A non-nil empty map keeps the transport from upgrading to HTTP/2.
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	return transport
+}
@@ -0,0 +1,345 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/paologalligit/justified/pkg/checks"
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// Sink consumes the result of every poll cycle with the checks it failed.
+type Sink interface {
+	Write(r checks.BlockResult, outcomes []checks.Outcome) error
+	Close() error
+}
+
+// SinksConfig selects the outputs the results are written to, on top of stdout.
+type SinksConfig struct {
+	// File is the path results are appended to as JSON lines, disabled when empty.
+	File   string            `json:"file"`
+	Kafka  *KafkaSinkConfig  `json:"kafka"`
+	NATS   *NATSSinkConfig   `json:"nats"`
+	MQTT   *MQTTSinkConfig   `json:"mqtt"`
+	Redis  *RedisSinkConfig  `json:"redis"`
+	Influx *InfluxSinkConfig `json:"influx"`
+	StatsD *StatsDSinkConfig `json:"statsd"`
+	Exec   *ExecSinkConfig   `json:"exec"`
+	// FileFormat is json (default), JSON lines, or protobuf, Record messages
+	// of record.proto each prefixed with its varint length.
+	FileFormat string `json:"fileFormat"`
+	// Alerts configures the alerts sent by the alerters from the failed checks.
+	Alerts AlertsConfig `json:"alerts"`
+	// Hooks are fired when a checkpoint is newly justified or finalized.
+	Hooks HooksConfig `json:"hooks"`
+	// Forensics archives the blocks around the fatal violations, disabled when unset.
+	Forensics *ForensicsConfig `json:"forensics"`
+	// Buffer is how many results may wait for a slow sink before new ones are dropped.
+	Buffer int `json:"buffer"`
+}
+
+// Record is the serialized form of a poll cycle written by the sinks.
+type Record struct {
+	Result   checks.BlockResult `json:"result"`
+	Errors   []string           `json:"errors,omitempty"`
+	Outcomes []OutcomeRecord    `json:"outcomes,omitempty"`
+}
+
+// OutcomeRecord is the serialized form of a failed check.
+type OutcomeRecord struct {
+	Node     string          `json:"node"`
+	Name     string          `json:"name"`
+	Severity checks.Severity `json:"severity"`
+	Error    string          `json:"error"`
+}
+
+func newRecord(r checks.BlockResult, outcomes []checks.Outcome) Record {
+	rec := Record{Result: r}
+	for _, err := range r.Errs() {
+		rec.Errors = append(rec.Errors, err.Error())
+	}
+	for _, outcome := range outcomes {
+		rec.Outcomes = append(rec.Outcomes, OutcomeRecord{Node: outcome.Node, Name: outcome.Name, Severity: outcome.Severity, Error: outcome.Err.Error()})
+	}
+	return rec
+}
+
+// Verbosity levels of the stdout output.
+const (
+	VerbosityQuiet   = -1 // only the failed checks.
+	VerbosityNormal  = 0  // the failed checks and the new justified and finalized blocks.
+	VerbosityVerbose = 1  // the failed checks and every poll cycle.
+	VerbosityDebug   = 2  // the failed checks and the full record of every poll cycle.
+)
+
+// stdoutSink prints the failed checks and, depending on the verbosity, the
+// poll cycles. On a terminal the output is colorized and followed by a status
+// line updated in place.
+type stdoutSink struct {
+	verbosity int
+	last      map[string][2]uint32 // justified and finalized block by node.
+	status    *statusLine          // nil when stdout is not a terminal.
+}
+
+func newStdoutSink(verbosity int) *stdoutSink {
+	s := &stdoutSink{verbosity: verbosity, last: make(map[string][2]uint32)}
+	if colorTerminal() {
+		s.status = &statusLine{nodes: make(map[string]string)}
+	}
+	return s
+}
+
+func (s *stdoutSink) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	switch {
+	case s.verbosity >= VerbosityDebug:
+		data, err := json.Marshal(newRecord(r, outcomes))
+		if err != nil {
+			return err
+		}
+		s.println("", string(data))
+	case s.verbosity >= VerbosityVerbose:
+		s.println("", r.String())
+	case s.verbosity >= VerbosityNormal && r.Fetched(checks.FieldJustified|checks.FieldFinalized):
+		if last, ok := s.last[r.Node]; !ok || last != [2]uint32{r.Justified, r.Finalized} {
+			line := fmt.Sprintf("Node %s: justified block %d, finalized block %d", r.Node, r.Justified, r.Finalized)
+			if r.Epoch != nil {
+				line += ", next " + epochStatus(r.Epoch)
+			}
+			s.println(ansiGreen, line)
+			s.last[r.Node] = [2]uint32{r.Justified, r.Finalized}
+		}
+	}
+
+	for _, outcome := range outcomes {
+		if outcome.Severity == checks.SeverityFatal {
+			s.println(ansiRed, fmt.Sprintf("Error: check %s failed on %s: %v", outcome.Name, outcome.Node, outcome.Err))
+			continue
+		}
+		s.println(ansiYellow, fmt.Sprintf("Warning: check %s failed on %s: %v", outcome.Name, outcome.Node, outcome.Err))
+	}
+
+	if s.status != nil {
+		s.status.update(r, outcomes)
+		s.status.render()
+	}
+	return nil
+}
+
+// println prints line in color on a terminal, plain otherwise.
+func (s *stdoutSink) println(color, line string) {
+	if s.status == nil {
+		fmt.Println(line)
+		return
+	}
+	s.status.clear()
+	if color != "" {
+		line = color + line + ansiReset
+	}
+	fmt.Println(line)
+}
+
+func (s *stdoutSink) Close() error {
+	if s.status != nil {
+		s.status.clear()
+	}
+	syncStdout()
+	return nil
+}
+
+// syncStdout flushes stdout to disk. Syncing fails when stdout is a pipe or a
+// terminal, which need no flush, so the error is ignored.
+func syncStdout() {
+	os.Stdout.Sync()
+}
+
+// unclosed is a sink outliving the dispatchers it is added to, closed by its owner.
+type unclosed struct{ Sink }
+
+func (unclosed) Close() error { return nil }
+
+// fileSink appends every record to a file as a JSON line or a length
+// delimited protobuf message.
+type fileSink struct {
+	file     *os.File
+	enc      *json.Encoder
+	protobuf bool
+}
+
+func newFileSink(path, format string) (*fileSink, error) {
+	if format != "" && format != "json" && format != "protobuf" {
+		return nil, fmt.Errorf("unknown file format %q", format)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening output file: %w", err)
+	}
+	return &fileSink{file: file, enc: json.NewEncoder(file), protobuf: format == "protobuf"}, nil
+}
+
+func (s *fileSink) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	if !s.protobuf {
+		return s.enc.Encode(newRecord(r, outcomes))
+	}
+	msg := marshalRecordProto(newRecord(r, outcomes))
+	_, err := s.file.Write(protowire.AppendBytes(nil, msg))
+	return err
+}
+
+func (s *fileSink) Close() error {
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
+
+// newSinks starts a dispatcher writing to the metrics and the sinks enabled in cfg.
+func newSinks(cfg SinksConfig, metrics *chainMetrics) (*dispatcher, error) {
+	d := newDispatcher(cfg.Buffer)
+	d.add("metrics", metrics)
+
+	if cfg.File != "" {
+		file, err := newFileSink(cfg.File, cfg.FileFormat)
+		if err != nil {
+			d.Close()
+			return nil, err
+		}
+		d.add("file", file)
+	}
+	if cfg.Kafka != nil {
+		kafka, err := newKafkaSink(*cfg.Kafka)
+		if err != nil {
+			d.Close()
+			return nil, err
+		}
+		d.add("kafka", kafka)
+	}
+	if cfg.NATS != nil {
+		nats, err := newNATSSink(*cfg.NATS)
+		if err != nil {
+			d.Close()
+			return nil, err
+		}
+		d.add("nats", nats)
+	}
+	if cfg.MQTT != nil {
+		mqtt, err := newMQTTSink(*cfg.MQTT)
+		if err != nil {
+			d.Close()
+			return nil, err
+		}
+		d.add("mqtt", mqtt)
+	}
+	if cfg.Redis != nil {
+		redis, err := newRedisSink(*cfg.Redis)
+		if err != nil {
+			d.Close()
+			return nil, err
+		}
+		d.add("redis", redis)
+	}
+	if cfg.Influx != nil {
+		influx, err := newInfluxSink(*cfg.Influx)
+		if err != nil {
+			d.Close()
+			return nil, err
+		}
+		d.add("influx", influx)
+	}
+	if cfg.StatsD != nil {
+		statsd, err := newStatsDSink(*cfg.StatsD)
+		if err != nil {
+			d.Close()
+			return nil, err
+		}
+		d.add("statsd", statsd)
+	}
+	if cfg.Exec != nil {
+		hook, err := newExecSink(*cfg.Exec)
+		if err != nil {
+			d.Close()
+			return nil, err
+		}
+		d.add("exec", hook)
+	}
+	return d, nil
+}
+
+// sinkEntry is a poll cycle queued for a sink.
+type sinkEntry struct {
+	result   checks.BlockResult
+	outcomes []checks.Outcome
+}
+
+// dispatcher fans every poll cycle out to the sinks. Each sink consumes its
+// own queue, so a slow sink neither blocks the monitor nor the other sinks.
+type dispatcher struct {
+	buffer int
+	names  []string
+	queues []chan sinkEntry
+	sinks  []Sink
+	wg     sync.WaitGroup
+	once   sync.Once
+	log    client.Logger
+}
+
+// defaultSinkBuffer is the queue length of a sink when not configured.
+const defaultSinkBuffer = 64
+
+func newDispatcher(buffer int) *dispatcher {
+	return &dispatcher{buffer: firstNonZero(buffer, defaultSinkBuffer)}
+}
+
+// add starts consuming the poll cycles with sink.
+func (d *dispatcher) add(name string, sink Sink) {
+	queue := make(chan sinkEntry, d.buffer)
+	d.names = append(d.names, name)
+	d.queues = append(d.queues, queue)
+	d.sinks = append(d.sinks, sink)
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		for entry := range queue {
+			if err := sink.Write(entry.result, entry.outcomes); err != nil {
+				d.log.Printf("Error writing to %s sink: %v", name, err)
+			}
+		}
+	}()
+}
+
+// dispatch queues a poll cycle for every sink, dropping it for the sinks whose queue is full.
+func (d *dispatcher) dispatch(r checks.BlockResult, outcomes []checks.Outcome) {
+	for i, queue := range d.queues {
+		select {
+		case queue <- sinkEntry{result: r, outcomes: outcomes}:
+		default:
+			d.log.Printf("Dropping result of %s at %s: %s sink is full", r.Node, r.Time.Format(time.RFC3339), d.names[i])
+		}
+	}
+}
+
+// Close waits for the queued poll cycles to be written and closes the sinks.
+func (d *dispatcher) Close() error {
+	var firstErr error
+	d.once.Do(func() {
+		for _, queue := range d.queues {
+			close(queue)
+		}
+		d.wg.Wait()
+		for i, sink := range d.sinks {
+			if err := sink.Close(); err != nil {
+				d.log.Printf("Error closing %s sink: %v", d.names[i], err)
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+	})
+	return firstErr
+}
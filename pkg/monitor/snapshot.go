@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// defaultSnapshotInterval is how often the state is snapshotted by default.
+const defaultSnapshotInterval = time.Minute
+
+// SnapshotConfig periodically snapshots the state of the pollers and checks
+// of every node, to resume the statistics and the checks comparing poll
+// cycles when the monitor restarts.
+type SnapshotConfig struct {
+	File     string   `json:"file"`     // disabled when empty.
+	Interval Duration `json:"interval"` // between snapshots, defaults to 1m. One is taken on exit too.
+}
+
+// snapshot is the JSON document of the snapshot file.
+type snapshot struct {
+	Time  time.Time               `json:"time"`
+	Nodes map[string]nodeSnapshot `json:"nodes"`
+}
+
+type nodeSnapshot struct {
+	Poller checks.PollerState `json:"poller"`
+	State  *nodeState         `json:"state"` // the poll cycles replayed into the checks.
+}
+
+// snapshotter keeps the latest state of the poller of every node to snapshot
+// it, and the snapshot loaded on startup until the nodes resume from it.
+type snapshotter struct {
+	path     string
+	interval time.Duration
+	pollers  map[string]*checks.PollerState
+	restored map[string]nodeSnapshot
+}
+
+// loadSnapshotter reads the snapshot at cfg.File, if any.
+func loadSnapshotter(cfg SnapshotConfig) (*snapshotter, error) {
+	s := &snapshotter{
+		path:     cfg.File,
+		interval: firstNonZero(cfg.Interval.Duration, defaultSnapshotInterval),
+		pollers:  make(map[string]*checks.PollerState),
+		restored: make(map[string]nodeSnapshot),
+	}
+	data, err := os.ReadFile(cfg.File)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot file: %w", err)
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("error decoding snapshot file: %w", err)
+	}
+	for name, node := range snap.Nodes {
+		if node.State != nil {
+			s.restored[name] = node
+		}
+	}
+	fmt.Printf("Restoring the state of %d nodes snapshotted at %s\n", len(s.restored), snap.Time.Format(time.RFC3339))
+	return s, nil
+}
+
+// resume restores the poller and checks of node from the snapshot loaded on
+// startup, once, returning the state of the node to record its next poll
+// cycles in.
+func (s *snapshotter) resume(node string, poller *checks.Poller, nodeChecks []checks.Check) (*nodeState, bool) {
+	restored, ok := s.restored[node]
+	if !ok {
+		return nil, false
+	}
+	delete(s.restored, node)
+	poller.Restore(restored.Poller)
+	// The checks of a cycle with errors did not all run, so it is not replayed.
+	results := make([]checks.BlockResult, 0, len(restored.State.Recent))
+	for _, record := range restored.State.Recent {
+		if len(record.Errors) == 0 {
+			results = append(results, record.Result)
+		}
+	}
+	checks.Replay(nodeChecks, results)
+	if restored.State.Failures == nil {
+		restored.State.Failures = make(map[string]int)
+	}
+	return restored.State, true
+}
+
+// write snapshots the latest state of the pollers and of the nodes.
+func (s *snapshotter) write(nodes map[string]*nodeState) error {
+	snap := snapshot{Time: time.Now(), Nodes: make(map[string]nodeSnapshot, len(s.pollers))}
+	for name, state := range s.pollers {
+		if node, ok := nodes[name]; ok {
+			snap.Nodes[name] = nodeSnapshot{Poller: *state, State: node}
+		}
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	// Renaming keeps the previous file whole if the monitor dies meanwhile.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("error writing snapshot file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("error writing snapshot file: %w", err)
+	}
+	return nil
+}
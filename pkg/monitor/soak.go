@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// soakTest records the violations of a run bounded in time to give a verdict
+// at its end.
+type soakTest struct {
+	duration   time.Duration
+	deadline   <-chan time.Time
+	started    time.Time
+	cycles     int
+	violations []Violation
+}
+
+// Violation is a failed check of a soak test.
+type Violation struct {
+	Time     time.Time       `json:"time"`
+	Node     string          `json:"node"`
+	Check    string          `json:"check"`
+	Severity checks.Severity `json:"severity"`
+	Error    string          `json:"error"`
+}
+
+// Verdict is the outcome of a soak test. It passes when no fatal check failed.
+type Verdict struct {
+	Passed     bool        `json:"passed"`
+	Started    time.Time   `json:"started"`
+	Duration   Duration    `json:"duration"`
+	Cycles     int         `json:"cycles"`
+	Violations []Violation `json:"violations"`
+	// Aborted is set when a node exhausted the error budget before the end.
+	Aborted  string `json:"aborted,omitempty"`
+	ExitCode int    `json:"exitCode"`
+}
+
+func newSoakTest(duration time.Duration) *soakTest {
+	return &soakTest{duration: duration, deadline: time.After(duration), started: time.Now(), violations: []Violation{}}
+}
+
+func (s *soakTest) record(r checks.BlockResult, outcomes []checks.Outcome) {
+	s.cycles++
+	for _, outcome := range outcomes {
+		s.violations = append(s.violations, Violation{Time: r.Time, Node: outcome.Node, Check: outcome.Name, Severity: outcome.Severity, Error: outcome.Err.Error()})
+	}
+}
+
+// verdict concludes the soak test, aborted by exhausted if not nil.
+func (s *soakTest) verdict(exhausted *checks.Outcome) Verdict {
+	v := Verdict{Passed: true, Started: s.started, Duration: Duration{time.Since(s.started).Round(time.Second)}, Cycles: s.cycles, Violations: s.violations}
+	for _, violation := range s.violations {
+		if violation.Severity == checks.SeverityFatal {
+			v.Passed = false
+			v.ExitCode = ExitCheckViolation
+		}
+	}
+	if exhausted != nil {
+		v.Passed = false
+		v.Aborted = fmt.Sprintf("error budget exhausted by check %s on %s", exhausted.Name, exhausted.Node)
+		v.ExitCode = ExitCheckViolation
+		if exhausted.Name == checks.FetchErrors {
+			v.ExitCode = ExitNodeUnreachable
+		}
+	}
+	return v
+}
+
+// Print writes the verdict to stdout as indented JSON.
+func (v Verdict) Print() {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Println("Error encoding verdict: ", err)
+		return
+	}
+	os.Stdout.Write(append(data, '\n'))
+}
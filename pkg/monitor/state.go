@@ -0,0 +1,123 @@
+package monitor
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// StateDumpConfig configures the state dumped on SIGUSR1.
+type StateDumpConfig struct {
+	File    string `json:"file"`    // written to stderr when empty.
+	History int    `json:"history"` // poll cycles kept per node, defaults to 32.
+}
+
+// defaultStateHistory is how many poll cycles are kept per node when not configured.
+const defaultStateHistory = 32
+
+// stateRecorder keeps the recent poll cycles and check failures of every node
+// to dump them for debugging a live monitor.
+type stateRecorder struct {
+	history int
+	nodes   map[string]*nodeState
+}
+
+type nodeState struct {
+	Cycles   int            `json:"cycles"`
+	Failures map[string]int `json:"failures"` // by check.
+	// Recent are the latest poll cycles, oldest first.
+	Recent []Record `json:"recent"`
+}
+
+func newStateRecorder(history int) *stateRecorder {
+	return &stateRecorder{history: firstNonZero(history, defaultStateHistory), nodes: make(map[string]*nodeState)}
+}
+
+func (s *stateRecorder) record(r checks.BlockResult, outcomes []checks.Outcome) {
+	n, ok := s.nodes[r.Node]
+	if !ok {
+		n = &nodeState{Failures: make(map[string]int)}
+		s.nodes[r.Node] = n
+	}
+	n.Cycles++
+	for _, outcome := range outcomes {
+		n.Failures[outcome.Name]++
+	}
+	n.Recent = append(n.Recent, newRecord(r, outcomes))
+	if len(n.Recent) > s.history {
+		n.Recent = n.Recent[len(n.Recent)-s.history:]
+	}
+}
+
+// stateDump is the JSON document written on SIGUSR1.
+type stateDump struct {
+	Time   time.Time             `json:"time"`
+	Config Config                `json:"config"`
+	Nodes  map[string]*nodeState `json:"nodes"`
+	// ConsecutiveFatal are the poll cycles in a row with a fatal failure by
+	// node, counted against the error budget.
+	ConsecutiveFatal map[string]int                `json:"consecutiveFatal"`
+	Health           healthStatus                  `json:"health"`
+	Fleet            map[string]checks.BlockResult `json:"fleet"` // latest result of every node compared across the fleet.
+}
+
+// redacted returns cfg without the credentials of the sinks and nodes.
+func redacted(cfg Config) Config {
+	cfg.Sinks = SinksConfig{}
+	cfg.Nodes = slices.Clone(cfg.Nodes)
+	for i := range cfg.Nodes {
+		cfg.Nodes[i].Auth = cfg.Nodes[i].Auth.Redacted()
+	}
+	if cfg.DNSDiscovery != nil {
+		discovery := *cfg.DNSDiscovery
+		discovery.Auth = discovery.Auth.Redacted()
+		cfg.DNSDiscovery = &discovery
+	}
+	if cfg.KubernetesDiscovery != nil {
+		discovery := *cfg.KubernetesDiscovery
+		discovery.Auth = discovery.Auth.Redacted()
+		cfg.KubernetesDiscovery = &discovery
+	}
+	return cfg
+}
+
+// dumpState writes the internal state of the monitor as JSON.
+func (m *Monitor) dumpState() {
+	cfg := redacted(m.cfg)
+	cfg.Chains = slices.Clone(cfg.Chains)
+	for i := range cfg.Chains {
+		cfg.Chains[i].Config = redacted(cfg.Chains[i].Config)
+	}
+	fleet := make(map[string]checks.BlockResult)
+	for _, c := range m.chains {
+		maps.Copy(fleet, c.fleet.Latest)
+	}
+	dump := stateDump{
+		Time:             time.Now(),
+		Config:           cfg,
+		Nodes:            m.state.nodes,
+		ConsecutiveFatal: m.budget.consecutive,
+		Health:           m.health.status(true),
+		Fleet:            fleet,
+	}
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		m.log.Printf("Error encoding state: %v", err)
+		return
+	}
+	data = append(data, '\n')
+
+	if m.cfg.StateDump.File == "" {
+		os.Stderr.Write(data)
+		return
+	}
+	if err := os.WriteFile(m.cfg.StateDump.File, data, 0o644); err != nil {
+		m.log.Printf("Error writing state: %v", err)
+		return
+	}
+	m.log.Printf("State written to %s", m.cfg.StateDump.File)
+}
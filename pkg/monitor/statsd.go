@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// StatsDSinkConfig emits heights, lags and check failures over StatsD.
+type StatsDSinkConfig struct {
+	Addr   string `json:"addr"`   // defaults to 127.0.0.1:8125.
+	Prefix string `json:"prefix"` // defaults to justified.
+	// DogStatsD sends the node, check and severity as tags, along with Tags.
+	// Plain StatsD has no tags, so they are part of the metric name instead.
+	DogStatsD bool     `json:"dogStatsD"`
+	Tags      []string `json:"tags"` // e.g. ["env:prod"].
+}
+
+// maxStatsDPacket keeps the datagrams below the usual MTU.
+const maxStatsDPacket = 1400
+
+type statsdSink struct {
+	conn   net.Conn
+	prefix string
+	dog    bool
+	tags   []string
+}
+
+func newStatsDSink(cfg StatsDSinkConfig) (*statsdSink, error) {
+	conn, err := net.Dial("udp", firstNonZero(cfg.Addr, "127.0.0.1:8125"))
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to statsd: %w", err)
+	}
+	return &statsdSink{conn: conn, prefix: firstNonZero(cfg.Prefix, "justified"), dog: cfg.DogStatsD, tags: cfg.Tags}, nil
+}
+
+func (s *statsdSink) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	var lines []string
+	metric := func(name, value, kind string, tags ...string) {
+		if r.Chain != "" {
+			tags = append([]string{"chain:" + r.Chain}, tags...)
+		}
+		if s.dog {
+			tags = append(tags, s.tags...)
+			lines = append(lines, fmt.Sprintf("%s.%s:%s|%s|#%s", s.prefix, name, value, kind, strings.Join(tags, ",")))
+			return
+		}
+		path := []string{s.prefix}
+		for _, tag := range tags {
+			path = append(path, statsdName(tag[strings.IndexByte(tag, ':')+1:]))
+		}
+		lines = append(lines, fmt.Sprintf("%s.%s:%s|%s", strings.Join(path, "."), name, value, kind))
+	}
+
+	node := "node:" + r.Node
+	if r.Fetched(checks.FieldBest) {
+		metric("height.best", fmt.Sprint(r.Best), "g", node)
+	}
+	if r.Fetched(checks.FieldJustified) {
+		metric("height.justified", fmt.Sprint(r.Justified), "g", node)
+	}
+	if r.Fetched(checks.FieldFinalized) {
+		metric("height.finalized", fmt.Sprint(r.Finalized), "g", node)
+	}
+	if r.Fetched(checks.FieldBest | checks.FieldJustified) {
+		metric("lag.justified", fmt.Sprint(int64(r.Best)-int64(r.Justified)), "g", node)
+	}
+	if r.Fetched(checks.FieldBest | checks.FieldFinalized) {
+		metric("lag.finalized", fmt.Sprint(int64(r.Best)-int64(r.Finalized)), "g", node)
+	}
+	if r.TimedOut {
+		metric("cycle_timeouts", "1", "c", node)
+	}
+	for _, outcome := range outcomes {
+		metric("check_failures", "1", "c", node, "check:"+outcome.Name, "severity:"+outcome.Severity.String())
+	}
+
+	return s.send(lines)
+}
+
+// send writes lines in as few datagrams as possible.
+func (s *statsdSink) send(lines []string) error {
+	var packet strings.Builder
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxStatsDPacket {
+			if _, err := s.conn.Write([]byte(packet.String())); err != nil {
+				return err
+			}
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if packet.Len() == 0 {
+		return nil
+	}
+	_, err := s.conn.Write([]byte(packet.String()))
+	return err
+}
+
+func (s *statsdSink) Close() error {
+	return s.conn.Close()
+}
+
+// statsdName replaces the characters StatsD uses as separators in metric names.
+func statsdName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// summary accumulates the statistics of a run, printed when the monitor exits
+// or on SIGUSR2.
+type summary struct {
+	started time.Time
+	cycles  int
+	checks  map[string]*checkStats
+	nodes   map[string]*nodeSummary
+}
+
+type checkStats struct {
+	Run    int // zero for the fleet checks, which are only counted when failing.
+	Failed int
+}
+
+type nodeSummary struct {
+	Cycles          int
+	Errors          int // poll cycles with at least one error.
+	FirstBest       uint32
+	LastBest        uint32
+	MaxFinalizedLag int64
+	MaxJustifiedLag int64
+}
+
+func newSummary() *summary {
+	return &summary{started: time.Now(), checks: make(map[string]*checkStats), nodes: make(map[string]*nodeSummary)}
+}
+
+func (s *summary) check(name string) *checkStats {
+	c, ok := s.checks[name]
+	if !ok {
+		c = &checkStats{}
+		s.checks[name] = c
+	}
+	return c
+}
+
+// record accounts a poll cycle, the node checks run against it and the outcomes.
+func (s *summary) record(r checks.BlockResult, nodeChecks []checks.Check, outcomes []checks.Outcome) {
+	s.cycles++
+	for _, check := range nodeChecks {
+		if r.Fetched(check.Needs) {
+			s.check(check.Name).Run++
+		}
+	}
+	for _, outcome := range outcomes {
+		s.check(outcome.Name).Failed++
+	}
+
+	n, ok := s.nodes[r.Node]
+	if !ok {
+		n = &nodeSummary{}
+		s.nodes[r.Node] = n
+	}
+	n.Cycles++
+	if r.Err() != nil {
+		n.Errors++
+	}
+	if r.Fetched(checks.FieldBest) {
+		if n.FirstBest == 0 {
+			n.FirstBest = r.Best
+		}
+		n.LastBest = r.Best
+	}
+	if r.Fetched(checks.FieldBest | checks.FieldFinalized) {
+		n.MaxFinalizedLag = max(n.MaxFinalizedLag, int64(r.Best)-int64(r.Finalized))
+	}
+	if r.Fetched(checks.FieldBest | checks.FieldJustified) {
+		n.MaxJustifiedLag = max(n.MaxJustifiedLag, int64(r.Best)-int64(r.Justified))
+	}
+}
+
+func (s *summary) print(w io.Writer) {
+	fmt.Fprintf(w, "Summary: %d poll cycles in %s\n", s.cycles, time.Since(s.started).Round(time.Second))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tCYCLES\tERROR RATE\tBLOCKS\tMAX JUSTIFIED LAG\tMAX FINALIZED LAG")
+	for _, name := range sortedKeys(s.nodes) {
+		n := s.nodes[name]
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\t[%d, %d]\t%d\t%d\n", name, n.Cycles, float64(n.Errors)*100/float64(n.Cycles), n.FirstBest, n.LastBest, n.MaxJustifiedLag, n.MaxFinalizedLag)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(tw, "CHECK\tPASSED\tFAILED")
+	for _, name := range sortedKeys(s.checks) {
+		c := s.checks[name]
+		passed := "-"
+		if c.Run > 0 {
+			passed = fmt.Sprint(c.Run - c.Failed)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", name, passed, c.Failed)
+	}
+	tw.Flush()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,96 @@
+package monitor
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/paologalligit/justified/pkg/client"
+)
+
+// The default delays before restarting a crashed producer.
+const (
+	defaultRestartBackoff    = time.Second
+	defaultMaxRestartBackoff = time.Minute
+)
+
+// SupervisorConfig tunes the restarts of the producers which panicked, so
+// that a misbehaving node does not take the monitoring of the others down.
+// It is read at startup only.
+type SupervisorConfig struct {
+	// Backoff is the delay before the first restart, doubled at every crash
+	// up to MaxBackoff, and reset once the producer ran for MaxBackoff.
+	Backoff    Duration `json:"backoff"`    // defaults to 1s.
+	MaxBackoff Duration `json:"maxBackoff"` // defaults to 1m.
+}
+
+// supervisor restarts the producers and counts their crashes.
+type supervisor struct {
+	backoff    time.Duration
+	maxBackoff time.Duration
+	crashes    *prometheus.CounterVec
+	log        client.Logger
+}
+
+func newSupervisor(cfg SupervisorConfig) *supervisor {
+	backoff := firstNonZero(cfg.Backoff.Duration, defaultRestartBackoff)
+	return &supervisor{
+		backoff:    backoff,
+		maxBackoff: max(firstNonZero(cfg.MaxBackoff.Duration, defaultMaxRestartBackoff), backoff),
+		crashes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "justified_producer_crashes_total",
+			Help: "Number of times the producer of a node panicked and was restarted.",
+		}, []string{"node"}),
+	}
+}
+
+// register registers the metrics of the supervisor with reg.
+func (s *supervisor) register(reg prometheus.Registerer) {
+	reg.MustRegister(s.crashes)
+}
+
+// remove deletes the crash count of node, no longer monitored.
+func (s *supervisor) remove(node string) {
+	s.crashes.DeleteLabelValues(node)
+}
+
+// run calls produce until it returns without panicking, restarting it with
+// backoff after every panic until ctx is done.
+func (s *supervisor) run(ctx context.Context, node string, produce func(ctx context.Context)) {
+	backoff := s.backoff
+	for crashes := 1; ; crashes++ {
+		started := time.Now()
+		panicked, stack := recovered(func() { produce(ctx) })
+		if panicked == nil {
+			return
+		}
+		s.crashes.WithLabelValues(node).Inc()
+		if time.Since(started) >= s.maxBackoff {
+			backoff = s.backoff
+		}
+		s.log.Printf("Error polling %s, producer crashed %d times, restarting in %s: %v\n%s", node, crashes, backoff, panicked, stack)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+		backoff = min(2*backoff, s.maxBackoff)
+	}
+}
+
+// recovered calls f, returning what it panicked with and the stack of the
+// panic, or nil when it returned.
+func recovered(f func()) (panicked any, stack []byte) {
+	defer func() {
+		if panicked = recover(); panicked != nil {
+			stack = debug.Stack()
+		}
+	}()
+	f()
+	return nil, nil
+}
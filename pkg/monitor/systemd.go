@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// systemdNotifier implements the sd_notify protocol, telling systemd when the
+// monitor is ready and pinging its watchdog. A nil notifier does nothing, as
+// when the monitor is not run by systemd.
+type systemdNotifier struct {
+	conn     *net.UnixConn
+	interval time.Duration // between two watchdog pings, zero without watchdog.
+	last     time.Time
+}
+
+// newSystemdNotifier connects to $NOTIFY_SOCKET, returning nil when unset.
+func newSystemdNotifier() (*systemdNotifier, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil, nil
+	}
+	if socket[0] == '@' {
+		// abstract socket.
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	n := &systemdNotifier{conn: conn}
+	// Ping twice per watchdog period, as recommended by sd_watchdog_enabled(3).
+	if usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64); err == nil && usec > 0 {
+		if pid := os.Getenv("WATCHDOG_PID"); pid == "" || pid == strconv.Itoa(os.Getpid()) {
+			n.interval = time.Duration(usec) * time.Microsecond / 2
+		}
+	}
+	return n, nil
+}
+
+func (n *systemdNotifier) notify(state string) error {
+	if n == nil {
+		return nil
+	}
+	_, err := n.conn.Write([]byte(state))
+	return err
+}
+
+// watchdog pings the watchdog, at most once per interval.
+func (n *systemdNotifier) watchdog() error {
+	if n == nil || n.interval == 0 || time.Since(n.last) < n.interval {
+		return nil
+	}
+	n.last = time.Now()
+	return n.notify("WATCHDOG=1")
+}
@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/checks"
+)
+
+// tapSink prints every check of every poll cycle as a Test Anything Protocol
+// test point. Failed warnings are marked TODO so that they do not fail the
+// harness, and checks missing the blocks they need are skipped. Since the
+// number of cycles is not known in advance, the plan is printed on Close.
+type tapSink struct {
+	checks []checks.Check
+	fleet  []checks.FleetCheck
+
+	once sync.Once
+	n    int
+}
+
+func newTAPSink(cfg Config) *tapSink {
+	return &tapSink{checks: checks.New(cfg.Config), fleet: checks.NewFleetChecks(cfg.Config)}
+}
+
+func (s *tapSink) Write(r checks.BlockResult, outcomes []checks.Outcome) error {
+	s.once.Do(func() { fmt.Println("TAP version 13") })
+
+	failed := make(map[string]checks.Outcome, len(outcomes))
+	for _, outcome := range outcomes {
+		failed[outcome.Name] = outcome
+	}
+	for _, check := range s.checks {
+		s.point(r, check.Name, r.Fetched(check.Needs), failed)
+	}
+	for _, check := range s.fleet {
+		s.point(r, check.Name, r.Fetched(checks.FieldJustified|checks.FieldFinalized), failed)
+	}
+	return nil
+}
+
+func (s *tapSink) point(r checks.BlockResult, check string, ran bool, failed map[string]checks.Outcome) {
+	s.n++
+	description := fmt.Sprintf("%s %s at block %d", r.Node, check, r.Best)
+	outcome, ok := failed[check]
+	switch {
+	case ok && outcome.Severity == checks.SeverityFatal:
+		fmt.Printf("not ok %d - %s\n", s.n, description)
+	case ok:
+		fmt.Printf("not ok %d - %s # TODO %s\n", s.n, description, outcome.Severity)
+	case !ran:
+		fmt.Printf("ok %d - %s # SKIP blocks not fetched\n", s.n, description)
+		return
+	default:
+		fmt.Printf("ok %d - %s\n", s.n, description)
+		return
+	}
+	fmt.Println("  ---")
+	fmt.Printf("  message: %q\n", outcome.Err.Error())
+	fmt.Printf("  severity: %s\n", outcome.Severity)
+	fmt.Printf("  at: %s\n", r.Time.Format(time.RFC3339))
+	fmt.Println("  ...")
+}
+
+func (s *tapSink) Close() error {
+	s.once.Do(func() { fmt.Println("TAP version 13") })
+	fmt.Printf("1..%d\n", s.n)
+	syncStdout()
+	return nil
+}
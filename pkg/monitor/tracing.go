@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingConfig exports a trace per poll cycle over OTLP/HTTP, with child
+// spans for every request to the node and every check.
+type TracingConfig struct {
+	Endpoint    string  `json:"endpoint"`    // host:port of the collector, defaults to localhost:4318.
+	Insecure    bool    `json:"insecure"`    // use http instead of https.
+	SampleRatio float64 `json:"sampleRatio"` // fraction of poll cycles traced, all of them when zero.
+	ServiceName string  `json:"serviceName"` // defaults to justified.
+}
+
+// tracer records the spans of the monitor. It does nothing unless tracing is configured.
+var tracer = otel.Tracer("github.com/paologalligit/justified/pkg/monitor")
+
+// tracingShutdownTimeout bounds the export of the pending spans on exit.
+const tracingShutdownTimeout = 5 * time.Second
+
+// SetupTracing installs the OTLP exporter described by cfg, if any, and
+// returns a function flushing the pending spans.
+func SetupTracing(cfg *TracingConfig) (func(), error) {
+	if cfg == nil {
+		return func() {}, nil
+	}
+
+	opts := []otlptracehttp.Option{}
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating trace exporter: %w", err)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(firstNonZero(cfg.ServiceName, "justified")))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			fmt.Println("Error flushing traces: ", err)
+		}
+	}, nil
+}
+
+// endSpan records err on span, if any, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+
+	"github.com/paologalligit/justified/pkg/monitor"
+)
+
+// pushAuditMetrics pushes the outcome of an audit of node, grouped by node.
+func pushAuditMetrics(cfg monitor.PushgatewayConfig, node string, report *auditReport, duration time.Duration) error {
+	reg := prometheus.NewRegistry()
+
+	blocks := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "justified_audit_blocks",
+		Help: "Blocks audited by the last audit.",
+	})
+	bounds := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "justified_audit_block_height",
+		Help: "First, last and finalized block of the last audit.",
+	}, []string{"block"})
+	findings := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "justified_audit_findings",
+		Help: "Inconsistencies found by the last audit.",
+	}, []string{"kind"})
+	elapsed := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "justified_audit_duration_seconds",
+		Help: "Duration of the last audit.",
+	})
+	completed := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "justified_audit_last_completion_timestamp_seconds",
+		Help: "Time the last audit completed.",
+	})
+	reg.MustRegister(blocks, bounds, findings, elapsed, completed)
+
+	blocks.Set(float64(report.Audited))
+	bounds.WithLabelValues("from").Set(float64(report.From))
+	bounds.WithLabelValues("to").Set(float64(report.To))
+	bounds.WithLabelValues("finalized").Set(float64(report.Finalized))
+	findings.WithLabelValues("fetch").Set(float64(len(report.Fetch)))
+	findings.WithLabelValues("linkage").Set(float64(len(report.Linkage)))
+	findings.WithLabelValues("flags").Set(float64(len(report.Flags)))
+	findings.WithLabelValues("alignment").Set(float64(len(report.Alignment)))
+	elapsed.Set(duration.Seconds())
+	completed.SetToCurrentTime()
+
+	err := push.New(cfg.URL, cmp.Or(cfg.Job, "justified")).
+		Grouping("node", node).
+		Gatherer(reg).
+		Push()
+	if err != nil {
+		return fmt.Errorf("error pushing metrics: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/monitor"
+)
+
+// runReport renders the report of a period from the history of the poll
+// cycles written to sinks.file.
+func runReport(args []string) int {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to the JSON configuration file whose sinks.file is read")
+	history := fs.String("history", "", "path of the JSON lines history of the poll cycles, overrides sinks.file of the config")
+	period := fs.String("period", "daily", "period reported, in UTC: "+strings.Join(monitor.ReportPeriods, ", "))
+	date := fs.String("date", "", "day in the period reported as YYYY-MM-DD, defaults to the last completed period")
+	format := fs.String("format", "json", "format of the report: "+strings.Join(monitor.ReportFormats, ", "))
+	out := fs.String("out", "", "path the report is written to, stdout when empty")
+	fs.Parse(args)
+
+	if !slices.Contains(monitor.ReportFormats, *format) {
+		fmt.Printf("Unknown -format %q, expected one of %s\n", *format, strings.Join(monitor.ReportFormats, ", "))
+		return 1
+	}
+	if *history == "" {
+		cfg, err := monitor.LoadConfig(*configPath, "")
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		if *history, err = monitor.ReportHistory(cfg); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+	}
+
+	at := time.Now()
+	if *date != "" {
+		day, err := time.Parse(time.DateOnly, *date)
+		if err != nil {
+			fmt.Println("Invalid -date: ", err)
+			return 1
+		}
+		// the period completed at the end of the day includes it.
+		at = day.AddDate(0, 0, 1)
+		if *period == "weekly" {
+			at = day.AddDate(0, 0, 7)
+		}
+	}
+	from, to, err := monitor.ReportPeriod(*period, at)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	report, err := monitor.BuildReport(*history, from, to)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	report.Period = *period
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			fmt.Println("Error creating report file: ", err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := report.Encode(w, *format); err != nil {
+		fmt.Println("Error writing report: ", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/paologalligit/justified/pkg/client"
+	"github.com/paologalligit/justified/pkg/monitor"
+)
+
+// runWait blocks until a block becomes finalized, exiting with 0 once it is
+// and with 1 when the timeout elapses or the wait is interrupted first.
+func runWait(args []string) int {
+	fs := flag.NewFlagSet("wait", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to the JSON configuration file")
+	network := fs.String("network", "", "preset of a known network filling the unset settings: "+strings.Join(monitor.NetworkNames(), ", "))
+	number := fs.Uint("block", 0, "number of the block to wait for")
+	timeout := fs.Duration("timeout", 0, "how long to wait before giving up, forever when 0")
+	nodeName := fs.String("node", "", "name of the node to query, defaults to the first configured one")
+	fs.Parse(args)
+
+	if *number == 0 {
+		fmt.Println("Missing -block")
+		return 1
+	}
+
+	cfg, clients, _, err := monitor.Setup(*configPath, *network)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	node := clients[0]
+	if *nodeName != "" {
+		if node = client.Find(clients, *nodeName); node == nil {
+			fmt.Printf("Unknown node %q\n", *nodeName)
+			return 1
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, *timeout, fmt.Errorf("timed out after %s", *timeout))
+		defer cancel()
+	}
+
+	block, err := waitFinalized(ctx, node, uint32(*number), time.Duration(cfg.BlockInterval)*time.Second)
+	if err != nil {
+		fmt.Printf("Block %d not finalized: %v\n", *number, err)
+		return 1
+	}
+	fmt.Printf("Block %d %s is finalized\n", block.Number, block.ID)
+	return 0
+}
+
+// waitFinalized polls the finalized block every interval until it reaches
+// number, returning the now irreversible block at number.
+func waitFinalized(ctx context.Context, node *client.Client, number uint32, interval time.Duration) (client.JSONBlockSummary, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last uint32
+	for {
+		finalized, err := node.GetFinalizedBlock(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return client.JSONBlockSummary{}, context.Cause(ctx)
+		case err != nil:
+			fmt.Println("Error getting finalized block: ", err)
+		case finalized.Number >= number:
+			block, err := node.GetBlockByNumber(ctx, number)
+			if err != nil {
+				fmt.Printf("Error getting block %d: %v\n", number, err)
+				break
+			}
+			return block, nil
+		case finalized.Number != last:
+			fmt.Printf("Finalized block %d, waiting for %d\n", finalized.Number, number)
+			last = finalized.Number
+		}
+
+		select {
+		case <-ctx.Done():
+			return client.JSONBlockSummary{}, context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+}